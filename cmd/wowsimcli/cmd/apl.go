@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/wowsims/sod/sim/core"
+	"github.com/wowsims/sod/sim/core/proto"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var aplWrite bool
+
+var aplCmd = &cobra.Command{
+	Use:   "apl",
+	Short: "convert and check rotations in the apl text format",
+	Long:  "convert rotations between the apl text format and APLRotation json and check them for mistakes, use - to read from stdin",
+}
+
+var aplFmtCmd = &cobra.Command{
+	Use:          "fmt [file]",
+	Short:        "reformat an apl text file",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rotation, err := readAPLText(args[0])
+		if err != nil {
+			return err
+		}
+		text := core.APLRotationToText(rotation)
+		if aplWrite && args[0] != "-" {
+			return os.WriteFile(args[0], []byte(text), 0666)
+		}
+		fmt.Print(text)
+		return nil
+	},
+}
+
+var aplParseCmd = &cobra.Command{
+	Use:          "parse [file]",
+	Short:        "convert an apl text file to APLRotation json",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rotation, err := readAPLText(args[0])
+		if err != nil {
+			return err
+		}
+		output, err := protojson.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(rotation)
+		if err != nil {
+			return fmt.Errorf("failed to marshal rotation: %w", err)
+		}
+		return writeAPLOutput(append(output, '\n'))
+	},
+}
+
+var aplPrintCmd = &cobra.Command{
+	Use:          "print [file]",
+	Short:        "convert an APLRotation json file to apl text",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rotation, err := readAPLJSON(args[0])
+		if err != nil {
+			return err
+		}
+		return writeAPLOutput([]byte(core.APLRotationToText(rotation)))
+	},
+}
+
+var aplLintCmd = &cobra.Command{
+	Use:          "lint [file]",
+	Short:        "check an apl text or json file for mistakes",
+	Long:         "check an apl text or APLRotation json file for mistakes, using it as the rotation of the first player in the RaidSimRequest from --infile",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var rotation *proto.APLRotation
+		var err error
+		if strings.HasSuffix(args[0], ".json") {
+			rotation, err = readAPLJSON(args[0])
+		} else {
+			rotation, err = readAPLText(args[0])
+		}
+		if err != nil {
+			return err
+		}
+
+		data, err := os.ReadFile(infile)
+		if err != nil {
+			return fmt.Errorf("failed to load input json file %q: %w", infile, err)
+		}
+		input := &proto.RaidSimRequest{}
+		if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, input); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", infile, err)
+		}
+		if len(input.GetRaid().GetParties()) == 0 || len(input.Raid.Parties[0].Players) == 0 {
+			return fmt.Errorf("%s has no player to use the rotation", infile)
+		}
+		input.Raid.Parties[0].Players[0].Rotation = rotation
+
+		result := core.ComputeStats(&proto.ComputeStatsRequest{Raid: input.Raid, Encounter: input.Encounter})
+		if result.ErrorResult != "" {
+			return fmt.Errorf("failed to compute stats: %s", result.ErrorResult)
+		}
+
+		numWarnings := 0
+		printWarnings := func(location string, warnings []string) {
+			for _, warning := range warnings {
+				fmt.Printf("%s: %s: %s\n", args[0], location, warning)
+				numWarnings++
+			}
+		}
+		stats := result.RaidStats.Parties[0].Players[0].RotationStats
+		for i, variable := range stats.GetVariables() {
+			printWarnings(fmt.Sprintf("variable %s", rotation.Variables[i].Name), variable.Warnings)
+		}
+		for i, item := range stats.GetPrepullActions() {
+			printWarnings(fmt.Sprintf("prepull item %d", i+1), item.Warnings)
+		}
+		for i, item := range stats.GetPriorityList() {
+			printWarnings(fmt.Sprintf("item %d", i+1), item.Warnings)
+		}
+		for i, list := range stats.GetActionLists() {
+			name := rotation.ActionLists[i].Name
+			printWarnings(fmt.Sprintf("list %s", name), list.Warnings)
+			for j, item := range list.Items {
+				printWarnings(fmt.Sprintf("list %s item %d", name, j+1), item.Warnings)
+			}
+		}
+
+		if numWarnings > 0 {
+			return fmt.Errorf("found %d warnings", numWarnings)
+		}
+		return nil
+	},
+}
+
+func init() {
+	aplFmtCmd.Flags().BoolVarP(&aplWrite, "write", "w", false, "write the result back to the file instead of stdout")
+	aplParseCmd.Flags().StringVar(&outfile, "outfile", "", "location of output file, defaults to stdout")
+	aplPrintCmd.Flags().StringVar(&outfile, "outfile", "", "location of output file, defaults to stdout")
+	aplLintCmd.Flags().StringVar(&infile, "infile", "input.json", "location of input file (RaidSimRequest in protojson format)")
+
+	aplCmd.AddCommand(aplFmtCmd)
+	aplCmd.AddCommand(aplParseCmd)
+	aplCmd.AddCommand(aplPrintCmd)
+	aplCmd.AddCommand(aplLintCmd)
+}
+
+func readAPLInput(filename string) ([]byte, error) {
+	if filename == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(filename)
+}
+
+func readAPLText(filename string) (*proto.APLRotation, error) {
+	data, err := readAPLInput(filename)
+	if err != nil {
+		return nil, err
+	}
+	rotation, err := core.APLRotationFromText(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", filename, err)
+	}
+	return rotation, nil
+}
+
+func readAPLJSON(filename string) (*proto.APLRotation, error) {
+	data, err := readAPLInput(filename)
+	if err != nil {
+		return nil, err
+	}
+	rotation := &proto.APLRotation{}
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, rotation); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	return rotation, nil
+}
+
+func writeAPLOutput(output []byte) error {
+	if outfile == "" {
+		_, err := os.Stdout.Write(output)
+		return err
+	}
+	return os.WriteFile(outfile, output, 0666)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"text/tabwriter"
 
 	"github.com/spf13/cobra"
 	"github.com/wowsims/sod/sim/core"
@@ -11,6 +12,10 @@ import (
 	"google.golang.org/protobuf/encoding/protojson"
 )
 
+var combatlogfile string
+var resists bool
+var encounterfile string
+
 var simCmd = &cobra.Command{
 	Use:   "sim",
 	Short: "simulate items & settings",
@@ -21,6 +26,9 @@ func init() {
 	simCmd.Flags().StringVar(&infile, "infile", "input.json", "location of input file (RaidSimRequest in protojson format)")
 	simCmd.Flags().StringVar(&outfile, "outfile", "", "location of output file, defaults to stdout")
 	simCmd.Flags().BoolVar(&verbose, "verbose", false, "print information during runtime")
+	simCmd.Flags().StringVar(&combatlogfile, "combatlog", "", "location of file to write the first iteration's combat log to, one CombatLogEvent in protojson format per line")
+	simCmd.Flags().BoolVar(&resists, "resists", false, "print a per action breakdown of partial resists, binary misses and mitigated damage to stderr")
+	simCmd.Flags().StringVar(&encounterfile, "encounter", "", "location of a PresetEncounter in protojson format, such as a scripted encounter, whose targets replace the input's encounter targets")
 	simCmd.MarkFlagRequired("infile")
 }
 
@@ -35,6 +43,16 @@ func simMain(cmd *cobra.Command, args []string) {
 	if err != nil {
 		log.Fatalf("failed to load input json file: %s", err)
 	}
+	if encounterfile != "" {
+		setEncounterTargets(input, encounterfile)
+	}
+	if combatlogfile != "" {
+		if input.SimOptions == nil {
+			input.SimOptions = &proto.SimOptions{}
+		}
+		input.SimOptions.DebugFirstIteration = true
+		input.SimOptions.CombatLog = true
+	}
 
 	var output []byte
 	reporter := make(chan *proto.ProgressMetrics, 10)
@@ -51,6 +69,14 @@ func simMain(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	if combatlogfile != "" {
+		writeCombatLog(combatlogfile, finalResult.CombatLog)
+	}
+
+	if resists {
+		printResistBreakdown(finalResult)
+	}
+
 	output, err = protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(finalResult)
 	if err != nil {
 		log.Fatalf("failed to marshal final results: %s", err)
@@ -68,3 +94,86 @@ func simMain(cmd *cobra.Command, args []string) {
 		}
 	}
 }
+
+// Replaces the encounter targets of the request with those of a preset encounter file, so
+// scripted encounters can be simmed without rebuilding the sim.
+func setEncounterTargets(input *proto.RaidSimRequest, filename string) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		log.Fatalf("failed to load encounter file %q: %v", filename, err)
+	}
+	encounter, err := core.ParsePresetEncounterJSON(data)
+	if err != nil {
+		log.Fatalf("invalid encounter file %q: %s", filename, err)
+	}
+
+	if input.Encounter == nil {
+		input.Encounter = &proto.Encounter{}
+	}
+	input.Encounter.Targets = nil
+	for _, presetTarget := range encounter.Targets {
+		input.Encounter.Targets = append(input.Encounter.Targets, presetTarget.Target)
+	}
+	if verbose {
+		fmt.Printf("Loaded %d targets from encounter %s.\n", len(input.Encounter.Targets), encounter.Path)
+	}
+}
+
+func writeCombatLog(filename string, events []*proto.CombatLogEvent) {
+	var lines []byte
+	for _, event := range events {
+		line, err := protojson.Marshal(event)
+		if err != nil {
+			log.Fatalf("failed to marshal combat log: %s", err)
+		}
+		lines = append(append(lines, line...), '\n')
+	}
+
+	if err := os.WriteFile(filename, lines, 0666); err != nil {
+		log.Fatalf("failed to write combat log file: %s", err)
+	}
+	if verbose {
+		fmt.Printf("Wrote %d combat log events to `%s` successfully.\n", len(events), filename)
+	}
+}
+
+// Prints each player's actions that were resisted or mitigated, with totals over all iterations
+// and targets.
+func printResistBreakdown(result *proto.RaidSimResult) {
+	w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', tabwriter.AlignRight)
+	defer w.Flush()
+
+	for _, party := range result.GetRaidMetrics().GetParties() {
+		for _, player := range party.GetPlayers() {
+			fmt.Fprintf(w, "%s\n", player.Name)
+			fmt.Fprintf(w, "Action\tMisses\tBinary Misses\t25%%\t50%%\t75%%\tArmor Mitigated\tResist Mitigated\tResisted %%\t\n")
+
+			for _, action := range player.Actions {
+				var total proto.TargetedActionMetrics
+				for _, target := range action.Targets {
+					total.Misses += target.Misses
+					total.BinaryMisses += target.BinaryMisses
+					total.PartialResists_1_4 += target.PartialResists_1_4
+					total.PartialResists_2_4 += target.PartialResists_2_4
+					total.PartialResists_3_4 += target.PartialResists_3_4
+					total.Damage += target.Damage
+					total.ArmorMitigated += target.ArmorMitigated
+					total.ResistMitigated += target.ResistMitigated
+				}
+				if total.BinaryMisses == 0 && total.ArmorMitigated == 0 && total.ResistMitigated == 0 {
+					continue
+				}
+
+				resisted := 0.0
+				if unmitigated := total.Damage + total.ArmorMitigated + total.ResistMitigated; unmitigated > 0 {
+					resisted = total.ResistMitigated / unmitigated * 100
+				}
+				fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%.0f\t%.0f\t%.2f%%\t\n",
+					core.ProtoToActionID(action.Id), total.Misses, total.BinaryMisses,
+					total.PartialResists_1_4, total.PartialResists_2_4, total.PartialResists_3_4,
+					total.ArmorMitigated, total.ResistMitigated, resisted)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+}
@@ -20,6 +20,9 @@ var (
 	replacefile string
 	outfile     string
 	verbose     bool
+
+	checkpointfile string
+	resume         bool
 )
 
 var bulkCmd = &cobra.Command{
@@ -34,6 +37,8 @@ func init() {
 	bulkCmd.Flags().StringVar(&replacefile, "replacefile", "", "location of replacement items file. Writes a CSV result of the items replaced instead of JSON")
 	bulkCmd.Flags().StringVar(&outfile, "output", "", "location of output file, defaults to stdout")
 	bulkCmd.Flags().BoolVar(&verbose, "verbose", false, "print information during runtime")
+	bulkCmd.Flags().StringVar(&checkpointfile, "checkpoint", "", "location of checkpoint file to save completed combos to as the sim runs")
+	bulkCmd.Flags().BoolVar(&resume, "resume", false, "skip combos already saved to the checkpoint file by an interrupted run with the same inputs")
 	bulkCmd.MarkFlagRequired("infile")
 	bulkCmd.MarkFlagRequired("replacefile")
 }
@@ -50,7 +55,11 @@ func bulkSimMain(cmd *cobra.Command, args []string) {
 		log.Fatalf("failed to load input json file: %s", err)
 	}
 
-	output := BulkSim(input, replacefile, verbose)
+	if resume && checkpointfile == "" {
+		log.Fatalf("--resume requires --checkpoint")
+	}
+
+	output := BulkSim(input, replacefile, checkpointfile, resume, verbose)
 
 	if outfile == "" {
 		print(string(output))
@@ -76,7 +85,7 @@ type ReplaceIter struct {
 	Slots []proto.ItemSlot // Slots for each sub item
 }
 
-func BulkSim(input *proto.RaidSimRequest, replaceFile string, verbose bool) string {
+func BulkSim(input *proto.RaidSimRequest, replaceFile string, checkpointFile string, resume bool, verbose bool) string {
 	// 1. Load up all the sim data we need
 	replaceData, err := os.ReadFile(replaceFile)
 	if err != nil {
@@ -98,7 +107,7 @@ func BulkSim(input *proto.RaidSimRequest, replaceFile string, verbose bool) stri
 		},
 	}
 	progress := make(chan *proto.ProgressMetrics, 100)
-	core.RunBulkSimAsync(context.Background(), bsr, progress)
+	core.RunBulkSimAsyncWithCheckpoint(context.Background(), bsr, progress, checkpointFile, resume)
 
 	startTime := time.Now()
 
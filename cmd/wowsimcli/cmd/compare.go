@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/wowsims/sod/sim/core"
+	"github.com/wowsims/sod/sim/core/proto"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var (
+	baselinefile string
+	variantfiles []string
+)
+
+var compareCmd = &cobra.Command{
+	Use:   "compare",
+	Short: "compare variants against a baseline with paired iterations",
+	Long:  "sim each variant with the same random seeds as the baseline, and report the per-iteration dps difference with its 95% confidence interval",
+	Run:   compareMain,
+}
+
+func init() {
+	compareCmd.Flags().StringVar(&baselinefile, "baseline", "", "location of baseline input file (RaidSimRequest in protojson format), its sim options are used for every variant")
+	compareCmd.Flags().StringArrayVar(&variantfiles, "variant", nil, "location of a variant input file (RaidSimRequest in protojson format), can be repeated")
+	compareCmd.Flags().StringVar(&outfile, "outfile", "", "location of output file to write the CompareSimsResult to in protojson format")
+	compareCmd.Flags().BoolVar(&verbose, "verbose", false, "print information during runtime")
+	compareCmd.MarkFlagRequired("baseline")
+	compareCmd.MarkFlagRequired("variant")
+}
+
+func loadRaidSimRequest(filename string) *proto.RaidSimRequest {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		log.Fatalf("failed to load input json file %q: %v", filename, err)
+	}
+	request := &proto.RaidSimRequest{}
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, request); err != nil {
+		log.Fatalf("failed to load input json file %q: %s", filename, err)
+	}
+	return request
+}
+
+func compareMain(cmd *cobra.Command, args []string) {
+	request := &proto.CompareSimsRequest{
+		Baseline: loadRaidSimRequest(baselinefile),
+	}
+	for _, variantfile := range variantfiles {
+		request.Variants = append(request.Variants, loadRaidSimRequest(variantfile))
+	}
+
+	progress := make(chan *proto.ProgressMetrics, 100)
+	core.RunCompareSimsAsync(request, progress)
+
+	var result *proto.CompareSimsResult
+	for status := range progress {
+		if status.FinalCompareResult != nil {
+			result = status.FinalCompareResult
+			break
+		}
+		if verbose && status.TotalIterations > 0 {
+			fmt.Printf("Sim Progress: %d / %d\n", status.CompletedIterations, status.TotalIterations)
+		}
+	}
+	if result.ErrorResult != "" {
+		log.Fatalf("compare failed: %s", result.ErrorResult)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(w, "\tDPS\tDelta\tStdev\t95%% CI\t\n")
+	fmt.Fprintf(w, "%s\t%.2f\t\t\t\t\n", filepath.Base(baselinefile), result.BaselineDps)
+	for i, variant := range result.Variants {
+		fmt.Fprintf(w, "%s\t%.2f\t%+.2f\t%.2f\t[%+.2f, %+.2f]\t\n", filepath.Base(variantfiles[i]),
+			variant.Dps, variant.DpsDelta, variant.DpsDeltaStdev, variant.DpsDeltaCiLow, variant.DpsDeltaCiHigh)
+	}
+	w.Flush()
+
+	if outfile != "" {
+		output, err := protojson.Marshal(result)
+		if err != nil {
+			log.Fatalf("failed to marshal compare results: %s", err)
+		}
+		if err := os.WriteFile(outfile, output, 0666); err != nil {
+			log.Fatalf("failed to write output file: %s", err)
+		}
+		if verbose {
+			fmt.Printf("Wrote output file: `%s` successfully.\n", outfile)
+		}
+	}
+}
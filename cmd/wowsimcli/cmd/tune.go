@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/wowsims/sod/sim/core"
+	"github.com/wowsims/sod/sim/core/proto"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var (
+	tuneMethod     string
+	tuneIterations int32
+	tuneMaxRounds  int32
+	tuneTop        int
+)
+
+var tuneCmd = &cobra.Command{
+	Use:   "tune",
+	Short: "search for the best values of the tunable constants in the rotations",
+	Long:  "sim candidate values for every APL constant marked as tunable, with the same random seeds for each candidate, and report the values which give the most dps",
+	Run:   tuneMain,
+}
+
+func init() {
+	tuneCmd.Flags().StringVar(&infile, "infile", "input.json", "location of input file (RaidSimRequest in protojson format), its sim options are used for every candidate")
+	tuneCmd.Flags().StringVar(&tuneMethod, "method", "grid", "search method: 'grid' to sim every combination of values, or 'descent' to tune one constant at a time")
+	tuneCmd.Flags().Int32Var(&tuneIterations, "iterations", 0, "iterations per candidate, defaults to 1000")
+	tuneCmd.Flags().Int32Var(&tuneMaxRounds, "max-rounds", 0, "maximum number of passes over all constants for the descent method, defaults to 10")
+	tuneCmd.Flags().IntVar(&tuneTop, "top", 10, "number of candidates to print")
+	tuneCmd.Flags().StringVar(&outfile, "outfile", "", "location of output file to write the TuneRotationResult to in protojson format")
+	tuneCmd.Flags().BoolVar(&verbose, "verbose", false, "print information during runtime")
+}
+
+func tuneMain(cmd *cobra.Command, args []string) {
+	settings := &proto.TuneRotationSettings{
+		IterationsPerCandidate: tuneIterations,
+		MaxRounds:              tuneMaxRounds,
+	}
+	switch tuneMethod {
+	case "grid":
+		settings.Method = proto.TuneRotationSettings_GridSearch
+	case "descent":
+		settings.Method = proto.TuneRotationSettings_CoordinateDescent
+	default:
+		log.Fatalf("unknown search method %q, expected 'grid' or 'descent'", tuneMethod)
+	}
+
+	request := &proto.TuneRotationRequest{
+		BaseSettings: loadRaidSimRequest(infile),
+		TuneSettings: settings,
+	}
+
+	progress := make(chan *proto.ProgressMetrics, 100)
+	core.RunTuneRotationAsync(context.Background(), request, progress)
+
+	var result *proto.TuneRotationResult
+	for status := range progress {
+		if status.FinalTuneResult != nil {
+			result = status.FinalTuneResult
+			break
+		}
+		if verbose && status.TotalSims > 0 {
+			fmt.Printf("Sim Progress: %d / %d sims\n", status.CompletedSims, status.TotalSims)
+		}
+	}
+	if result.ErrorResult != "" {
+		log.Fatalf("tune failed: %s", result.ErrorResult)
+	}
+
+	fmt.Printf("Simmed %d candidates\n", result.CandidatesSimmed)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(w, "\t%s\tDPS\tDelta\t95%% CI\t\n", strings.Join(result.Names, "\t"))
+	printCandidate := func(label string, candidate *proto.TuneRotationCandidate) {
+		fmt.Fprintf(w, "%s\t%s\t%.2f\t%+.2f\t[%+.2f, %+.2f]\t\n", label, strings.Join(candidate.Values, "\t"),
+			candidate.Dps, candidate.DpsDelta, candidate.DpsDeltaCiLow, candidate.DpsDeltaCiHigh)
+	}
+	printCandidate("baseline", result.Baseline)
+	for i, candidate := range result.Candidates {
+		if i >= tuneTop {
+			break
+		}
+		printCandidate(fmt.Sprintf("#%d", i+1), candidate)
+	}
+	w.Flush()
+
+	if outfile != "" {
+		output, err := protojson.Marshal(result)
+		if err != nil {
+			log.Fatalf("failed to marshal tune results: %s", err)
+		}
+		if err := os.WriteFile(outfile, output, 0666); err != nil {
+			log.Fatalf("failed to write output file: %s", err)
+		}
+		if verbose {
+			fmt.Printf("Wrote output file: `%s` successfully.\n", outfile)
+		}
+	}
+}
@@ -53,6 +53,38 @@ func RunRaidSimAsync(request *proto.RaidSimRequest, progress chan *proto.Progres
 	go RunSim(request, progress)
 }
 
+/**
+ * Runs each variant and the baseline with identical seeds, and compares their dps iteration by iteration.
+ */
+func RunCompareSims(request *proto.CompareSimsRequest) *proto.CompareSimsResult {
+	return CompareSims(request, nil)
+}
+
+func RunCompareSimsAsync(request *proto.CompareSimsRequest, progress chan *proto.ProgressMetrics) {
+	go func() {
+		result := CompareSims(request, progress)
+		progress <- &proto.ProgressMetrics{
+			FinalCompareResult: result,
+		}
+	}()
+}
+
+/**
+ * Sims the tunable constants of the raid's rotations with paired seeds, and returns the values which give the most dps.
+ */
+func RunTuneRotation(request *proto.TuneRotationRequest) *proto.TuneRotationResult {
+	return TuneRotation(context.Background(), request, nil)
+}
+
+func RunTuneRotationAsync(ctx context.Context, request *proto.TuneRotationRequest, progress chan *proto.ProgressMetrics) {
+	go func() {
+		result := TuneRotation(ctx, request, progress)
+		progress <- &proto.ProgressMetrics{
+			FinalTuneResult: result,
+		}
+	}()
+}
+
 func RunBulkSim(request *proto.BulkSimRequest) *proto.BulkSimResult {
 	return BulkSim(context.Background(), request, nil)
 }
@@ -60,3 +92,7 @@ func RunBulkSim(request *proto.BulkSimRequest) *proto.BulkSimResult {
 func RunBulkSimAsync(ctx context.Context, request *proto.BulkSimRequest, progress chan *proto.ProgressMetrics) {
 	go BulkSim(ctx, request, progress)
 }
+
+func RunBulkSimAsyncWithCheckpoint(ctx context.Context, request *proto.BulkSimRequest, progress chan *proto.ProgressMetrics, checkpointFile string, resume bool) {
+	go BulkSimWithCheckpoint(ctx, request, progress, checkpointFile, resume)
+}
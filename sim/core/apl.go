@@ -13,6 +13,12 @@ type APLRotation struct {
 	prepullActions []*APLAction
 	priorityList   []*APLAction
 
+	// Index of the config item each priority list action was built from.
+	priorityListIdxs []int
+
+	// Only set when APL profiling is enabled.
+	profile *aplProfile
+
 	// Action currently controlling this rotation (only used for certain actions, such as StrictSequence).
 	controllingActions []APLActionImpl
 
@@ -29,11 +35,29 @@ type APLRotation struct {
 	// Used to avoid recursive APL loops.
 	inLoop bool
 
+	// Variables declared by the rotation, by name.
+	variables map[string]*aplVariable
+	// Names of the variables currently being built, to detect cyclic references.
+	buildingVariables []string
+	// Action lists declared by the rotation, by name and in declaration order.
+	actionLists     map[string]*aplActionList
+	actionListOrder []*aplActionList
+	// Names of the action lists currently being built, to detect cyclic references.
+	buildingActionLists []string
+
+	// Incremented whenever the next action is chosen, so variables are evaluated at most once per decision.
+	decisionIdx int
+
+	// Buffs from the raid cooldown plan whose conditions are evaluated by this rotation.
+	plannedCooldowns []*plannedCooldown
+
 	// Validation warnings that occur during proto parsing.
 	// We return these back to the user for display in the UI.
 	curWarnings          []string
 	prepullWarnings      [][]string
 	priorityListWarnings [][]string
+	variableWarnings     [][]string
+	actionListStats      []*proto.APLActionListStats
 }
 
 func (rot *APLRotation) ValidationWarning(message string, vals ...interface{}) {
@@ -75,8 +99,20 @@ func (unit *Unit) newAPLRotation(config *proto.APLRotation) *APLRotation {
 		unit:                 unit,
 		prepullWarnings:      make([][]string, len(config.PrepullActions)),
 		priorityListWarnings: make([][]string, len(config.PriorityList)),
+		variableWarnings:     make([][]string, len(config.Variables)),
+		actionListStats: MapSlice(config.ActionLists, func(list *proto.APLActionList) *proto.APLActionListStats {
+			return &proto.APLActionListStats{
+				Items: MapSlice(list.Items, func(*proto.APLListItem) *proto.APLActionStats { return &proto.APLActionStats{} }),
+			}
+		}),
 	}
 
+	// Parse variables first, so actions can refer to them
+	rotation.newAPLVariables(config.Variables)
+
+	// Parse action lists
+	rotation.newAPLActionLists(config.ActionLists)
+
 	// Parse prepull actions
 	for i, prepullItem := range config.PrepullActions {
 		prepullIdx := i // Save to local variable for correct lambda capture behavior
@@ -106,14 +142,13 @@ func (unit *Unit) newAPLRotation(config *proto.APLRotation) *APLRotation {
 	}
 
 	// Parse priority list
-	var configIdxs []int
 	for i, aplItem := range config.PriorityList {
 		rotation.doAndRecordWarnings(&rotation.priorityListWarnings[i], false, func() {
 			if !aplItem.Hide {
 				action := rotation.newAPLAction(aplItem.Action)
 				if action != nil {
 					rotation.priorityList = append(rotation.priorityList, action)
-					configIdxs = append(configIdxs, i)
+					rotation.priorityListIdxs = append(rotation.priorityListIdxs, i)
 				}
 			}
 		})
@@ -130,6 +165,15 @@ func (unit *Unit) newAPLRotation(config *proto.APLRotation) *APLRotation {
 			action.Finalize(rotation)
 		})
 	}
+	for _, list := range rotation.actionListOrder {
+		for i, action := range list.actions {
+			rotation.doAndRecordWarnings(&list.stats.Items[list.itemIdxs[i]].Warnings, false, func() {
+				action.Finalize(rotation)
+			})
+		}
+	}
+
+	rotation.lint()
 
 	// Remove MCDs that are referenced by APL actions, so that the Autocast Other Cooldowns
 	// action does not include them.
@@ -140,6 +184,11 @@ func (unit *Unit) newAPLRotation(config *proto.APLRotation) *APLRotation {
 			if castSpellAction, ok := action.impl.(*APLActionCastSpell); ok {
 				character.removeInitialMajorCooldown(castSpellAction.spell.ActionID)
 			}
+			if useItemSlotAction, ok := action.impl.(*APLActionUseItemSlot); ok {
+				for _, spell := range useItemSlotAction.itemSlot.spells {
+					character.removeInitialMajorCooldown(spell.ActionID)
+				}
+			}
 		}
 	}
 
@@ -168,12 +217,18 @@ func (rot *APLRotation) getStats() *proto.APLStats {
 	return &proto.APLStats{
 		PrepullActions: MapSlice(rot.prepullWarnings, func(warnings []string) *proto.APLActionStats { return &proto.APLActionStats{Warnings: warnings} }),
 		PriorityList:   MapSlice(rot.priorityListWarnings, func(warnings []string) *proto.APLActionStats { return &proto.APLActionStats{Warnings: warnings} }),
+		Variables:      MapSlice(rot.variableWarnings, func(warnings []string) *proto.APLActionStats { return &proto.APLActionStats{Warnings: warnings} }),
+		ActionLists:    rot.actionListStats,
 	}
 }
 
 // Returns all action objects as an unstructured list. Used for easily finding specific actions.
 func (rot *APLRotation) allAPLActions() []*APLAction {
-	return Flatten(MapSlice(rot.priorityList, func(action *APLAction) []*APLAction { return action.GetAllActions() }))
+	actions := Flatten(MapSlice(rot.priorityList, func(action *APLAction) []*APLAction { return action.GetAllActions() }))
+	for _, list := range rot.actionListOrder {
+		actions = append(actions, Flatten(MapSlice(list.actions, func(action *APLAction) []*APLAction { return action.GetAllActions() }))...)
+	}
+	return actions
 }
 
 // Returns all action objects from the prepull as an unstructured list. Used for easily finding specific actions.
@@ -189,6 +244,9 @@ func (rot *APLRotation) reset(sim *Simulation) {
 	for _, action := range rot.allAPLActions() {
 		action.impl.Reset(sim)
 	}
+	for _, variable := range rot.variables {
+		variable.reset()
+	}
 }
 
 // We intentionally try to mimic the behavior of simc APL to avoid confusion
@@ -203,10 +261,16 @@ func (apl *APLRotation) DoNextAction(sim *Simulation) {
 		return
 	}
 
+	for _, planned := range apl.plannedCooldowns {
+		planned.tryCast(sim)
+	}
+
 	if apl.unit.ChanneledDot != nil {
 		return
 	}
 
+	apl.profile.endIdle(sim)
+
 	i := 0
 	apl.inLoop = true
 
@@ -215,6 +279,10 @@ func (apl *APLRotation) DoNextAction(sim *Simulation) {
 			panic(fmt.Sprintf("[USER_ERROR] Infinite loop detected, current action:\n%s", nextAction))
 		}
 
+		apl.profile.executed(nextAction)
+		if sim.combatLogEnabled {
+			sim.logAPLAction(apl.unit, nextAction)
+		}
 		nextAction.Execute(sim)
 	}
 	apl.inLoop = false
@@ -224,23 +292,22 @@ func (apl *APLRotation) DoNextAction(sim *Simulation) {
 	}
 
 	gcdReady := apl.unit.GCD.IsReady(sim)
+	if gcdReady && apl.unit.Hardcast.Expires <= sim.CurrentTime {
+		apl.profile.startIdle(sim)
+	}
 	if gcdReady {
 		apl.unit.WaitUntil(sim, sim.CurrentTime+time.Millisecond*50)
 	}
 }
 
 func (apl *APLRotation) getNextAction(sim *Simulation) *APLAction {
+	apl.decisionIdx++
+
 	if len(apl.controllingActions) != 0 {
 		return apl.controllingActions[len(apl.controllingActions)-1].GetNextAction(sim)
 	}
 
-	for _, action := range apl.priorityList {
-		if action.IsReady(sim) {
-			return action
-		}
-	}
-
-	return nil
+	return apl.getNextActionFromList(sim, apl.priorityList)
 }
 
 func (apl *APLRotation) pushControllingAction(ca APLActionImpl) {
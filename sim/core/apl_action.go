@@ -9,6 +9,9 @@ import (
 type APLAction struct {
 	condition APLValue
 	impl      APLActionImpl
+
+	// Only set for list items, when APL profiling is enabled.
+	profile *aplItemProfile
 }
 
 func (action *APLAction) Finalize(rot *APLRotation) {
@@ -19,7 +22,11 @@ func (action *APLAction) Finalize(rot *APLRotation) {
 }
 
 func (action *APLAction) IsReady(sim *Simulation) bool {
-	return (action.condition == nil || action.condition.GetBool(sim)) && action.impl.IsReady(sim)
+	return action.conditionMet(sim) && action.impl.IsReady(sim)
+}
+
+func (action *APLAction) conditionMet(sim *Simulation) bool {
+	return action.condition == nil || action.condition.GetBool(sim)
 }
 
 func (action *APLAction) Execute(sim *Simulation) {
@@ -147,6 +154,8 @@ func (rot *APLRotation) newAPLActionImpl(config *proto.APLAction) APLActionImpl
 		return rot.newActionMultidot(config.GetMultidot())
 	case *proto.APLAction_Multishield:
 		return rot.newActionMultishield(config.GetMultishield())
+	case *proto.APLAction_UseItemSlot:
+		return rot.newActionUseItemSlot(config.GetUseItemSlot())
 	case *proto.APLAction_AutocastOtherCooldowns:
 		return rot.newActionAutocastOtherCooldowns(config.GetAutocastOtherCooldowns())
 
@@ -166,6 +175,12 @@ func (rot *APLRotation) newAPLActionImpl(config *proto.APLAction) APLActionImpl
 	case *proto.APLAction_StrictSequence:
 		return rot.newActionStrictSequence(config.GetStrictSequence())
 
+	// Action lists
+	case *proto.APLAction_CallActionList:
+		return rot.newActionCallActionList(config.GetCallActionList())
+	case *proto.APLAction_RunActionList:
+		return rot.newActionRunActionList(config.GetRunActionList())
+
 	// Misc
 	case *proto.APLAction_ChangeTarget:
 		return rot.newActionChangeTarget(config.GetChangeTarget())
@@ -181,6 +196,8 @@ func (rot *APLRotation) newAPLActionImpl(config *proto.APLAction) APLActionImpl
 		return rot.newActionItemSwap(config.GetItemSwap())
 	case *proto.APLAction_Move:
 		return rot.newActionMove(config.GetMove())
+	case *proto.APLAction_SetVariable:
+		return rot.newActionSetVariable(config.GetSetVariable())
 	case *proto.APLAction_CustomRotation:
 		return rot.newActionCustomRotation(config.GetCustomRotation())
 	default:
@@ -156,8 +156,9 @@ func (action *APLActionMultidot) IsReady(sim *Simulation) bool {
 			}
 		}
 	} else {
-		for i := int32(0); i < action.maxDots; i++ {
-			target := sim.Encounter.TargetUnits[i]
+		activeTargets := sim.Encounter.ActiveTargetUnits
+		for i := 0; i < min(int(action.maxDots), len(activeTargets)); i++ {
+			target := activeTargets[i]
 			dot := action.spell.Dot(target)
 			if (!dot.IsActive() || dot.RemainingDuration(sim) < maxOverlap) && action.spell.CanCast(sim, target) {
 				action.nextTarget = target
@@ -235,6 +236,39 @@ func (action *APLActionMultishield) String() string {
 	return fmt.Sprintf("Multishield(%s)", action.spell.ActionID)
 }
 
+type APLActionUseItemSlot struct {
+	defaultAPLActionImpl
+	itemSlot *APLItemSlot
+}
+
+func (rot *APLRotation) newActionUseItemSlot(config *proto.APLActionUseItemSlot) APLActionImpl {
+	itemSlot := rot.GetAPLItemSlot(config.ItemSlot)
+	if itemSlot == nil {
+		return nil
+	}
+	return &APLActionUseItemSlot{
+		itemSlot: itemSlot,
+	}
+}
+func (action *APLActionUseItemSlot) target(spell *Spell) *Unit {
+	if spell.Flags.Matches(SpellFlagHelpful) {
+		return &action.itemSlot.character.Unit
+	}
+	return action.itemSlot.character.CurrentTarget
+}
+func (action *APLActionUseItemSlot) IsReady(sim *Simulation) bool {
+	spell := action.itemSlot.Spell()
+	// Checks the GCD like Cast Spell does for other major cooldowns.
+	return spell != nil && spell.CanCast(sim, action.target(spell)) && (spell.Unit.GCD.IsReady(sim) || spell.DefaultCast.GCD == 0)
+}
+func (action *APLActionUseItemSlot) Execute(sim *Simulation) {
+	spell := action.itemSlot.Spell()
+	spell.Cast(sim, action.target(spell))
+}
+func (action *APLActionUseItemSlot) String() string {
+	return fmt.Sprintf("Use Item Slot(%s)", action.itemSlot.Name())
+}
+
 type APLActionAutocastOtherCooldowns struct {
 	defaultAPLActionImpl
 	character *Character
@@ -0,0 +1,216 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/wowsims/sod/sim/core/proto"
+)
+
+// A named list of actions declared on the rotation, which Call Action List and Run Action List
+// evaluate in the same way as the priority list.
+type aplActionList struct {
+	name      string
+	config    *proto.APLActionList
+	configIdx int
+	stats     *proto.APLActionListStats
+	actions   []*APLAction
+	itemIdxs  []int // Index of the config item each action was built from.
+	building  bool
+	built     bool
+}
+
+func (rot *APLRotation) newAPLActionLists(configs []*proto.APLActionList) {
+	rot.actionLists = make(map[string]*aplActionList, len(configs))
+	for i, config := range configs {
+		stats := rot.actionListStats[i]
+		rot.doAndRecordWarnings(&stats.Warnings, false, func() {
+			if config.Name == "" {
+				rot.ValidationWarning("Action list must have a name")
+			} else if _, ok := rot.actionLists[config.Name]; ok {
+				rot.ValidationWarning("Duplicate action list name: '%s'", config.Name)
+			} else {
+				list := &aplActionList{
+					name:      config.Name,
+					config:    config,
+					configIdx: i,
+					stats:     stats,
+				}
+				rot.actionLists[config.Name] = list
+				rot.actionListOrder = append(rot.actionListOrder, list)
+			}
+		})
+	}
+
+	// Lists are built in declaration order, or earlier if another list refers to them.
+	for _, list := range rot.actionListOrder {
+		if !list.built {
+			rot.buildActionList(list)
+		}
+	}
+}
+
+// Builds the actions of a list, recording warnings against the list items rather than the action
+// which happened to refer to the list first.
+func (rot *APLRotation) buildActionList(list *aplActionList) {
+	list.building = true
+	rot.buildingActionLists = append(rot.buildingActionLists, list.name)
+	outerWarnings, outerParsingPrepull := rot.curWarnings, rot.parsingPrepull
+	rot.curWarnings = nil
+
+	for i, item := range list.config.Items {
+		rot.doAndRecordWarnings(&list.stats.Items[i].Warnings, false, func() {
+			if !item.Hide {
+				action := rot.newAPLAction(item.Action)
+				if action != nil {
+					list.actions = append(list.actions, action)
+					list.itemIdxs = append(list.itemIdxs, i)
+				}
+			}
+		})
+	}
+
+	rot.curWarnings, rot.parsingPrepull = outerWarnings, outerParsingPrepull
+	rot.buildingActionLists = rot.buildingActionLists[:len(rot.buildingActionLists)-1]
+	list.building = false
+	list.built = true
+}
+
+func (rot *APLRotation) getActionList(name string) *aplActionList {
+	if rot.parsingPrepull {
+		rot.ValidationWarning("Action lists cannot be used in the prepull")
+		return nil
+	}
+
+	list := rot.actionLists[name]
+	if list == nil {
+		rot.ValidationWarning("No action list with name: '%s'", name)
+		return nil
+	}
+
+	if list.building {
+		rot.ValidationWarning("Cyclic action list reference: %s", aplReferenceCycle(rot.buildingActionLists, name))
+		return nil
+	}
+
+	if !list.built {
+		rot.buildActionList(list)
+	}
+	return list
+}
+
+// Returns the first ready action of a list, looking inside any action lists it calls or runs so
+// the returned action is always the one which will actually be performed. Set Variable actions
+// are performed on the way.
+func (apl *APLRotation) getNextActionFromList(sim *Simulation, actions []*APLAction) *APLAction {
+	for _, action := range actions {
+		conditionMet := action.conditionMet(sim)
+		action.profile.addEvaluation(conditionMet)
+
+		switch impl := action.impl.(type) {
+		case *APLActionCallActionList:
+			if conditionMet {
+				if nextAction := apl.getNextActionFromList(sim, impl.list.actions); nextAction != nil {
+					return nextAction
+				}
+			}
+		case *APLActionRunActionList:
+			if conditionMet {
+				return apl.getNextActionFromList(sim, impl.list.actions)
+			}
+		case *APLActionSetVariable:
+			// Like simc's variable action, setting a variable doesn't end the decision, so the
+			// actions after it already see the new value.
+			if conditionMet {
+				apl.profile.executed(action)
+				if sim.combatLogEnabled {
+					sim.logAPLAction(apl.unit, action)
+				}
+				impl.Execute(sim)
+			}
+		default:
+			if conditionMet && action.impl.IsReady(sim) {
+				return action
+			}
+		}
+	}
+	return nil
+}
+
+// Shared implementation for actions which refer to a named list. These are normally resolved by
+// getNextActionFromList, so IsReady and Execute are only used when nested inside other actions
+// such as sequences.
+type aplActionListRef struct {
+	defaultAPLActionImpl
+	rot  *APLRotation
+	list *aplActionList
+
+	// The action chosen by IsReady, reused by Execute in the same decision so the conditions in
+	// the list are only evaluated once.
+	nextAction   *APLAction
+	nextActionAt int
+}
+
+func (action *aplActionListRef) Reset(*Simulation) {
+	action.nextAction = nil
+	action.nextActionAt = -1
+}
+func (action *aplActionListRef) getNextAction(sim *Simulation) *APLAction {
+	// Outside of the action loop the rotation state can change between calls, so nothing is cached.
+	rot := action.rot
+	if !rot.inLoop {
+		return rot.getNextActionFromList(sim, action.list.actions)
+	}
+	if action.nextActionAt != rot.decisionIdx {
+		action.nextAction = rot.getNextActionFromList(sim, action.list.actions)
+		action.nextActionAt = rot.decisionIdx
+	}
+	return action.nextAction
+}
+func (action *aplActionListRef) IsReady(sim *Simulation) bool {
+	return action.getNextAction(sim) != nil
+}
+func (action *aplActionListRef) Execute(sim *Simulation) {
+	if nextAction := action.getNextAction(sim); nextAction != nil {
+		nextAction.Execute(sim)
+	}
+}
+
+type APLActionCallActionList struct {
+	aplActionListRef
+}
+
+func (rot *APLRotation) newActionCallActionList(config *proto.APLActionCallActionList) APLActionImpl {
+	list := rot.getActionList(config.Name)
+	if list == nil {
+		return nil
+	}
+	return &APLActionCallActionList{
+		aplActionListRef: aplActionListRef{
+			rot:  rot,
+			list: list,
+		},
+	}
+}
+func (action *APLActionCallActionList) String() string {
+	return fmt.Sprintf("Call Action List(%s)", action.list.name)
+}
+
+type APLActionRunActionList struct {
+	aplActionListRef
+}
+
+func (rot *APLRotation) newActionRunActionList(config *proto.APLActionRunActionList) APLActionImpl {
+	list := rot.getActionList(config.Name)
+	if list == nil {
+		return nil
+	}
+	return &APLActionRunActionList{
+		aplActionListRef: aplActionListRef{
+			rot:  rot,
+			list: list,
+		},
+	}
+}
+func (action *APLActionRunActionList) String() string {
+	return fmt.Sprintf("Run Action List(%s)", action.list.name)
+}
@@ -0,0 +1,67 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/wowsims/sod/sim/core/proto"
+)
+
+func TestAPLActionLists(t *testing.T) {
+	rsr := fakeCasterRequest(t, `run_action_list("opener") if current_time() < 6s
+call_action_list("empty")
+call_action_list("undefined")
+call_action_list("scorches") if current_time() < 12s
+cast_spell(spell:10149)
+
+list opener:
+cast_spell(spell:10205) if current_time() < 3s
+call_action_list("opener")
+
+list empty:
+
+list scorches:
+cast_spell(spell:10205)
+
+list empty:
+`, &proto.SimOptions{
+		Iterations: 5,
+		RandomSeed: 101,
+	})
+
+	rotationStats := fakeCasterRotationStats(rsr)
+	if warnings := rotationStats.PriorityList[2].Warnings; len(warnings) != 1 || warnings[0] != "No action list with name: 'undefined'" {
+		t.Errorf("Unexpected warnings for undefined list: %v", warnings)
+	}
+	if warnings := rotationStats.ActionLists[0].Items[1].Warnings; len(warnings) != 1 || warnings[0] != "Cyclic action list reference: opener -> opener" {
+		t.Errorf("Unexpected warnings for cyclic list: %v", warnings)
+	}
+	if warnings := rotationStats.ActionLists[3].Warnings; len(warnings) != 1 || warnings[0] != "Duplicate action list name: 'empty'" {
+		t.Errorf("Unexpected warnings for duplicate list: %v", warnings)
+	}
+
+	// Nothing after the opener is cast until 6s, even though the opener stops casting at 3s. Calling
+	// the empty list falls through, so Scorch is cast again from 6s until 12s.
+	result := runFakeCasterSim(t, rsr)
+	if casts := fakeCasterCasts(result, fakeScorchID); casts != 6*5 {
+		t.Errorf("Got %d Scorch casts, expected 6 per iteration", casts)
+	}
+}
+
+func TestAPLNestedActionListEvaluations(t *testing.T) {
+	// A list called from inside a sequence is only evaluated once to check and then perform it.
+	rsr := fakeCasterRequest(t, `sequence("opener", call_action_list("opener"))
+cast_spell(spell:10149)
+
+list opener:
+cast_spell(spell:10205)
+`, &proto.SimOptions{
+		Iterations: 5,
+		RandomSeed: 101,
+		AplProfile: true,
+	})
+
+	profile := runFakeCasterSim(t, rsr).RaidMetrics.Parties[0].Players[0].AplProfile
+	if item := profile.ActionLists[0].Items[0]; item.Evaluations != 1 {
+		t.Errorf("Got %f evaluations of the nested list per iteration, expected 1", item.Evaluations)
+	}
+}
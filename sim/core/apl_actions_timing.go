@@ -37,7 +37,11 @@ func (action *APLActionWait) IsReady(sim *Simulation) bool {
 
 func (action *APLActionWait) Execute(sim *Simulation) {
 	action.unit.Rotation.pushControllingAction(action)
+	action.unit.Rotation.profile.startWait(sim)
 	action.curWaitTime = sim.CurrentTime + action.duration.GetDuration(sim)
+	if sim.combatLogEnabled {
+		sim.logAPLWait(proto.CombatLogEvent_WaitStarted, action.unit, action, action.curWaitTime-sim.CurrentTime)
+	}
 
 	pa := &PendingAction{
 		Priority:     ActionPriorityLow,
@@ -50,6 +54,10 @@ func (action *APLActionWait) Execute(sim *Simulation) {
 func (action *APLActionWait) GetNextAction(sim *Simulation) *APLAction {
 	if sim.CurrentTime >= action.curWaitTime {
 		action.unit.Rotation.popControllingAction(action)
+		action.unit.Rotation.profile.endWait(sim)
+		if sim.combatLogEnabled {
+			sim.logAPLWait(proto.CombatLogEvent_WaitEnded, action.unit, action, 0)
+		}
 		return action.unit.Rotation.getNextAction(sim)
 	} else {
 		return nil
@@ -87,11 +95,19 @@ func (action *APLActionWaitUntil) IsReady(sim *Simulation) bool {
 
 func (action *APLActionWaitUntil) Execute(sim *Simulation) {
 	action.unit.Rotation.pushControllingAction(action)
+	action.unit.Rotation.profile.startWait(sim)
+	if sim.combatLogEnabled {
+		sim.logAPLWait(proto.CombatLogEvent_WaitStarted, action.unit, action, 0)
+	}
 }
 
 func (action *APLActionWaitUntil) GetNextAction(sim *Simulation) *APLAction {
 	if action.condition.GetBool(sim) {
 		action.unit.Rotation.popControllingAction(action)
+		action.unit.Rotation.profile.endWait(sim)
+		if sim.combatLogEnabled {
+			sim.logAPLWait(proto.CombatLogEvent_WaitEnded, action.unit, action, 0)
+		}
 		return action.unit.Rotation.getNextAction(sim)
 	} else {
 		return nil
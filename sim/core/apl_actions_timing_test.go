@@ -0,0 +1,47 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/wowsims/sod/sim/core/proto"
+)
+
+func TestAPLWaitCombatLog(t *testing.T) {
+	result := runFakeCasterSim(t, fakeCasterRequest(t, `wait(1s) if current_time() < 500ms
+cast_spell(spell:10149)
+`, &proto.SimOptions{
+		Iterations:          1,
+		RandomSeed:          101,
+		DebugFirstIteration: true,
+		CombatLog:           true,
+	}))
+
+	counts := map[proto.CombatLogEvent_Type]int{}
+	for _, event := range result.CombatLog {
+		counts[event.Type]++
+		switch event.Type {
+		case proto.CombatLogEvent_WaitStarted:
+			if event.WaitTime != 1 || event.AplAction != "Wait(1s)" {
+				t.Errorf("Wait started event for %q waiting %.1fs, expected Wait(1s) waiting 1s", event.AplAction, event.WaitTime)
+			}
+		case proto.CombatLogEvent_WaitEnded:
+			if event.Timestamp != 1 {
+				t.Errorf("Wait ended at %.3fs, expected 1s", event.Timestamp)
+			}
+		case proto.CombatLogEvent_AplAction, proto.CombatLogEvent_Resource:
+			if event.Source.GetType() != proto.UnitReference_Player {
+				t.Errorf("%s event from %v, expected the player", event.Type, event.Source)
+			}
+		}
+	}
+
+	if counts[proto.CombatLogEvent_WaitStarted] != 1 || counts[proto.CombatLogEvent_WaitEnded] != 1 {
+		t.Errorf("Got %d wait started and %d wait ended events, expected 1 each", counts[proto.CombatLogEvent_WaitStarted], counts[proto.CombatLogEvent_WaitEnded])
+	}
+	if fireballs := counts[proto.CombatLogEvent_CastStarted]; counts[proto.CombatLogEvent_AplAction] != fireballs+1 {
+		t.Errorf("Got %d APL action events, expected one for the wait and each of the %d Fireballs started", counts[proto.CombatLogEvent_AplAction], fireballs)
+	}
+	if counts[proto.CombatLogEvent_Resource] == 0 {
+		t.Errorf("No resource events in combat log")
+	}
+}
@@ -0,0 +1,41 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/wowsims/sod/sim/core/proto"
+)
+
+type APLActionSetVariable struct {
+	defaultAPLActionImpl
+	variable *aplVariable
+	value    APLValue
+}
+
+func (rot *APLRotation) newActionSetVariable(config *proto.APLActionSetVariable) APLActionImpl {
+	variable := rot.getVariable(config.Name)
+	if variable == nil {
+		return nil
+	}
+	value := rot.coerceTo(rot.newAPLValue(config.Value), variable.value.Type())
+	if value == nil {
+		rot.ValidationWarning("Set Variable must provide a value")
+		return nil
+	}
+	return &APLActionSetVariable{
+		variable: variable,
+		value:    value,
+	}
+}
+func (action *APLActionSetVariable) GetAPLValues() []APLValue {
+	return []APLValue{action.value}
+}
+func (action *APLActionSetVariable) IsReady(sim *Simulation) bool {
+	return true
+}
+func (action *APLActionSetVariable) Execute(sim *Simulation) {
+	action.variable.set(sim, action.value)
+}
+func (action *APLActionSetVariable) String() string {
+	return fmt.Sprintf("Set Variable(%s, %s)", action.variable.name, action.value)
+}
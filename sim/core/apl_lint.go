@@ -0,0 +1,328 @@
+package core
+
+import (
+	"strings"
+
+	"github.com/wowsims/sod/sim/core/proto"
+)
+
+// The APL linter looks for rotations which are valid, but almost certainly don't do what was
+// intended: type mismatches, conditions which are never true, unreachable actions and sequences
+// which are never reset. It runs once the rotation is finalized, and reports its findings as
+// validation warnings on the affected list items.
+
+// What a value represents, which is finer grained than its APLValueType.
+type aplValueKind int
+
+const (
+	aplKindNumber aplValueKind = iota
+	aplKindPercent
+	aplKindDuration
+	aplKindString
+	aplKindBool
+)
+
+var aplValueKindNames = map[aplValueKind]string{
+	aplKindNumber:   "number",
+	aplKindPercent:  "percentage",
+	aplKindDuration: "duration",
+	aplKindString:   "string",
+	aplKindBool:     "bool",
+}
+
+func (rot *APLRotation) lint() {
+	resetSequences := make(map[string]bool)
+	for _, action := range rot.allAPLActions() {
+		if resetAction, ok := action.impl.(*APLActionResetSequence); ok {
+			resetSequences[resetAction.name] = true
+		}
+	}
+
+	for _, variable := range rot.variables {
+		if variable.value != nil {
+			rot.doAndRecordWarnings(variable.warnings, false, func() {
+				rot.lintValue(variable.value)
+			})
+		}
+	}
+	rot.lintList(rot.priorityList, resetSequences, func(i int) *[]string {
+		return &rot.priorityListWarnings[rot.priorityListIdxs[i]]
+	})
+	for _, list := range rot.actionListOrder {
+		rot.lintList(list.actions, resetSequences, func(i int) *[]string {
+			return &list.stats.Items[list.itemIdxs[i]].Warnings
+		})
+	}
+}
+
+func (rot *APLRotation) lintList(actions []*APLAction, resetSequences map[string]bool, itemWarnings func(i int) *[]string) {
+	// An earlier action which is always performed when it is considered, so the actions after it
+	// are never reached. Actions using the GCD are only blocked by other actions using the GCD.
+	var blocker *APLAction
+	blocksAll := false
+
+	for i, action := range actions {
+		rot.doAndRecordWarnings(itemWarnings(i), false, func() {
+			if blocker != nil && (blocksAll || aplActionUsesGCD(action)) {
+				rot.ValidationWarning("Never reached, because %s is always performed first", blocker.impl)
+			}
+
+			for _, innerAction := range action.GetAllActions() {
+				for _, value := range innerAction.impl.GetAPLValues() {
+					rot.lintValue(value)
+				}
+				if innerAction.condition != nil {
+					rot.lintValue(innerAction.condition)
+				}
+			}
+
+			neverTrue := action.condition != nil && aplNeverTrue(action.condition)
+			if neverTrue {
+				rot.ValidationWarning("Condition is never true, so this action is never performed")
+			}
+
+			for _, innerAction := range action.GetAllActions() {
+				if sequence, ok := innerAction.impl.(*APLActionSequence); ok && (sequence.name == "" || !resetSequences[sequence.name]) {
+					if sequence.name == "" {
+						rot.ValidationWarning("Sequence without a name is never reset, so it is skipped once complete")
+					} else {
+						rot.ValidationWarning("Sequence '%s' is never reset, so it is skipped once complete", sequence.name)
+					}
+				}
+			}
+
+			if blocker == nil && !neverTrue && (action.condition == nil || aplAlwaysTrue(action.condition)) {
+				switch impl := action.impl.(type) {
+				case *APLActionRunActionList:
+					blocker, blocksAll = action, true
+				case *APLActionWait:
+					if aplIsConstValue(impl.duration) && impl.duration.GetDuration(nil) > 0 {
+						blocker, blocksAll = action, true
+					}
+				case *APLActionCastSpell:
+					spell := impl.spell
+					if spell.Cost == nil && spell.CD.Timer == nil && spell.SharedCD.Timer == nil && spell.ExtraCastCondition == nil &&
+						spell.DefaultCast.CastTime == 0 && spell.DefaultCast.GCD > 0 {
+						blocker = action
+					}
+				}
+			}
+		})
+	}
+}
+
+// Only casts are considered to use the GCD, since other actions might not be blocked by it.
+func aplActionUsesGCD(action *APLAction) bool {
+	castAction, ok := action.impl.(*APLActionCastSpell)
+	return ok && castAction.spell.DefaultCast.GCD > 0
+}
+
+// Lints a value and all of its inner values. Variables are linted once by themselves, rather than
+// wherever they are read.
+func (rot *APLRotation) lintValue(value APLValue) {
+	if value == nil {
+		return
+	}
+	rot.lintTypes(value)
+	if _, ok := value.(*APLValueVariable); ok {
+		return
+	}
+	for _, inner := range value.GetInnerValues() {
+		rot.lintValue(inner)
+	}
+}
+
+// Warns about comparisons and additions between values of different kinds, e.g. a duration and a
+// percentage. These are coerced to the same type, but the result is rarely what was meant.
+func (rot *APLRotation) lintTypes(value APLValue) {
+	var op string
+	var lhs, rhs APLValue
+	switch value := value.(type) {
+	case *APLValueCompare:
+		op, lhs, rhs = "comparing a %s to a %s", value.lhs, value.rhs
+	case *APLValueMath:
+		if value.op == proto.APLValueMath_OpAdd {
+			op, lhs, rhs = "adding a %s to a %s", value.rhs, value.lhs
+		} else if value.op == proto.APLValueMath_OpSub {
+			op, lhs, rhs = "subtracting a %s from a %s", value.rhs, value.lhs
+		} else {
+			return
+		}
+	default:
+		return
+	}
+
+	lhsKind, lhsConst := rot.aplValueKindOf(lhs)
+	rhsKind, rhsConst := rot.aplValueKindOf(rhs)
+	if aplKindsMatch(lhsKind, lhsConst, rhsKind, rhsConst) {
+		return
+	}
+	rot.ValidationWarning("Type mismatch: "+op, aplValueKindNames[lhsKind], aplValueKindNames[rhsKind])
+}
+
+func aplKindsMatch(kind1 aplValueKind, isConst1 bool, kind2 aplValueKind, isConst2 bool) bool {
+	if kind1 == kind2 {
+		return true
+	}
+	if kind1 > kind2 {
+		kind1, isConst1, kind2, isConst2 = kind2, isConst2, kind1, isConst1
+	}
+	switch {
+	case kind1 == aplKindNumber && kind2 == aplKindPercent:
+		// Percentages are stored as fractions, so they can be compared to plain numbers.
+		return true
+	case kind1 == aplKindNumber && kind2 == aplKindDuration:
+		// Plain number constants are read as seconds.
+		return isConst1
+	}
+	return false
+}
+
+// Returns the kind of a value, and whether it is a constant. Constants have the kind they were
+// written as, before being coerced to the type of the other operand.
+func (rot *APLRotation) aplValueKindOf(value APLValue) (aplValueKind, bool) {
+	if coerced, ok := value.(*APLValueCoerced); ok {
+		return rot.aplValueKindOf(coerced.inner)
+	}
+	if constValue, ok := value.(*APLValueConst); ok {
+		original := rot.newValueConst(&proto.APLValueConst{Val: constValue.stringVal}).(*APLValueConst)
+		if original.valType == proto.APLValueType_ValueTypeFloat && strings.HasSuffix(original.stringVal, "%") {
+			return aplKindPercent, true
+		}
+		if original.valType == proto.APLValueType_ValueTypeDuration && original.stringVal == "0" {
+			// A plain 0 is also a valid duration, but is usually meant as a number.
+			return aplKindNumber, true
+		}
+		return aplTypeKind(original.valType), true
+	}
+	if aplIsPercentValue(value) {
+		return aplKindPercent, false
+	}
+	return aplTypeKind(value.Type()), false
+}
+
+func aplTypeKind(valType proto.APLValueType) aplValueKind {
+	switch valType {
+	case proto.APLValueType_ValueTypeDuration:
+		return aplKindDuration
+	case proto.APLValueType_ValueTypeString:
+		return aplKindString
+	case proto.APLValueType_ValueTypeBool:
+		return aplKindBool
+	}
+	return aplKindNumber
+}
+
+func aplIsPercentValue(value APLValue) bool {
+	switch value.(type) {
+	case *APLValueCurrentHealthPercent, *APLValueCurrentManaPercent, *APLValueCurrentTimePercent, *APLValueRemainingTimePercent, *APLValueTargetHealthPercent:
+		return true
+	}
+	return false
+}
+
+// Returns whether a value only depends on constants, so it can be evaluated without a sim.
+func aplIsConstValue(value APLValue) bool {
+	switch value.(type) {
+	case *APLValueConst:
+		return true
+	case *APLValueCompare, *APLValueMath, *APLValueMax, *APLValueMin, *APLValueAnd, *APLValueOr, *APLValueNot, *APLValueCoerced:
+		for _, inner := range value.GetInnerValues() {
+			if inner == nil || !aplIsConstValue(inner) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func aplAlwaysTrue(value APLValue) bool {
+	return aplIsConstValue(value) && value.GetBool(nil)
+}
+
+// Returns whether a condition can be shown to be false at every point of the rotation.
+func aplNeverTrue(value APLValue) bool {
+	if aplIsConstValue(value) {
+		return !value.GetBool(nil)
+	}
+
+	switch value := value.(type) {
+	case *APLValueAnd:
+		for _, val := range value.vals {
+			if aplNeverTrue(val) {
+				return true
+			}
+		}
+	case *APLValueOr:
+		for _, val := range value.vals {
+			if !aplNeverTrue(val) {
+				return false
+			}
+		}
+		return len(value.vals) > 0
+	case *APLValueCompare:
+		return aplCompareOutOfRange(value)
+	}
+	return false
+}
+
+// Percentages are always between 0% and 100%, and the durations reported by the rotation values
+// are never negative, so comparisons against constants outside of those ranges are never true.
+func aplCompareOutOfRange(value *APLValueCompare) bool {
+	op, variable, constant := value.op, value.lhs, value.rhs
+	if aplIsConstValue(variable) {
+		variable, constant = constant, variable
+		switch op {
+		case proto.APLValueCompare_OpLt:
+			op = proto.APLValueCompare_OpGt
+		case proto.APLValueCompare_OpLe:
+			op = proto.APLValueCompare_OpGe
+		case proto.APLValueCompare_OpGt:
+			op = proto.APLValueCompare_OpLt
+		case proto.APLValueCompare_OpGe:
+			op = proto.APLValueCompare_OpLe
+		}
+	}
+	if !aplIsConstValue(constant) || aplIsConstValue(variable) {
+		return false
+	}
+
+	if coerced, ok := variable.(*APLValueCoerced); ok {
+		variable = coerced.inner
+	}
+	// The compared constant, in the units of the non-constant value.
+	var c float64
+	hasMax := false
+	switch {
+	case aplIsPercentValue(variable) && value.lhs.Type() != proto.APLValueType_ValueTypeDuration:
+		c = constant.GetFloat(nil)
+		hasMax = true
+	case variable.Type() == proto.APLValueType_ValueTypeDuration && aplIsRotationDuration(variable):
+		c = constant.GetDuration(nil).Seconds()
+	default:
+		return false
+	}
+
+	switch op {
+	case proto.APLValueCompare_OpLt:
+		return c <= 0
+	case proto.APLValueCompare_OpLe:
+		return c < 0
+	case proto.APLValueCompare_OpGt:
+		return hasMax && c >= 1
+	case proto.APLValueCompare_OpGe:
+		return hasMax && c > 1
+	}
+	return false
+}
+
+// Durations computed by operators or stored in variables can be negative, so only values read
+// directly from the sim are known to be at least 0.
+func aplIsRotationDuration(value APLValue) bool {
+	switch value.(type) {
+	case *APLValueMath, *APLValueMax, *APLValueMin, *APLValueVariable, *APLValueConst:
+		return false
+	}
+	return true
+}
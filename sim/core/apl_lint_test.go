@@ -0,0 +1,37 @@
+package core
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/wowsims/sod/sim/core/proto"
+)
+
+func TestAPLLint(t *testing.T) {
+	rotationStats := fakeCasterRotationStats(fakeCasterRequest(t, `variable low = current_mana_percent() < 2s
+
+cast_spell(spell:10205) if spell_time_to_ready(spell:10205) < 20%
+cast_spell(spell:10205) if current_mana_percent() > 100%
+cast_spell(spell:10205) if false or current_time() < 0s
+sequence("", cast_spell(spell:10205))
+sequence("opener", cast_spell(spell:10205))
+reset_sequence("opener") if current_time() > 10s and current_mana() >= 0
+wait(1s)
+cast_spell(spell:10149)
+`, &proto.SimOptions{}))
+
+	checkWarnings := func(name string, warnings []string, expected ...string) {
+		if !slices.Equal(warnings, expected) {
+			t.Errorf("Got warnings %q for %s, expected %q", warnings, name, expected)
+		}
+	}
+	checkWarnings("variable", rotationStats.Variables[0].Warnings, "Type mismatch: comparing a percentage to a duration")
+	checkWarnings("item 1", rotationStats.PriorityList[0].Warnings, "Type mismatch: comparing a duration to a percentage")
+	checkWarnings("item 2", rotationStats.PriorityList[1].Warnings, "Condition is never true, so this action is never performed")
+	checkWarnings("item 3", rotationStats.PriorityList[2].Warnings, "Condition is never true, so this action is never performed")
+	checkWarnings("item 4", rotationStats.PriorityList[3].Warnings, "Sequence without a name is never reset, so it is skipped once complete")
+	checkWarnings("item 5", rotationStats.PriorityList[4].Warnings)
+	checkWarnings("item 6", rotationStats.PriorityList[5].Warnings)
+	checkWarnings("item 7", rotationStats.PriorityList[6].Warnings)
+	checkWarnings("item 8", rotationStats.PriorityList[7].Warnings, "Never reached, because Wait(1s) is always performed first")
+}
@@ -0,0 +1,159 @@
+package core
+
+import (
+	"time"
+
+	"github.com/wowsims/sod/sim/core/proto"
+)
+
+// APL profiles record how often each item of a rotation is evaluated and performed, how long its
+// waits last and how long the unit sat idle with its GCD ready. They are enabled by
+// SimOptions.AplProfile. Counts are summed over all iterations and averaged in ToProto.
+
+type aplItemProfile struct {
+	evaluations   int64
+	conditionTrue int64
+	executions    int64
+	waitTime      time.Duration
+}
+
+// Profile items are only set when profiling is enabled, so these are all safe to call on nil.
+func (item *aplItemProfile) addEvaluation(conditionMet bool) {
+	if item != nil {
+		item.evaluations++
+		if conditionMet {
+			item.conditionTrue++
+		}
+	}
+}
+
+func (item *aplItemProfile) merge(other *aplItemProfile) {
+	item.evaluations += other.evaluations
+	item.conditionTrue += other.conditionTrue
+	item.executions += other.executions
+	item.waitTime += other.waitTime
+}
+
+func (item *aplItemProfile) ToProto(numIterations float64) *proto.APLItemProfile {
+	return &proto.APLItemProfile{
+		Evaluations:   float64(item.evaluations) / numIterations,
+		ConditionTrue: float64(item.conditionTrue) / numIterations,
+		Executions:    float64(item.executions) / numIterations,
+		WaitSeconds:   item.waitTime.Seconds() / numIterations,
+	}
+}
+
+type aplProfile struct {
+	priorityList []aplItemProfile
+	actionLists  [][]aplItemProfile
+	idleTime     time.Duration
+
+	// State for the current iteration.
+	lastExecuted *aplItemProfile // Item whose action was performed most recently.
+	waitingItem  *aplItemProfile // Item of the wait in progress, if any.
+	waitingSince time.Duration
+	idle         bool
+	idleSince    time.Duration
+}
+
+// Enables APL profiles on every player, if requested by the sim options. Pets and targets only
+// run the built-in custom rotation, so they are not profiled.
+func (sim *Simulation) setupAPLProfiles() {
+	if !sim.Options.AplProfile {
+		return
+	}
+
+	for _, unit := range sim.AllUnits {
+		rot := unit.Rotation
+		if unit.Type != PlayerUnit || rot == nil {
+			continue
+		}
+
+		profile := &aplProfile{
+			priorityList: make([]aplItemProfile, len(rot.priorityListWarnings)),
+			actionLists:  make([][]aplItemProfile, len(rot.actionListStats)),
+		}
+		for i, action := range rot.priorityList {
+			action.profile = &profile.priorityList[rot.priorityListIdxs[i]]
+		}
+		for i, stats := range rot.actionListStats {
+			profile.actionLists[i] = make([]aplItemProfile, len(stats.Items))
+		}
+		for _, list := range rot.actionListOrder {
+			for i, action := range list.actions {
+				action.profile = &profile.actionLists[list.configIdx][list.itemIdxs[i]]
+			}
+		}
+
+		rot.profile = profile
+		unit.Metrics.aplProfile = profile
+	}
+}
+
+func (profile *aplProfile) executed(action *APLAction) {
+	if profile != nil && action.profile != nil {
+		action.profile.executions++
+		profile.lastExecuted = action.profile
+	}
+}
+
+// Waits are credited to the item performed most recently, which is the item containing the wait
+// even when it is part of a sequence.
+func (profile *aplProfile) startWait(sim *Simulation) {
+	if profile != nil && profile.lastExecuted != nil {
+		profile.waitingItem = profile.lastExecuted
+		profile.waitingSince = sim.CurrentTime
+	}
+}
+
+func (profile *aplProfile) endWait(sim *Simulation) {
+	if profile != nil && profile.waitingItem != nil {
+		profile.waitingItem.waitTime += sim.CurrentTime - profile.waitingSince
+		profile.waitingItem = nil
+	}
+}
+
+func (profile *aplProfile) startIdle(sim *Simulation) {
+	if profile != nil && !profile.idle {
+		profile.idle = true
+		profile.idleSince = sim.CurrentTime
+	}
+}
+
+func (profile *aplProfile) endIdle(sim *Simulation) {
+	if profile != nil && profile.idle {
+		profile.idleTime += sim.CurrentTime - profile.idleSince
+		profile.idle = false
+	}
+}
+
+func (profile *aplProfile) doneIteration(sim *Simulation) {
+	profile.endWait(sim)
+	profile.endIdle(sim)
+	profile.lastExecuted = nil
+}
+
+func (profile *aplProfile) merge(other *aplProfile) {
+	for i := range profile.priorityList {
+		profile.priorityList[i].merge(&other.priorityList[i])
+	}
+	for i := range profile.actionLists {
+		for j := range profile.actionLists[i] {
+			profile.actionLists[i][j].merge(&other.actionLists[i][j])
+		}
+	}
+	profile.idleTime += other.idleTime
+}
+
+func (profile *aplProfile) ToProto(numIterations int) *proto.APLProfile {
+	n := float64(numIterations)
+	return &proto.APLProfile{
+		PriorityList: MapSlice(profile.priorityList, func(item aplItemProfile) *proto.APLItemProfile { return item.ToProto(n) }),
+		ActionLists: MapSlice(profile.actionLists, func(items []aplItemProfile) *proto.APLActionListProfile {
+			return &proto.APLActionListProfile{
+				Items: MapSlice(items, func(item aplItemProfile) *proto.APLItemProfile { return item.ToProto(n) }),
+			}
+		}),
+		IdleGcdReadySeconds: profile.idleTime.Seconds() / n,
+	}
+}
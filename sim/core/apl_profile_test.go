@@ -0,0 +1,63 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/wowsims/sod/sim/core/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestAPLProfile(t *testing.T) {
+	rsr := fakeCasterRequest(t, `cast_spell(spell:10205) if current_time() < 0s
+hidden cast_spell(spell:10149)
+call_action_list("opener") if current_time() < 3s
+wait_until(current_time() >= 6s) if current_time() >= 3s and current_time() < 6s
+cast_spell(spell:10149)
+
+list opener:
+cast_spell(spell:10205)
+`, &proto.SimOptions{
+		Iterations: 5,
+		RandomSeed: 101,
+		AplProfile: true,
+	})
+
+	profile := runFakeCasterSim(t, rsr).RaidMetrics.Parties[0].Players[0].AplProfile
+	if profile == nil {
+		t.Fatalf("No APL profile")
+	}
+	if len(profile.PriorityList) != 5 || len(profile.ActionLists) != 1 || len(profile.ActionLists[0].Items) != 1 {
+		t.Fatalf("APL profile does not match the rotation: %v", profile)
+	}
+
+	if item := profile.PriorityList[0]; item.Evaluations == 0 || item.ConditionTrue != 0 || item.Executions != 0 {
+		t.Errorf("Unexpected profile for the never true item: %v", item)
+	}
+	if item := profile.PriorityList[1]; item.Evaluations != 0 {
+		t.Errorf("Unexpected profile for the hidden item: %v", item)
+	}
+	if item := profile.ActionLists[0].Items[0]; item.Executions != 2 {
+		t.Errorf("Got %f opener executions per iteration, expected 2", item.Executions)
+	}
+	if item := profile.PriorityList[3]; item.Executions != 1 || item.WaitSeconds < 3 || item.WaitSeconds > 3.1 {
+		t.Errorf("Unexpected profile for the wait: %v", item)
+	}
+	if profile.IdleGcdReadySeconds < 3 {
+		t.Errorf("Got %f idle seconds per iteration, expected at least the 3 seconds spent waiting", profile.IdleGcdReadySeconds)
+	}
+
+	// Each worker profiles its own iterations, which must add up to the same profile.
+	for _, workers := range []int32{2, 3} {
+		rsr.SimOptions.Workers = workers
+		workersProfile := runFakeCasterSim(t, rsr).RaidMetrics.Parties[0].Players[0].AplProfile
+		if diff := cmp.Diff(profile, workersProfile, protocmp.Transform()); diff != "" {
+			t.Errorf("Profile with %d workers differs from single-threaded profile: %s", workers, diff)
+		}
+	}
+
+	rsr.SimOptions.AplProfile = false
+	if result := runFakeCasterSim(t, rsr); result.RaidMetrics.Parties[0].Players[0].AplProfile != nil {
+		t.Errorf("APL profile should only be set when enabled")
+	}
+}
@@ -0,0 +1,181 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wowsims/sod/sim/core/proto"
+)
+
+func init() {
+	RegisterAgentFactory(
+		proto.Player_Mage{},
+		proto.Spec_SpecMage,
+		NewFakeCaster,
+		func(player *proto.Player, spec interface{}) {
+			playerSpec, ok := spec.(*proto.Player_Mage)
+			if !ok {
+				panic("Invalid spec value for Mage!")
+			}
+			player.Spec = playerSpec
+		},
+	)
+}
+
+const (
+	fakeFireballID  = 10149
+	fakeScorchID    = 10205
+	fakeFireBlastID = 10197
+)
+
+// Casts spells from an APL rotation: Fireball has a cast time and costs mana, Scorch is an instant
+// and Fire Blast is an instant with a cooldown and random damage.
+type FakeCaster struct {
+	FakeAgent
+	Fireball  *Spell
+	Scorch    *Spell
+	FireBlast *Spell
+}
+
+func NewFakeCaster(char *Character, _ *proto.Player) Agent {
+	caster := &FakeCaster{
+		FakeAgent: FakeAgent{
+			Character: *char,
+		},
+	}
+	caster.EnableManaBar()
+
+	caster.Init = func() {
+		caster.Fireball = caster.RegisterSpell(SpellConfig{
+			ActionID:    ActionID{SpellID: fakeFireballID},
+			SpellSchool: SpellSchoolFire,
+			DefenseType: DefenseTypeMagic,
+			ProcMask:    ProcMaskSpellDamage,
+			Flags:       SpellFlagAPL,
+
+			ManaCost: ManaCostOptions{
+				FlatCost: 10,
+			},
+			Cast: CastConfig{
+				DefaultCast: Cast{
+					GCD:      GCDDefault,
+					CastTime: time.Millisecond * 2500,
+				},
+			},
+
+			DamageMultiplier: 1,
+			ThreatMultiplier: 1,
+			BonusCoefficient: 1,
+
+			ApplyEffects: func(sim *Simulation, target *Unit, spell *Spell) {
+				spell.CalcAndDealDamage(sim, target, 500, spell.OutcomeMagicHitAndCrit)
+			},
+		})
+
+		caster.Scorch = caster.RegisterSpell(SpellConfig{
+			ActionID:    ActionID{SpellID: fakeScorchID},
+			SpellSchool: SpellSchoolFire,
+			DefenseType: DefenseTypeMagic,
+			ProcMask:    ProcMaskSpellDamage,
+			Flags:       SpellFlagAPL,
+
+			Cast: CastConfig{
+				DefaultCast: Cast{
+					GCD: GCDDefault,
+				},
+			},
+
+			DamageMultiplier: 1,
+			ThreatMultiplier: 1,
+			BonusCoefficient: 0.4,
+
+			ApplyEffects: func(sim *Simulation, target *Unit, spell *Spell) {
+				spell.CalcAndDealDamage(sim, target, 150, spell.OutcomeMagicHitAndCrit)
+			},
+		})
+
+		caster.FireBlast = caster.RegisterSpell(SpellConfig{
+			ActionID:    ActionID{SpellID: fakeFireBlastID},
+			SpellSchool: SpellSchoolFire,
+			DefenseType: DefenseTypeMagic,
+			ProcMask:    ProcMaskSpellDamage,
+			Flags:       SpellFlagAPL,
+
+			Cast: CastConfig{
+				DefaultCast: Cast{
+					GCD: GCDDefault,
+				},
+				CD: Cooldown{
+					Timer:    caster.NewTimer(),
+					Duration: time.Second * 8,
+				},
+			},
+
+			DamageMultiplier: 1,
+			ThreatMultiplier: 1,
+			BonusCoefficient: 0.4,
+
+			ExpectedInitialDamage: func(sim *Simulation, target *Unit, spell *Spell, _ bool) *SpellResult {
+				return spell.CalcDamage(sim, target, 300, spell.OutcomeExpectedMagicHitAndCrit)
+			},
+			ApplyEffects: func(sim *Simulation, target *Unit, spell *Spell) {
+				spell.CalcAndDealDamage(sim, target, sim.Roll(200, 400), spell.OutcomeMagicHitAndCrit)
+			},
+		})
+	}
+
+	return caster
+}
+
+// Returns a request for a fake caster using the given APL rotation, against a boss level target.
+func fakeCasterRequest(t *testing.T, rotationText string, simOptions *proto.SimOptions) *proto.RaidSimRequest {
+	rotation, err := APLRotationFromText("type: TypeAPL\n" + rotationText)
+	if err != nil {
+		t.Fatalf("Failed to parse rotation: %s", err)
+	}
+	return &proto.RaidSimRequest{
+		Raid: SinglePlayerRaidProto(&proto.Player{
+			Name:      "Caster",
+			Class:     proto.Class_ClassMage,
+			Race:      proto.Race_RaceHuman,
+			Level:     60,
+			Spec:      &proto.Player_Mage{},
+			Equipment: &proto.EquipmentSpec{},
+			Rotation:  rotation,
+		}, &proto.PartyBuffs{}, &proto.RaidBuffs{}, &proto.Debuffs{}),
+		Encounter: &proto.Encounter{
+			Duration:             60,
+			DurationVariation:    5,
+			ExecuteProportion_20: 0.2,
+			ExecuteProportion_25: 0.25,
+			ExecuteProportion_35: 0.35,
+			Targets: []*proto.Target{
+				{Name: "target", Level: 63, MobType: proto.MobType_MobTypeDemon},
+			},
+		},
+		SimOptions: simOptions,
+	}
+}
+
+func runFakeCasterSim(t *testing.T, rsr *proto.RaidSimRequest) *proto.RaidSimResult {
+	result := RunRaidSim(rsr)
+	if result.ErrorResult != "" {
+		t.Fatalf("Sim failed with error: %s", result.ErrorResult)
+	}
+	return result
+}
+
+// Returns the casts of the spell on the first target, over all iterations.
+func fakeCasterCasts(result *proto.RaidSimResult, spellID int32) int32 {
+	for _, action := range result.RaidMetrics.Parties[0].Players[0].Actions {
+		if action.Id.GetSpellId() == spellID {
+			return action.Targets[0].Casts
+		}
+	}
+	return 0
+}
+
+func fakeCasterRotationStats(rsr *proto.RaidSimRequest) *proto.APLStats {
+	stats := ComputeStats(&proto.ComputeStatsRequest{Raid: rsr.Raid, Encounter: rsr.Encounter})
+	return stats.RaidStats.Parties[0].Players[0].RotationStats
+}
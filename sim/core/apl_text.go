@@ -0,0 +1,399 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/wowsims/sod/sim/core/proto"
+	googleProto "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// The APL text format is a readable alternative to the JSON form of APLRotation, e.g.
+//
+//	type: TypeAPL
+//	variable low_mana = current_mana_percent() < 20%
+//
+//	prepull -1.5s: cast_spell(spell:133)
+//
+//	# Notes for the next action.
+//	cast_spell(spell:133) if aura_remaining_time(spell:12654) < 2s and current_mana_percent() > 10%
+//	hidden cast_spell(spell:10181(rank=4)) if not variable("low_mana")
+//	call_action_list("aoe") if number_targets() > 3
+//
+//	list aoe:
+//	cast_spell(spell:10216)
+//
+// Actions and values are written as calls named after their field in APLAction/APLValue, with
+// arguments for the fields of their message. Arguments are either positional, in field number
+// order, or named like max_dots=3. A repeated last field takes all remaining positional arguments.
+// Operators (and, or, not, comparisons and math) can also be written infix.
+//
+// Action IDs are written as spell:133, item:19950 or other:OtherActionPotion, and unit references
+// as self, current_target or target:1. Any other message is written as {field=value, ...}.
+//
+// Named action lists follow the priority list, each starting with a list header. Every action
+// after a header belongs to that list.
+
+// Precedence of the infix value operators, from loosest to tightest binding.
+const (
+	aplPrecOr = iota + 1
+	aplPrecAnd
+	aplPrecNot
+	aplPrecCmp
+	aplPrecAdd
+	aplPrecMul
+	aplPrecPrimary
+)
+
+var aplCompareOperators = map[proto.APLValueCompare_ComparisonOperator]string{
+	proto.APLValueCompare_OpEq: "==",
+	proto.APLValueCompare_OpNe: "!=",
+	proto.APLValueCompare_OpLt: "<",
+	proto.APLValueCompare_OpLe: "<=",
+	proto.APLValueCompare_OpGt: ">",
+	proto.APLValueCompare_OpGe: ">=",
+}
+
+var aplMathOperators = map[proto.APLValueMath_MathOperator]string{
+	proto.APLValueMath_OpAdd: "+",
+	proto.APLValueMath_OpSub: "-",
+	proto.APLValueMath_OpMul: "*",
+	proto.APLValueMath_OpDiv: "/",
+}
+
+// Constants which can be written without quotes, e.g. 10, 1.5s or 20%.
+var aplBareConstRegex = regexp.MustCompile(`^(-?[0-9]+(\.[0-9]+)?[a-zA-Z%]*|true|false)$`)
+
+var (
+	aplActionOneof = (&proto.APLAction{}).ProtoReflect().Descriptor().Oneofs().ByName("action")
+	aplValueOneof  = (&proto.APLValue{}).ProtoReflect().Descriptor().Oneofs().ByName("value")
+)
+
+// Returns the text form of an APL rotation, which APLRotationFromText parses back into the same proto.
+func APLRotationToText(rotation *proto.APLRotation) string {
+	var sb strings.Builder
+	if rotation.Type != proto.APLRotation_TypeUnknown {
+		fmt.Fprintf(&sb, "type: %s\n", aplEnumName(rotation.ProtoReflect().Descriptor().Fields().ByName("type"), protoreflect.EnumNumber(rotation.Type)))
+	}
+	if rotation.Simple != nil {
+		fmt.Fprintf(&sb, "simple: {%s}\n", aplFieldsToText(rotation.Simple.ProtoReflect(), false))
+	}
+
+	for _, variable := range rotation.Variables {
+		sb.WriteString("variable " + aplNameToText(variable.Name))
+		if variable.Value != nil {
+			sb.WriteString(" = " + APLValueToText(variable.Value))
+		}
+		sb.WriteString("\n")
+	}
+	if len(rotation.Variables) > 0 && len(rotation.PrepullActions)+len(rotation.PriorityList)+len(rotation.ActionLists) > 0 {
+		sb.WriteString("\n")
+	}
+
+	for _, prepullAction := range rotation.PrepullActions {
+		sb.WriteString(aplHiddenPrefix(prepullAction.Hide))
+		sb.WriteString("prepull")
+		if prepullAction.DoAtValue != nil {
+			sb.WriteString(" " + APLValueToText(prepullAction.DoAtValue))
+		}
+		sb.WriteString(": " + aplOptionalActionToText(prepullAction.Action) + "\n")
+	}
+
+	if len(rotation.PrepullActions) > 0 && len(rotation.PriorityList) > 0 {
+		sb.WriteString("\n")
+	}
+	aplListItemsToText(&sb, rotation.PriorityList)
+
+	for i, list := range rotation.ActionLists {
+		if i > 0 || len(rotation.PrepullActions)+len(rotation.PriorityList) > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("list " + aplNameToText(list.Name) + ":\n")
+		aplListItemsToText(&sb, list.Items)
+	}
+	return sb.String()
+}
+
+func aplListItemsToText(sb *strings.Builder, items []*proto.APLListItem) {
+	for _, item := range items {
+		if item.Notes != "" {
+			for _, line := range strings.Split(item.Notes, "\n") {
+				if line == "" {
+					sb.WriteString("#\n")
+				} else {
+					sb.WriteString("# " + line + "\n")
+				}
+			}
+		}
+		sb.WriteString(aplHiddenPrefix(item.Hide) + aplOptionalActionToText(item.Action) + "\n")
+	}
+}
+
+var aplIdentRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Variable and action list names are written bare when they are valid identifiers.
+func aplNameToText(name string) string {
+	if aplIdentRegex.MatchString(name) {
+		return name
+	}
+	return strconv.Quote(name)
+}
+
+func aplHiddenPrefix(hide bool) string {
+	if hide {
+		return "hidden "
+	}
+	return ""
+}
+
+// Prepull actions and list items without an action are written as a lone '-'.
+func aplOptionalActionToText(action *proto.APLAction) string {
+	if action == nil {
+		return "-"
+	}
+	return APLActionToText(action)
+}
+
+// Returns the text form of a single action, including its condition.
+func APLActionToText(action *proto.APLAction) string {
+	m := action.ProtoReflect()
+	fd := m.WhichOneof(aplActionOneof)
+	if fd == nil {
+		return "{" + aplFieldsToText(m, false) + "}"
+	}
+
+	text := string(fd.Name()) + "(" + aplFieldsToText(m.Get(fd).Message(), true) + ")"
+	if action.Condition != nil {
+		text += " if " + APLValueToText(action.Condition)
+	}
+	return text
+}
+
+// Returns the text form of a value, using infix operators where possible.
+func APLValueToText(value *proto.APLValue) string {
+	return aplValueToText(value, 0)
+}
+
+func aplValueToText(value *proto.APLValue, minPrec int) string {
+	prec := aplPrecPrimary
+	text := ""
+
+	switch v := value.Value.(type) {
+	case *proto.APLValue_Const:
+		// Tunable constants are written as a call, which keeps their range.
+		if v.Const.Tunable != nil {
+			break
+		}
+		if aplBareConstRegex.MatchString(v.Const.Val) {
+			text = v.Const.Val
+		} else {
+			text = strconv.Quote(v.Const.Val)
+		}
+	case *proto.APLValue_And:
+		if len(v.And.Vals) >= 2 {
+			prec, text = aplPrecAnd, aplJoinValues(v.And.Vals, " and ", aplPrecAnd+1)
+		}
+	case *proto.APLValue_Or:
+		if len(v.Or.Vals) >= 2 {
+			prec, text = aplPrecOr, aplJoinValues(v.Or.Vals, " or ", aplPrecOr+1)
+		}
+	case *proto.APLValue_Not:
+		if v.Not.Val != nil {
+			prec, text = aplPrecNot, "not "+aplValueToText(v.Not.Val, aplPrecNot)
+		}
+	case *proto.APLValue_Cmp:
+		if op, ok := aplCompareOperators[v.Cmp.Op]; ok && v.Cmp.Lhs != nil && v.Cmp.Rhs != nil {
+			// Comparisons don't chain, so both sides need to bind tighter.
+			prec = aplPrecCmp
+			text = aplValueToText(v.Cmp.Lhs, aplPrecCmp+1) + " " + op + " " + aplValueToText(v.Cmp.Rhs, aplPrecCmp+1)
+		}
+	case *proto.APLValue_Math:
+		if op, ok := aplMathOperators[v.Math.Op]; ok && v.Math.Lhs != nil && v.Math.Rhs != nil {
+			// Math operators are left associative, so only the right side needs parentheses at equal precedence.
+			prec = aplPrecAdd
+			if v.Math.Op == proto.APLValueMath_OpMul || v.Math.Op == proto.APLValueMath_OpDiv {
+				prec = aplPrecMul
+			}
+			text = aplValueToText(v.Math.Lhs, prec) + " " + op + " " + aplValueToText(v.Math.Rhs, prec+1)
+		}
+	}
+
+	if text == "" {
+		// Everything without an infix form is written as a call.
+		m := value.ProtoReflect()
+		if fd := m.WhichOneof(aplValueOneof); fd != nil {
+			text = string(fd.Name()) + "(" + aplFieldsToText(m.Get(fd).Message(), true) + ")"
+		} else {
+			text = "{}"
+		}
+	}
+
+	if prec < minPrec {
+		return "(" + text + ")"
+	}
+	return text
+}
+
+func aplJoinValues(values []*proto.APLValue, sep string, minPrec int) string {
+	texts := make([]string, len(values))
+	for i, value := range values {
+		texts[i] = aplValueToText(value, minPrec)
+	}
+	return strings.Join(texts, sep)
+}
+
+// Fields of a message in field number order, which is also the order of positional arguments.
+func aplSortedFields(md protoreflect.MessageDescriptor) []protoreflect.FieldDescriptor {
+	fields := make([]protoreflect.FieldDescriptor, md.Fields().Len())
+	for i := range fields {
+		fields[i] = md.Fields().Get(i)
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Number() < fields[j].Number()
+	})
+	return fields
+}
+
+// Returns the set fields of a message as a comma-separated argument list. With positional set,
+// fields are written without their name until the first unset one.
+func aplFieldsToText(m protoreflect.Message, positional bool) string {
+	fields := aplSortedFields(m.Descriptor())
+	var args []string
+	for i, fd := range fields {
+		if !m.Has(fd) {
+			positional = false
+			continue
+		}
+
+		if fd.IsList() && positional && i == len(fields)-1 {
+			list := m.Get(fd).List()
+			for j := 0; j < list.Len(); j++ {
+				args = append(args, aplSingularToText(fd, list.Get(j)))
+			}
+			continue
+		}
+
+		text := aplFieldToText(fd, m.Get(fd))
+		if !positional {
+			text = string(fd.Name()) + "=" + text
+		}
+		args = append(args, text)
+	}
+	return strings.Join(args, ", ")
+}
+
+func aplFieldToText(fd protoreflect.FieldDescriptor, v protoreflect.Value) string {
+	if fd.IsList() {
+		list := v.List()
+		elems := make([]string, list.Len())
+		for i := range elems {
+			elems[i] = aplSingularToText(fd, list.Get(i))
+		}
+		return "[" + strings.Join(elems, ", ") + "]"
+	}
+	return aplSingularToText(fd, v)
+}
+
+func aplSingularToText(fd protoreflect.FieldDescriptor, v protoreflect.Value) string {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return aplMessageToText(v.Message())
+	case protoreflect.EnumKind:
+		return aplEnumName(fd, v.Enum())
+	case protoreflect.BoolKind:
+		return strconv.FormatBool(v.Bool())
+	case protoreflect.StringKind:
+		return strconv.Quote(v.String())
+	case protoreflect.FloatKind:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 32)
+	case protoreflect.DoubleKind:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return strconv.FormatUint(v.Uint(), 10)
+	default:
+		return strconv.FormatInt(v.Int(), 10)
+	}
+}
+
+func aplEnumName(fd protoreflect.FieldDescriptor, n protoreflect.EnumNumber) string {
+	if ev := fd.Enum().Values().ByNumber(n); ev != nil {
+		return string(ev.Name())
+	}
+	return strconv.Itoa(int(n))
+}
+
+func aplMessageToText(m protoreflect.Message) string {
+	switch msg := m.Interface().(type) {
+	case *proto.APLValue:
+		return APLValueToText(msg)
+	case *proto.APLAction:
+		return APLActionToText(msg)
+	case *proto.ActionID:
+		if text := actionIDToText(msg); text != "" {
+			return text
+		}
+	case *proto.UnitReference:
+		if text := unitReferenceToText(msg); text != "" {
+			return text
+		}
+	}
+	return "{" + aplFieldsToText(m, false) + "}"
+}
+
+// Writes e.g. spell:10181(rank=4), or returns "" if the ID has no short form.
+func actionIDToText(id *proto.ActionID) string {
+	var text string
+	switch rawID := id.RawId.(type) {
+	case *proto.ActionID_SpellId:
+		text = fmt.Sprintf("spell:%d", rawID.SpellId)
+	case *proto.ActionID_ItemId:
+		text = fmt.Sprintf("item:%d", rawID.ItemId)
+	case *proto.ActionID_OtherId:
+		text = "other:" + aplEnumName(id.ProtoReflect().Descriptor().Fields().ByName("other_id"), protoreflect.EnumNumber(rawID.OtherId))
+	default:
+		return ""
+	}
+
+	rest := googleProto.Clone(id).(*proto.ActionID)
+	rest.RawId = nil
+	if extra := aplFieldsToText(rest.ProtoReflect(), false); extra != "" {
+		text += "(" + extra + ")"
+	}
+	return text
+}
+
+// Writes e.g. current_target or target:1, or returns "" if the reference has no short form.
+func unitReferenceToText(ref *proto.UnitReference) string {
+	ev := ref.Type.Descriptor().Values().ByNumber(protoreflect.EnumNumber(ref.Type))
+	if ev == nil {
+		return ""
+	}
+
+	text := aplSnakeCase(string(ev.Name()))
+	if ref.Index != 0 {
+		text += fmt.Sprintf(":%d", ref.Index)
+	}
+	if ref.Owner != nil {
+		text += "(owner=" + aplMessageToText(ref.Owner.ProtoReflect()) + ")"
+	}
+	return text
+}
+
+// Converts e.g. CurrentTarget to current_target.
+func aplSnakeCase(name string) string {
+	var sb strings.Builder
+	for i, r := range name {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 {
+				sb.WriteByte('_')
+			}
+			r += 'a' - 'A'
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -0,0 +1,884 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/wowsims/sod/sim/core/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// Error for malformed APL text, with the 1-based position of the offending token.
+type APLSyntaxError struct {
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (err *APLSyntaxError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", err.Line, err.Column, err.Msg)
+}
+
+// Parses a rotation in the APL text format, see APLRotationToText.
+func APLRotationFromText(text string) (*proto.APLRotation, error) {
+	tokens, err := tokenizeAPLText(text)
+	if err != nil {
+		return nil, err
+	}
+	parser := &aplTextParser{tokens: tokens}
+	return parser.parseRotation()
+}
+
+type aplTokenKind int
+
+const (
+	aplTokenEOF aplTokenKind = iota
+	aplTokenNewline
+	aplTokenComment
+	aplTokenIdent
+	aplTokenNumber
+	aplTokenString
+	aplTokenPunct
+)
+
+type aplToken struct {
+	kind   aplTokenKind
+	text   string
+	line   int
+	column int
+}
+
+func (tok aplToken) String() string {
+	switch tok.kind {
+	case aplTokenEOF:
+		return "end of input"
+	case aplTokenNewline:
+		return "end of line"
+	case aplTokenString:
+		return tok.text
+	default:
+		return strconv.Quote(tok.text)
+	}
+}
+
+var aplTwoCharPuncts = []string{"==", "!=", "<=", ">="}
+
+const aplOneCharPuncts = "()[]{},:=<>+-*/"
+
+// Splits APL text into tokens. Newlines and comments are only kept outside of brackets, where
+// they separate statements. Comments are only kept when they are on a line of their own.
+func tokenizeAPLText(text string) ([]aplToken, error) {
+	runes := []rune(text)
+	var tokens []aplToken
+	line, lineStart := 1, 0
+	depth := 0
+	lineHasTokens := false
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		tok := aplToken{line: line, column: i - lineStart + 1}
+
+		switch {
+		case r == '\n':
+			if depth == 0 {
+				tok.kind = aplTokenNewline
+				tokens = append(tokens, tok)
+			}
+			i++
+			line, lineStart = line+1, i
+			lineHasTokens = false
+			continue
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case r == '#':
+			start := i
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			if depth == 0 && !lineHasTokens {
+				tok.kind, tok.text = aplTokenComment, strings.TrimRight(string(runes[start:i]), "\r")
+				tokens = append(tokens, tok)
+			}
+			continue
+		case r == '"':
+			start := i
+			for i++; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' {
+					i++
+				}
+				if i < len(runes) && runes[i] == '\n' {
+					break
+				}
+			}
+			if i >= len(runes) || runes[i] != '"' {
+				return nil, &APLSyntaxError{Line: tok.line, Column: tok.column, Msg: "unterminated string"}
+			}
+			i++
+			tok.kind, tok.text = aplTokenString, string(runes[start:i])
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && unicode.IsDigit(runes[i]) {
+				i++
+			}
+			if i+1 < len(runes) && runes[i] == '.' && unicode.IsDigit(runes[i+1]) {
+				for i++; i < len(runes) && unicode.IsDigit(runes[i]); i++ {
+				}
+			}
+			// Units, e.g. 1.5s or 20%.
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || runes[i] == '%') {
+				i++
+			}
+			tok.kind, tok.text = aplTokenNumber, string(runes[start:i])
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tok.kind, tok.text = aplTokenIdent, string(runes[start:i])
+		default:
+			tok.kind = aplTokenPunct
+			for _, punct := range aplTwoCharPuncts {
+				if strings.HasPrefix(string(runes[i:min(i+2, len(runes))]), punct) {
+					tok.text = punct
+				}
+			}
+			if tok.text == "" {
+				if !strings.ContainsRune(aplOneCharPuncts, r) {
+					return nil, &APLSyntaxError{Line: tok.line, Column: tok.column, Msg: fmt.Sprintf("unexpected character %q", r)}
+				}
+				tok.text = string(r)
+			}
+			i += len(tok.text)
+
+			switch tok.text {
+			case "(", "[", "{":
+				depth++
+			case ")", "]", "}":
+				depth = max(depth-1, 0)
+			}
+		}
+
+		tokens = append(tokens, tok)
+		lineHasTokens = true
+	}
+
+	tokens = append(tokens, aplToken{kind: aplTokenEOF, line: line, column: len(runes) - lineStart + 1})
+	return tokens, nil
+}
+
+type aplTextParser struct {
+	tokens []aplToken
+	pos    int
+
+	// Action list which action statements are added to, after a list header. Nil for the priority list.
+	actionList *proto.APLActionList
+}
+
+func (p *aplTextParser) peek() aplToken {
+	return p.peekAt(0)
+}
+
+func (p *aplTextParser) peekAt(offset int) aplToken {
+	return p.tokens[min(p.pos+offset, len(p.tokens)-1)]
+}
+
+func (p *aplTextParser) next() aplToken {
+	tok := p.peek()
+	if tok.kind != aplTokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *aplTextParser) isPunct(offset int, text string) bool {
+	tok := p.peekAt(offset)
+	return tok.kind == aplTokenPunct && tok.text == text
+}
+
+func (p *aplTextParser) isIdent(offset int, text string) bool {
+	tok := p.peekAt(offset)
+	return tok.kind == aplTokenIdent && tok.text == text
+}
+
+// A keyword is an identifier which isn't immediately called like a function.
+func (p *aplTextParser) isKeyword(text string) bool {
+	return p.isIdent(0, text) && !p.isPunct(1, "(")
+}
+
+func (p *aplTextParser) errorf(tok aplToken, format string, args ...interface{}) error {
+	return &APLSyntaxError{Line: tok.line, Column: tok.column, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *aplTextParser) expectPunct(text string) error {
+	if tok := p.next(); tok.kind != aplTokenPunct || tok.text != text {
+		return p.errorf(tok, "expected %q, found %s", text, tok)
+	}
+	return nil
+}
+
+func (p *aplTextParser) parseRotation() (*proto.APLRotation, error) {
+	rotation := &proto.APLRotation{}
+	var notes []string
+	afterNewline := true
+
+	for {
+		tok := p.peek()
+		switch tok.kind {
+		case aplTokenEOF:
+			return rotation, nil
+		case aplTokenNewline:
+			p.next()
+			// A blank line separates comments from the next item.
+			if afterNewline {
+				notes = nil
+			}
+			afterNewline = true
+			continue
+		case aplTokenComment:
+			p.next()
+			note := strings.TrimPrefix(tok.text, "#")
+			notes = append(notes, strings.TrimPrefix(note, " "))
+			afterNewline = false
+			continue
+		}
+
+		if err := p.parseStatement(rotation, strings.Join(notes, "\n")); err != nil {
+			return nil, err
+		}
+		notes = nil
+
+		if tok := p.next(); tok.kind != aplTokenNewline && tok.kind != aplTokenEOF {
+			return nil, p.errorf(tok, "expected end of line, found %s", tok)
+		}
+		afterNewline = true
+	}
+}
+
+func (p *aplTextParser) parseStatement(rotation *proto.APLRotation, notes string) error {
+	hide := false
+	if p.isIdent(0, "hidden") {
+		p.next()
+		hide = true
+	}
+
+	switch {
+	case !hide && p.isIdent(0, "type") && p.isPunct(1, ":"):
+		p.pos += 2
+		fd := rotation.ProtoReflect().Descriptor().Fields().ByName("type")
+		value, err := p.parseSingular(fd)
+		if err != nil {
+			return err
+		}
+		rotation.ProtoReflect().Set(fd, value)
+	case !hide && p.isIdent(0, "simple") && p.isPunct(1, ":"):
+		p.pos += 2
+		rotation.Simple = &proto.SimpleRotation{}
+		if err := p.expectPunct("{"); err != nil {
+			return err
+		}
+		return p.parseFields(rotation.Simple.ProtoReflect(), "}")
+	case !hide && p.isIdent(0, "variable") && !p.isPunct(1, "("):
+		p.next()
+		name, err := p.parseName()
+		if err != nil {
+			return err
+		}
+		variable := &proto.APLVariable{Name: name}
+		if p.isPunct(0, "=") {
+			p.next()
+			value, err := p.parseValue()
+			if err != nil {
+				return err
+			}
+			variable.Value = value
+		}
+		rotation.Variables = append(rotation.Variables, variable)
+	case !hide && p.isIdent(0, "list") && !p.isPunct(1, "("):
+		p.next()
+		name, err := p.parseName()
+		if err != nil {
+			return err
+		}
+		if err := p.expectPunct(":"); err != nil {
+			return err
+		}
+		p.actionList = &proto.APLActionList{Name: name}
+		rotation.ActionLists = append(rotation.ActionLists, p.actionList)
+	case p.isIdent(0, "prepull"):
+		p.next()
+		prepullAction := &proto.APLPrepullAction{Hide: hide}
+		if !p.isPunct(0, ":") {
+			value, err := p.parseValue()
+			if err != nil {
+				return err
+			}
+			prepullAction.DoAtValue = value
+		}
+		if err := p.expectPunct(":"); err != nil {
+			return err
+		}
+		action, err := p.parseOptionalAction()
+		if err != nil {
+			return err
+		}
+		prepullAction.Action = action
+		rotation.PrepullActions = append(rotation.PrepullActions, prepullAction)
+	default:
+		action, err := p.parseOptionalAction()
+		if err != nil {
+			return err
+		}
+		item := &proto.APLListItem{
+			Hide:   hide,
+			Notes:  notes,
+			Action: action,
+		}
+		if p.actionList != nil {
+			p.actionList.Items = append(p.actionList.Items, item)
+		} else {
+			rotation.PriorityList = append(rotation.PriorityList, item)
+		}
+	}
+	return nil
+}
+
+// Parses the name of a variable or action list, which is an identifier or a quoted string.
+func (p *aplTextParser) parseName() (string, error) {
+	if tok := p.peek(); tok.kind == aplTokenIdent {
+		p.next()
+		return tok.text, nil
+	}
+	return p.parseString()
+}
+
+func (p *aplTextParser) parseOptionalAction() (*proto.APLAction, error) {
+	if p.isPunct(0, "-") {
+		p.next()
+		return nil, nil
+	}
+	return p.parseAction()
+}
+
+func (p *aplTextParser) parseAction() (*proto.APLAction, error) {
+	action := &proto.APLAction{}
+	m := action.ProtoReflect()
+	tok := p.next()
+
+	switch {
+	case tok.kind == aplTokenPunct && tok.text == "{":
+		if err := p.parseFields(m, "}"); err != nil {
+			return nil, err
+		}
+	case tok.kind == aplTokenIdent && p.isPunct(0, "("):
+		fd := aplActionOneof.Fields().ByName(protoreflect.Name(tok.text))
+		if fd == nil {
+			return nil, p.errorf(tok, "unknown action %q", tok.text)
+		}
+		p.next()
+		sub := newAPLFieldMessage(fd)
+		if err := p.parseFields(sub, ")"); err != nil {
+			return nil, err
+		}
+		m.Set(fd, protoreflect.ValueOfMessage(sub))
+	default:
+		return nil, p.errorf(tok, "expected an action, found %s", tok)
+	}
+
+	if p.isIdent(0, "if") {
+		ifTok := p.next()
+		if action.Condition != nil {
+			return nil, p.errorf(ifTok, "action already has a condition")
+		}
+		condition, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		action.Condition = condition
+	}
+	return action, nil
+}
+
+// Parses a value expression, see the precedence levels in apl_text.go.
+func (p *aplTextParser) parseValue() (*proto.APLValue, error) {
+	return p.parseOr()
+}
+
+func (p *aplTextParser) parseOr() (*proto.APLValue, error) {
+	vals, err := p.parseOperands("or", p.parseAnd)
+	if err != nil || len(vals) == 1 {
+		return firstAPLValue(vals), err
+	}
+	return &proto.APLValue{Value: &proto.APLValue_Or{Or: &proto.APLValueOr{Vals: vals}}}, nil
+}
+
+func (p *aplTextParser) parseAnd() (*proto.APLValue, error) {
+	vals, err := p.parseOperands("and", p.parseNot)
+	if err != nil || len(vals) == 1 {
+		return firstAPLValue(vals), err
+	}
+	return &proto.APLValue{Value: &proto.APLValue_And{And: &proto.APLValueAnd{Vals: vals}}}, nil
+}
+
+func firstAPLValue(vals []*proto.APLValue) *proto.APLValue {
+	if len(vals) == 0 {
+		return nil
+	}
+	return vals[0]
+}
+
+func (p *aplTextParser) parseOperands(keyword string, parseOperand func() (*proto.APLValue, error)) ([]*proto.APLValue, error) {
+	var vals []*proto.APLValue
+	for {
+		val, err := parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		vals = append(vals, val)
+		if !p.isIdent(0, keyword) {
+			return vals, nil
+		}
+		p.next()
+	}
+}
+
+func (p *aplTextParser) parseNot() (*proto.APLValue, error) {
+	// not(...) is parsed as a call, which gives the same result.
+	if !p.isKeyword("not") {
+		return p.parseCompare()
+	}
+	p.next()
+	val, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	return &proto.APLValue{Value: &proto.APLValue_Not{Not: &proto.APLValueNot{Val: val}}}, nil
+}
+
+func (p *aplTextParser) parseCompare() (*proto.APLValue, error) {
+	lhs, err := p.parseMath(aplPrecAdd)
+	if err != nil {
+		return nil, err
+	}
+	for op, text := range aplCompareOperators {
+		if p.isPunct(0, text) {
+			p.next()
+			rhs, err := p.parseMath(aplPrecAdd)
+			if err != nil {
+				return nil, err
+			}
+			return &proto.APLValue{Value: &proto.APLValue_Cmp{Cmp: &proto.APLValueCompare{Op: op, Lhs: lhs, Rhs: rhs}}}, nil
+		}
+	}
+	return lhs, nil
+}
+
+// Parses left associative math operators of the given precedence or tighter.
+func (p *aplTextParser) parseMath(prec int) (*proto.APLValue, error) {
+	parseOperand := p.parsePrimary
+	if prec == aplPrecAdd {
+		parseOperand = func() (*proto.APLValue, error) { return p.parseMath(aplPrecMul) }
+	}
+
+	lhs, err := parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.peekMathOperator(prec)
+		if !ok {
+			return lhs, nil
+		}
+		p.next()
+		rhs, err := parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		lhs = &proto.APLValue{Value: &proto.APLValue_Math{Math: &proto.APLValueMath{Op: op, Lhs: lhs, Rhs: rhs}}}
+	}
+}
+
+func (p *aplTextParser) peekMathOperator(prec int) (proto.APLValueMath_MathOperator, bool) {
+	for op, text := range aplMathOperators {
+		isMul := op == proto.APLValueMath_OpMul || op == proto.APLValueMath_OpDiv
+		if p.isPunct(0, text) && isMul == (prec == aplPrecMul) {
+			return op, true
+		}
+	}
+	return proto.APLValueMath_OpUnknown, false
+}
+
+func (p *aplTextParser) parsePrimary() (*proto.APLValue, error) {
+	tok := p.peek()
+	switch {
+	case tok.kind == aplTokenPunct && tok.text == "(":
+		p.next()
+		val, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		return val, p.expectPunct(")")
+	case tok.kind == aplTokenPunct && tok.text == "{":
+		p.next()
+		val := &proto.APLValue{}
+		return val, p.parseFields(val.ProtoReflect(), "}")
+	case tok.kind == aplTokenString:
+		str, err := p.parseString()
+		return aplConstValue(str), err
+	case tok.kind == aplTokenNumber || (tok.kind == aplTokenPunct && tok.text == "-"):
+		number, _, err := p.parseNumber()
+		return aplConstValue(number), err
+	case tok.kind == aplTokenIdent && (tok.text == "true" || tok.text == "false") && !p.isPunct(1, "("):
+		p.next()
+		return aplConstValue(tok.text), nil
+	case tok.kind == aplTokenIdent && p.isPunct(1, "("):
+		fd := aplValueOneof.Fields().ByName(protoreflect.Name(tok.text))
+		if fd == nil {
+			return nil, p.errorf(tok, "unknown value %q", tok.text)
+		}
+		p.pos += 2
+		sub := newAPLFieldMessage(fd)
+		if err := p.parseFields(sub, ")"); err != nil {
+			return nil, err
+		}
+		val := &proto.APLValue{}
+		val.ProtoReflect().Set(fd, protoreflect.ValueOfMessage(sub))
+		return val, nil
+	case tok.kind == aplTokenIdent:
+		return nil, p.errorf(tok, "unknown value %q, values are called like %s()", tok.text, tok.text)
+	default:
+		return nil, p.errorf(tok, "expected a value, found %s", tok)
+	}
+}
+
+func aplConstValue(val string) *proto.APLValue {
+	return &proto.APLValue{Value: &proto.APLValue_Const{Const: &proto.APLValueConst{Val: val}}}
+}
+
+// Parses an argument list up to the closing bracket into the fields of m. Positional arguments
+// fill fields in field number order, and a repeated last field takes all remaining ones.
+func (p *aplTextParser) parseFields(m protoreflect.Message, closing string) error {
+	fields := aplSortedFields(m.Descriptor())
+	nextPositional := 0
+	seenNamed := false
+	seen := map[protoreflect.FieldNumber]bool{}
+
+	for !p.isPunct(0, closing) {
+		tok := p.peek()
+		var fd protoreflect.FieldDescriptor
+		variadic := false
+
+		if tok.kind == aplTokenIdent && p.isPunct(1, "=") {
+			fd = m.Descriptor().Fields().ByName(protoreflect.Name(tok.text))
+			if fd == nil {
+				return p.errorf(tok, "unknown field %q for %s", tok.text, m.Descriptor().Name())
+			}
+			p.pos += 2
+			seenNamed = true
+		} else {
+			if seenNamed {
+				return p.errorf(tok, "positional argument after named argument")
+			}
+			if nextPositional >= len(fields) {
+				return p.errorf(tok, "too many arguments for %s", m.Descriptor().Name())
+			}
+			fd = fields[nextPositional]
+			variadic = fd.IsList() && nextPositional == len(fields)-1
+			if !variadic {
+				nextPositional++
+			}
+		}
+
+		if variadic {
+			value, err := p.parseSingular(fd)
+			if err != nil {
+				return err
+			}
+			m.Mutable(fd).List().Append(value)
+		} else {
+			if seen[fd.Number()] {
+				return p.errorf(tok, "field %q is set more than once", fd.Name())
+			}
+			if err := p.parseField(m, fd); err != nil {
+				return err
+			}
+		}
+		seen[fd.Number()] = true
+
+		if !p.isPunct(0, ",") {
+			break
+		}
+		p.next()
+	}
+	return p.expectPunct(closing)
+}
+
+func (p *aplTextParser) parseField(m protoreflect.Message, fd protoreflect.FieldDescriptor) error {
+	if fd.IsMap() {
+		return p.errorf(p.peek(), "map field %q is not supported", fd.Name())
+	}
+	if !fd.IsList() {
+		value, err := p.parseSingular(fd)
+		if err != nil {
+			return err
+		}
+		m.Set(fd, value)
+		return nil
+	}
+
+	if err := p.expectPunct("["); err != nil {
+		return err
+	}
+	list := m.Mutable(fd).List()
+	for !p.isPunct(0, "]") {
+		value, err := p.parseSingular(fd)
+		if err != nil {
+			return err
+		}
+		list.Append(value)
+		if !p.isPunct(0, ",") {
+			break
+		}
+		p.next()
+	}
+	return p.expectPunct("]")
+}
+
+func (p *aplTextParser) parseSingular(fd protoreflect.FieldDescriptor) (protoreflect.Value, error) {
+	tok := p.peek()
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		m, err := p.parseMessage(fd)
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		return protoreflect.ValueOfMessage(m), nil
+	case protoreflect.EnumKind:
+		n, err := p.parseEnum(fd.Enum())
+		return protoreflect.ValueOfEnum(n), err
+	case protoreflect.BoolKind:
+		p.next()
+		if tok.kind != aplTokenIdent || (tok.text != "true" && tok.text != "false") {
+			return protoreflect.Value{}, p.errorf(tok, "expected true or false, found %s", tok)
+		}
+		return protoreflect.ValueOfBool(tok.text == "true"), nil
+	case protoreflect.StringKind:
+		str, err := p.parseString()
+		return protoreflect.ValueOfString(str), err
+	case protoreflect.BytesKind:
+		return protoreflect.Value{}, p.errorf(tok, "bytes field %q is not supported", fd.Name())
+	}
+
+	number, numTok, err := p.parseNumber()
+	if err != nil {
+		return protoreflect.Value{}, err
+	}
+	var value protoreflect.Value
+	switch fd.Kind() {
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		bitSize := 64
+		if fd.Kind() == protoreflect.FloatKind {
+			bitSize = 32
+		}
+		var f float64
+		if f, err = strconv.ParseFloat(number, bitSize); err == nil {
+			value = protoreflect.ValueOfFloat64(f)
+			if bitSize == 32 {
+				value = protoreflect.ValueOfFloat32(float32(f))
+			}
+		}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		var i int64
+		if i, err = strconv.ParseInt(number, 10, 32); err == nil {
+			value = protoreflect.ValueOfInt32(int32(i))
+		}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		var i int64
+		if i, err = strconv.ParseInt(number, 10, 64); err == nil {
+			value = protoreflect.ValueOfInt64(i)
+		}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		var u uint64
+		if u, err = strconv.ParseUint(number, 10, 32); err == nil {
+			value = protoreflect.ValueOfUint32(uint32(u))
+		}
+	default:
+		var u uint64
+		if u, err = strconv.ParseUint(number, 10, 64); err == nil {
+			value = protoreflect.ValueOfUint64(u)
+		}
+	}
+	if err != nil {
+		return protoreflect.Value{}, p.errorf(numTok, "invalid %s value %q for field %q", fd.Kind(), number, fd.Name())
+	}
+	return value, nil
+}
+
+func (p *aplTextParser) parseMessage(fd protoreflect.FieldDescriptor) (protoreflect.Message, error) {
+	m := newAPLFieldMessage(fd)
+	if p.isPunct(0, "{") {
+		p.next()
+		return m, p.parseFields(m, "}")
+	}
+
+	switch msg := m.Interface().(type) {
+	case *proto.APLValue:
+		val, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		return val.ProtoReflect(), nil
+	case *proto.APLAction:
+		action, err := p.parseAction()
+		if err != nil {
+			return nil, err
+		}
+		return action.ProtoReflect(), nil
+	case *proto.ActionID:
+		return m, p.parseActionID(msg)
+	case *proto.UnitReference:
+		return m, p.parseUnitReference(msg)
+	default:
+		tok := p.peek()
+		return nil, p.errorf(tok, "expected {...} for field %q, found %s", fd.Name(), tok)
+	}
+}
+
+func newAPLFieldMessage(fd protoreflect.FieldDescriptor) protoreflect.Message {
+	mt, err := protoregistry.GlobalTypes.FindMessageByName(fd.Message().FullName())
+	if err != nil {
+		panic(err)
+	}
+	return mt.New()
+}
+
+// Parses e.g. spell:133, item:19950 or other:OtherActionPotion, optionally followed by (tag=1, rank=2).
+func (p *aplTextParser) parseActionID(id *proto.ActionID) error {
+	tok := p.next()
+	if tok.kind != aplTokenIdent || !p.isPunct(0, ":") {
+		return p.errorf(tok, "expected an action ID such as spell:133, found %s", tok)
+	}
+	p.next()
+
+	switch tok.text {
+	case "spell", "item":
+		number, numTok, err := p.parseNumber()
+		if err != nil {
+			return err
+		}
+		rawID, err := strconv.ParseInt(number, 10, 32)
+		if err != nil {
+			return p.errorf(numTok, "invalid %s ID %q", tok.text, number)
+		}
+		if tok.text == "spell" {
+			id.RawId = &proto.ActionID_SpellId{SpellId: int32(rawID)}
+		} else {
+			id.RawId = &proto.ActionID_ItemId{ItemId: int32(rawID)}
+		}
+	case "other":
+		n, err := p.parseEnum(proto.OtherAction(0).Descriptor())
+		if err != nil {
+			return err
+		}
+		id.RawId = &proto.ActionID_OtherId{OtherId: proto.OtherAction(n)}
+	default:
+		return p.errorf(tok, "unknown action ID kind %q, expected spell, item or other", tok.text)
+	}
+
+	if p.isPunct(0, "(") {
+		p.next()
+		return p.parseFields(id.ProtoReflect(), ")")
+	}
+	return nil
+}
+
+// Parses e.g. self, current_target or target:1, optionally followed by (owner=player).
+func (p *aplTextParser) parseUnitReference(ref *proto.UnitReference) error {
+	tok := p.next()
+	if tok.kind != aplTokenIdent {
+		return p.errorf(tok, "expected a unit such as current_target, found %s", tok)
+	}
+
+	values := ref.Type.Descriptor().Values()
+	found := false
+	for i := 0; i < values.Len(); i++ {
+		if aplSnakeCase(string(values.Get(i).Name())) == tok.text {
+			ref.Type = proto.UnitReference_Type(values.Get(i).Number())
+			found = true
+		}
+	}
+	if !found {
+		return p.errorf(tok, "unknown unit %q", tok.text)
+	}
+
+	if p.isPunct(0, ":") {
+		p.next()
+		number, numTok, err := p.parseNumber()
+		if err != nil {
+			return err
+		}
+		index, err := strconv.ParseInt(number, 10, 32)
+		if err != nil {
+			return p.errorf(numTok, "invalid unit index %q", number)
+		}
+		ref.Index = int32(index)
+	}
+
+	if p.isPunct(0, "(") {
+		p.next()
+		return p.parseFields(ref.ProtoReflect(), ")")
+	}
+	return nil
+}
+
+// Parses an enum value by name, or by number.
+func (p *aplTextParser) parseEnum(ed protoreflect.EnumDescriptor) (protoreflect.EnumNumber, error) {
+	tok := p.peek()
+	if tok.kind == aplTokenIdent {
+		p.next()
+		if ev := ed.Values().ByName(protoreflect.Name(tok.text)); ev != nil {
+			return ev.Number(), nil
+		}
+		return 0, p.errorf(tok, "unknown %s value %q", ed.Name(), tok.text)
+	}
+
+	number, numTok, err := p.parseNumber()
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseInt(number, 10, 32)
+	if err != nil {
+		return 0, p.errorf(numTok, "invalid %s value %q", ed.Name(), number)
+	}
+	return protoreflect.EnumNumber(n), nil
+}
+
+func (p *aplTextParser) parseString() (string, error) {
+	tok := p.next()
+	if tok.kind != aplTokenString {
+		return "", p.errorf(tok, "expected a string, found %s", tok)
+	}
+	str, err := strconv.Unquote(tok.text)
+	if err != nil {
+		return "", p.errorf(tok, "invalid string %s", tok.text)
+	}
+	return str, nil
+}
+
+// Parses a number with an optional minus sign, returning its text and first token.
+func (p *aplTextParser) parseNumber() (string, aplToken, error) {
+	first := p.peek()
+	sign := ""
+	if p.isPunct(0, "-") {
+		p.next()
+		sign = "-"
+	}
+	tok := p.next()
+	if tok.kind != aplTokenNumber {
+		return "", first, p.errorf(tok, "expected a number, found %s", tok)
+	}
+	return sign + tok.text, first, nil
+}
@@ -0,0 +1,341 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/wowsims/sod/sim/core/proto"
+	"google.golang.org/protobuf/encoding/protojson"
+	googleProto "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func TestAPLTextParse(t *testing.T) {
+	text := `type: TypeAPL
+prepull -6s: cast_spell(spell:12525(rank=6))
+
+# Evocate when low.
+#
+#  Indented.
+cast_spell(spell:12051) if current_mana_percent() <= 15% # trailing comments are ignored
+
+# Separated from the next item by a blank line, so not a note.
+
+hidden multidot(spell:400613, 3, max_overlap=0.5s)
+sequence("opener", cast_spell(spell:1), cast_spell(spell:2) if not (gcd_is_ready() or current_time() > 2s - 1s * 3))
+`
+	expected := &proto.APLRotation{
+		Type: proto.APLRotation_TypeAPL,
+		PrepullActions: []*proto.APLPrepullAction{{
+			DoAtValue: aplConstValue("-6s"),
+			Action:    &proto.APLAction{Action: &proto.APLAction_CastSpell{CastSpell: &proto.APLActionCastSpell{SpellId: &proto.ActionID{RawId: &proto.ActionID_SpellId{SpellId: 12525}, Rank: 6}}}},
+		}},
+		PriorityList: []*proto.APLListItem{
+			{
+				Notes: "Evocate when low.\n\n Indented.",
+				Action: &proto.APLAction{
+					Condition: &proto.APLValue{Value: &proto.APLValue_Cmp{Cmp: &proto.APLValueCompare{
+						Op:  proto.APLValueCompare_OpLe,
+						Lhs: &proto.APLValue{Value: &proto.APLValue_CurrentManaPercent{CurrentManaPercent: &proto.APLValueCurrentManaPercent{}}},
+						Rhs: aplConstValue("15%"),
+					}}},
+					Action: &proto.APLAction_CastSpell{CastSpell: &proto.APLActionCastSpell{SpellId: &proto.ActionID{RawId: &proto.ActionID_SpellId{SpellId: 12051}}}},
+				},
+			},
+			{
+				Hide: true,
+				Action: &proto.APLAction{Action: &proto.APLAction_Multidot{Multidot: &proto.APLActionMultidot{
+					SpellId:    &proto.ActionID{RawId: &proto.ActionID_SpellId{SpellId: 400613}},
+					MaxDots:    3,
+					MaxOverlap: aplConstValue("0.5s"),
+				}}},
+			},
+			{
+				Action: &proto.APLAction{Action: &proto.APLAction_Sequence{Sequence: &proto.APLActionSequence{
+					Name: "opener",
+					Actions: []*proto.APLAction{
+						{Action: &proto.APLAction_CastSpell{CastSpell: &proto.APLActionCastSpell{SpellId: &proto.ActionID{RawId: &proto.ActionID_SpellId{SpellId: 1}}}}},
+						{
+							Condition: &proto.APLValue{Value: &proto.APLValue_Not{Not: &proto.APLValueNot{Val: &proto.APLValue{Value: &proto.APLValue_Or{Or: &proto.APLValueOr{Vals: []*proto.APLValue{
+								{Value: &proto.APLValue_GcdIsReady{GcdIsReady: &proto.APLValueGCDIsReady{}}},
+								{Value: &proto.APLValue_Cmp{Cmp: &proto.APLValueCompare{
+									Op:  proto.APLValueCompare_OpGt,
+									Lhs: &proto.APLValue{Value: &proto.APLValue_CurrentTime{CurrentTime: &proto.APLValueCurrentTime{}}},
+									Rhs: &proto.APLValue{Value: &proto.APLValue_Math{Math: &proto.APLValueMath{
+										Op:  proto.APLValueMath_OpSub,
+										Lhs: aplConstValue("2s"),
+										Rhs: &proto.APLValue{Value: &proto.APLValue_Math{Math: &proto.APLValueMath{Op: proto.APLValueMath_OpMul, Lhs: aplConstValue("1s"), Rhs: aplConstValue("3")}}},
+									}}},
+								}}},
+							}}}}}}},
+							Action: &proto.APLAction_CastSpell{CastSpell: &proto.APLActionCastSpell{SpellId: &proto.ActionID{RawId: &proto.ActionID_SpellId{SpellId: 2}}}},
+						},
+					},
+				}}},
+			},
+		},
+	}
+
+	rotation, err := APLRotationFromText(text)
+	if err != nil {
+		t.Fatalf("Failed to parse rotation: %s", err)
+	}
+	if !googleProto.Equal(rotation, expected) {
+		t.Fatalf("Unexpected rotation:\n%s\nExpected:\n%s", protojson.Format(rotation), protojson.Format(expected))
+	}
+}
+
+func TestAPLTextRoundTripUIRotations(t *testing.T) {
+	files, err := filepath.Glob("../../ui/*/apls/*.apl.json")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("No APL files found: %v", err)
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %s", file, err)
+		}
+		rotation := &proto.APLRotation{}
+		if err := protojson.Unmarshal(data, rotation); err != nil {
+			t.Fatalf("Failed to unmarshal %s: %s", file, err)
+		}
+		checkAPLTextRoundTrip(t, file, rotation)
+	}
+}
+
+// Builds every action and value type with all of its fields set, and checks that the text
+// format reproduces it exactly.
+func TestAPLTextRoundTripAllTypes(t *testing.T) {
+	for i := 0; i < aplActionOneof.Fields().Len(); i++ {
+		fd := aplActionOneof.Fields().Get(i)
+		action := &proto.APLAction{Condition: aplConstValue("true")}
+		action.ProtoReflect().Set(fd, protoreflect.ValueOfMessage(fillAPLTestMessage(newAPLFieldMessage(fd), 2)))
+		checkAPLTextRoundTrip(t, string(fd.Name()), &proto.APLRotation{PriorityList: []*proto.APLListItem{{Action: action}}})
+	}
+
+	for i := 0; i < aplValueOneof.Fields().Len(); i++ {
+		fd := aplValueOneof.Fields().Get(i)
+		value := &proto.APLValue{}
+		value.ProtoReflect().Set(fd, protoreflect.ValueOfMessage(fillAPLTestMessage(newAPLFieldMessage(fd), 2)))
+		checkAPLTextRoundTrip(t, string(fd.Name()), &proto.APLRotation{PrepullActions: []*proto.APLPrepullAction{{DoAtValue: value}}})
+	}
+}
+
+func TestAPLTextRoundTripOperators(t *testing.T) {
+	a := aplConstValue("1")
+	b := aplConstValue("-2s")
+	c := aplConstValue("some string")
+	math := func(op proto.APLValueMath_MathOperator, lhs, rhs *proto.APLValue) *proto.APLValue {
+		return &proto.APLValue{Value: &proto.APLValue_Math{Math: &proto.APLValueMath{Op: op, Lhs: lhs, Rhs: rhs}}}
+	}
+	cmp := func(op proto.APLValueCompare_ComparisonOperator, lhs, rhs *proto.APLValue) *proto.APLValue {
+		return &proto.APLValue{Value: &proto.APLValue_Cmp{Cmp: &proto.APLValueCompare{Op: op, Lhs: lhs, Rhs: rhs}}}
+	}
+	and := func(vals ...*proto.APLValue) *proto.APLValue {
+		return &proto.APLValue{Value: &proto.APLValue_And{And: &proto.APLValueAnd{Vals: vals}}}
+	}
+	or := func(vals ...*proto.APLValue) *proto.APLValue {
+		return &proto.APLValue{Value: &proto.APLValue_Or{Or: &proto.APLValueOr{Vals: vals}}}
+	}
+	not := func(val *proto.APLValue) *proto.APLValue {
+		return &proto.APLValue{Value: &proto.APLValue_Not{Not: &proto.APLValueNot{Val: val}}}
+	}
+
+	values := []*proto.APLValue{
+		math(proto.APLValueMath_OpSub, a, math(proto.APLValueMath_OpSub, b, c)),
+		math(proto.APLValueMath_OpMul, math(proto.APLValueMath_OpAdd, a, b), math(proto.APLValueMath_OpDiv, b, c)),
+		cmp(proto.APLValueCompare_OpLt, cmp(proto.APLValueCompare_OpEq, a, b), not(c)),
+		not(and(or(a, b), not(not(c)), and(a, b))),
+		or(and(a), or(b), &proto.APLValue{}),
+		and(),
+		cmp(proto.APLValueCompare_OpUnknown, a, b),
+		math(proto.APLValueMath_OpAdd, nil, b),
+		not(nil),
+	}
+	for _, value := range values {
+		checkAPLTextRoundTrip(t, APLValueToText(value), &proto.APLRotation{PrepullActions: []*proto.APLPrepullAction{{DoAtValue: value}}})
+	}
+}
+
+func TestAPLTextSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		text string
+		err  string
+	}{
+		{"cast_spell(spell:1) if", "1:23: expected a value, found end of input"},
+		{"\n  cast_spel(spell:1)", "2:3: unknown action \"cast_spel\""},
+		{"cast_spell(spell:1) if current_time < 1s", "1:24: unknown value \"current_time\", values are called like current_time()"},
+		{"cast_spell(spell:1,\n    foo=1)", "2:5: unknown field \"foo\" for APLActionCastSpell"},
+		{"cast_spell(target=self, spell:1)", "1:25: positional argument after named argument"},
+		{"multidot(spell:1, 1.5s)", "1:19: invalid int32 value \"1.5s\" for field \"max_dots\""},
+		{"cast_spell(\"133\")", "1:12: expected an action ID such as spell:133, found \"133\""},
+		{"wait(1s) wait(2s)", "1:10: expected end of line, found \"wait\""},
+		{"wait_until(is_execute_phase(E30))", "1:29: unknown ExecutePhaseThreshold value \"E30\""},
+		{"wait(\"1s)", "1:6: unterminated string"},
+		{"wait(1s) ; wait(2s)", "1:10: unexpected character ';'"},
+	}
+
+	for _, test := range tests {
+		_, err := APLRotationFromText(test.text)
+		if err == nil {
+			t.Errorf("Expected error %q for %q", test.err, test.text)
+		} else if err.Error() != test.err {
+			t.Errorf("Got error %q for %q, expected %q", err, test.text, test.err)
+		}
+	}
+}
+
+func checkAPLTextRoundTrip(t *testing.T, name string, rotation *proto.APLRotation) {
+	text := APLRotationToText(rotation)
+	parsed, err := APLRotationFromText(text)
+	if err != nil {
+		t.Errorf("%s: failed to parse printed rotation: %s\n%s", name, err, text)
+		return
+	}
+	if !googleProto.Equal(parsed, rotation) {
+		t.Errorf("%s: rotation changed after printing and parsing:\n%s", name, text)
+		return
+	}
+	if reprinted := APLRotationToText(parsed); reprinted != text {
+		t.Errorf("%s: printing is not stable:\n%s\n%s", name, text, reprinted)
+	}
+}
+
+// Sets every field of m to a non-default value, nesting messages up to the given depth.
+func fillAPLTestMessage(m protoreflect.Message, depth int) protoreflect.Message {
+	for _, fd := range aplSortedFields(m.Descriptor()) {
+		if fd.ContainingOneof() != nil && m.WhichOneof(fd.ContainingOneof()) != nil {
+			continue
+		}
+		if fd.IsList() {
+			list := m.Mutable(fd).List()
+			for i := 0; i < 2; i++ {
+				if value, ok := aplTestFieldValue(m, fd, depth); ok {
+					list.Append(value)
+				}
+			}
+		} else if value, ok := aplTestFieldValue(m, fd, depth); ok {
+			m.Set(fd, value)
+		}
+	}
+	return m
+}
+
+func aplTestFieldValue(m protoreflect.Message, fd protoreflect.FieldDescriptor, depth int) (protoreflect.Value, bool) {
+	switch fd.Kind() {
+	case protoreflect.MessageKind:
+		switch fd.Message().FullName() {
+		case aplValueOneof.Parent().FullName():
+			return protoreflect.ValueOfMessage(aplConstValue("1.5s").ProtoReflect()), true
+		case aplActionOneof.Parent().FullName():
+			action := &proto.APLAction{Action: &proto.APLAction_Wait{Wait: &proto.APLActionWait{Duration: aplConstValue("1s")}}}
+			return protoreflect.ValueOfMessage(action.ProtoReflect()), true
+		}
+		if depth == 0 {
+			return protoreflect.Value{}, false
+		}
+		return protoreflect.ValueOfMessage(fillAPLTestMessage(newAPLFieldMessage(fd), depth-1)), true
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		return protoreflect.ValueOfEnum(values.Get(values.Len() - 1).Number()), true
+	case protoreflect.BoolKind:
+		return protoreflect.ValueOfBool(true), true
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString("a \"quoted\"\nstring"), true
+	case protoreflect.FloatKind:
+		return protoreflect.ValueOfFloat32(-1.25), true
+	case protoreflect.DoubleKind:
+		return protoreflect.ValueOfFloat64(0.1), true
+	case protoreflect.Int32Kind:
+		return protoreflect.ValueOfInt32(-3), true
+	case protoreflect.Int64Kind:
+		return protoreflect.ValueOfInt64(4), true
+	default:
+		return protoreflect.Value{}, false
+	}
+}
+
+func TestAPLTextNotesAndHiddenItems(t *testing.T) {
+	rotation := &proto.APLRotation{
+		PrepullActions: []*proto.APLPrepullAction{{Hide: true}},
+		PriorityList: []*proto.APLListItem{
+			{Notes: "line one\n\n  line three \n", Hide: true},
+			{Notes: "# not a comment marker"},
+		},
+	}
+	checkAPLTextRoundTrip(t, "notes", rotation)
+
+	if text := APLRotationToText(rotation); !strings.Contains(text, "# line one\n#\n#   line three \n#\nhidden -\n") {
+		t.Fatalf("Unexpected notes format:\n%s", text)
+	}
+}
+
+func TestAPLTextVariables(t *testing.T) {
+	rotation, err := APLRotationFromText(`variable casts = 0
+variable "not an identifier"
+
+set_variable("casts", variable("casts") + 1)
+`)
+	if err != nil {
+		t.Fatalf("Failed to parse rotation: %s", err)
+	}
+	expected := []*proto.APLVariable{
+		{Name: "casts", Value: aplConstValue("0")},
+		{Name: "not an identifier"},
+	}
+	if len(rotation.Variables) != len(expected) {
+		t.Fatalf("Got %d variables, expected %d", len(rotation.Variables), len(expected))
+	}
+	for i, variable := range rotation.Variables {
+		if !googleProto.Equal(variable, expected[i]) {
+			t.Errorf("Unexpected variable %s, expected %s", variable, expected[i])
+		}
+	}
+	checkAPLTextRoundTrip(t, "variables", rotation)
+}
+
+func TestAPLTextActionLists(t *testing.T) {
+	rotation, err := APLRotationFromText(`call_action_list("aoe") if number_targets() > 3
+run_action_list("single target")
+
+list aoe:
+# Notes inside a list.
+cast_spell(spell:10216)
+hidden -
+
+list "single target":
+`)
+	if err != nil {
+		t.Fatalf("Failed to parse rotation: %s", err)
+	}
+	if len(rotation.PriorityList) != 2 {
+		t.Fatalf("Got %d priority list items, expected 2", len(rotation.PriorityList))
+	}
+	expected := []*proto.APLActionList{
+		{
+			Name: "aoe",
+			Items: []*proto.APLListItem{
+				{
+					Notes: "Notes inside a list.",
+					Action: &proto.APLAction{Action: &proto.APLAction_CastSpell{CastSpell: &proto.APLActionCastSpell{
+						SpellId: &proto.ActionID{RawId: &proto.ActionID_SpellId{SpellId: 10216}},
+					}}},
+				},
+				{Hide: true},
+			},
+		},
+		{Name: "single target"},
+	}
+	if len(rotation.ActionLists) != len(expected) {
+		t.Fatalf("Got %d action lists, expected %d", len(rotation.ActionLists), len(expected))
+	}
+	for i, list := range rotation.ActionLists {
+		if !googleProto.Equal(list, expected[i]) {
+			t.Errorf("Unexpected action list %s, expected %s", list, expected[i])
+		}
+	}
+	checkAPLTextRoundTrip(t, "action lists", rotation)
+}
@@ -93,6 +93,14 @@ func (rot *APLRotation) newAPLValue(config *proto.APLValue) APLValue {
 		return rot.newValueIsExecutePhase(config.GetIsExecutePhase())
 	case *proto.APLValue_NumberTargets:
 		return rot.newValueNumberTargets(config.GetNumberTargets())
+	case *proto.APLValue_TargetHealthPercent:
+		return rot.newValueTargetHealthPercent(config.GetTargetHealthPercent())
+	case *proto.APLValue_TargetTimeToDie:
+		return rot.newValueTargetTimeToDie(config.GetTargetTimeToDie())
+	case *proto.APLValue_TimeToHealthPercent:
+		return rot.newValueTimeToHealthPercent(config.GetTimeToHealthPercent())
+	case *proto.APLValue_ForcedMovementTimeToNext:
+		return rot.newValueForcedMovementTimeToNext(config.GetForcedMovementTimeToNext())
 
 	// Resources
 	case *proto.APLValue_CurrentHealth:
@@ -145,6 +153,14 @@ func (rot *APLRotation) newAPLValue(config *proto.APLValue) APLValue {
 		return rot.newValueSpellChanneledTicks(config.GetSpellChanneledTicks())
 	case *proto.APLValue_SpellCurrentCost:
 		return rot.newValueSpellCurrentCost(config.GetSpellCurrentCost())
+	case *proto.APLValue_SpellExpectedDamage:
+		return rot.newValueSpellExpectedDamage(config.GetSpellExpectedDamage())
+	case *proto.APLValue_SpellExpectedDamagePerCastTime:
+		return rot.newValueSpellExpectedDamagePerCastTime(config.GetSpellExpectedDamagePerCastTime())
+
+	// External cooldowns
+	case *proto.APLValue_ExternalCooldownTimeToNext:
+		return rot.newValueExternalCooldownTimeToNext(config.GetExternalCooldownTimeToNext())
 
 	// Auras
 	case *proto.APLValue_AuraIsKnown:
@@ -168,6 +184,12 @@ func (rot *APLRotation) newAPLValue(config *proto.APLValue) APLValue {
 	case *proto.APLValue_RuneIsEquipped:
 		return rot.newValueRuneIsEquipped(config.GetRuneIsEquipped())
 
+	// Items
+	case *proto.APLValue_ItemSlotIsReady:
+		return rot.newValueItemSlotIsReady(config.GetItemSlotIsReady())
+	case *proto.APLValue_ItemSlotTimeToReady:
+		return rot.newValueItemSlotTimeToReady(config.GetItemSlotTimeToReady())
+
 	// Dots
 	case *proto.APLValue_DotIsActive:
 		return rot.newValueDotIsActive(config.GetDotIsActive())
@@ -182,6 +204,10 @@ func (rot *APLRotation) newAPLValue(config *proto.APLValue) APLValue {
 	case *proto.APLValue_SequenceTimeToReady:
 		return rot.newValueSequenceTimeToReady(config.GetSequenceTimeToReady())
 
+	// Variables
+	case *proto.APLValue_Variable:
+		return rot.newValueVariable(config.GetVariable())
+
 	// Properties
 	case *proto.APLValue_ChannelClipDelay:
 		return rot.newValueChannelClipDelay(config.GetChannelClipDelay())
@@ -92,6 +92,119 @@ func (value *APLValueNumberTargets) String() string {
 	return "Num Targets"
 }
 
+type APLValueTargetHealthPercent struct {
+	DefaultAPLValueImpl
+	unit UnitReference
+}
+
+func (rot *APLRotation) newValueTargetHealthPercent(config *proto.APLValueTargetHealthPercent) APLValue {
+	unit := rot.GetTargetUnit(config.TargetUnit)
+	if unit.Get() == nil {
+		return nil
+	}
+	if unit.Get().Type != EnemyUnit && !unit.Get().HasHealthBar() {
+		rot.ValidationWarning("%s does not use Health", unit.Get().Label)
+		return nil
+	}
+	return &APLValueTargetHealthPercent{
+		unit: unit,
+	}
+}
+func (value *APLValueTargetHealthPercent) Type() proto.APLValueType {
+	return proto.APLValueType_ValueTypeFloat
+}
+func (value *APLValueTargetHealthPercent) GetFloat(sim *Simulation) float64 {
+	unit := value.unit.Get()
+	if unit.Type == EnemyUnit {
+		return sim.Encounter.Targets[unit.Index].HealthPercent(sim)
+	}
+	return unit.CurrentHealthPercent()
+}
+func (value *APLValueTargetHealthPercent) String() string {
+	return fmt.Sprintf("Target Health %%")
+}
+
+// Returns the target unit for values which estimate when a target dies, which only enemies do.
+func (rot *APLRotation) getEnemyTargetUnit(ref *proto.UnitReference) UnitReference {
+	unit := rot.GetTargetUnit(ref)
+	if unit.Get() != nil && unit.Get().Type != EnemyUnit {
+		rot.ValidationWarning("%s is not an enemy target", unit.Get().Label)
+		return UnitReference{}
+	}
+	return unit
+}
+
+type APLValueTargetTimeToDie struct {
+	DefaultAPLValueImpl
+	unit UnitReference
+}
+
+func (rot *APLRotation) newValueTargetTimeToDie(config *proto.APLValueTargetTimeToDie) APLValue {
+	unit := rot.getEnemyTargetUnit(config.TargetUnit)
+	if unit.Get() == nil {
+		return nil
+	}
+	return &APLValueTargetTimeToDie{
+		unit: unit,
+	}
+}
+func (value *APLValueTargetTimeToDie) Type() proto.APLValueType {
+	return proto.APLValueType_ValueTypeDuration
+}
+func (value *APLValueTargetTimeToDie) GetDuration(sim *Simulation) time.Duration {
+	return sim.Encounter.Targets[value.unit.Get().Index].TimeToDie(sim)
+}
+func (value *APLValueTargetTimeToDie) String() string {
+	return "Target Time to Die"
+}
+
+type APLValueTimeToHealthPercent struct {
+	DefaultAPLValueImpl
+	unit          UnitReference
+	healthPercent APLValue
+}
+
+func (rot *APLRotation) newValueTimeToHealthPercent(config *proto.APLValueTimeToHealthPercent) APLValue {
+	unit := rot.getEnemyTargetUnit(config.TargetUnit)
+	healthPercent := rot.coerceTo(rot.newAPLValue(config.HealthPercent), proto.APLValueType_ValueTypeFloat)
+	if unit.Get() == nil || healthPercent == nil {
+		return nil
+	}
+	return &APLValueTimeToHealthPercent{
+		unit:          unit,
+		healthPercent: healthPercent,
+	}
+}
+func (value *APLValueTimeToHealthPercent) GetInnerValues() []APLValue {
+	return []APLValue{value.healthPercent}
+}
+func (value *APLValueTimeToHealthPercent) Type() proto.APLValueType {
+	return proto.APLValueType_ValueTypeDuration
+}
+func (value *APLValueTimeToHealthPercent) GetDuration(sim *Simulation) time.Duration {
+	return sim.Encounter.Targets[value.unit.Get().Index].TimeToHealthPercent(sim, value.healthPercent.GetFloat(sim))
+}
+func (value *APLValueTimeToHealthPercent) String() string {
+	return fmt.Sprintf("Time to Health %%(%s)", value.healthPercent)
+}
+
+type APLValueForcedMovementTimeToNext struct {
+	DefaultAPLValueImpl
+}
+
+func (rot *APLRotation) newValueForcedMovementTimeToNext(_ *proto.APLValueForcedMovementTimeToNext) APLValue {
+	return &APLValueForcedMovementTimeToNext{}
+}
+func (value *APLValueForcedMovementTimeToNext) Type() proto.APLValueType {
+	return proto.APLValueType_ValueTypeDuration
+}
+func (value *APLValueForcedMovementTimeToNext) GetDuration(sim *Simulation) time.Duration {
+	return sim.Encounter.ForcedMovementTimeToNext(sim)
+}
+func (value *APLValueForcedMovementTimeToNext) String() string {
+	return "Forced Movement Time To Next"
+}
+
 type APLValueIsExecutePhase struct {
 	DefaultAPLValueImpl
 	threshold proto.APLValueIsExecutePhase_ExecutePhaseThreshold
@@ -0,0 +1,47 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/wowsims/sod/sim/core/proto"
+)
+
+type APLValueExternalCooldownTimeToNext struct {
+	DefaultAPLValueImpl
+	cooldown proto.ExternalCooldown
+	planned  []*plannedCooldown
+}
+
+func (rot *APLRotation) newValueExternalCooldownTimeToNext(config *proto.APLValueExternalCooldownTimeToNext) APLValue {
+	if _, ok := externalCooldownConfigs[config.Cooldown]; !ok {
+		rot.ValidationWarning("Unknown external cooldown: %s", config.Cooldown)
+		return nil
+	}
+
+	// Rotations should work with or without a plan, so it isn't an error if nothing is planned.
+	var planned []*plannedCooldown
+	for _, p := range rot.unit.Env.Raid.cooldownPlan {
+		if p.cooldown == config.Cooldown && &p.character.Unit == rot.unit {
+			planned = append(planned, p)
+		}
+	}
+	return &APLValueExternalCooldownTimeToNext{
+		cooldown: config.Cooldown,
+		planned:  planned,
+	}
+}
+func (value *APLValueExternalCooldownTimeToNext) Type() proto.APLValueType {
+	return proto.APLValueType_ValueTypeDuration
+}
+func (value *APLValueExternalCooldownTimeToNext) GetDuration(sim *Simulation) time.Duration {
+	timeToNext := NeverExpires
+	for _, planned := range value.planned {
+		timeToNext = min(timeToNext, planned.timeToNext(sim))
+	}
+	return timeToNext
+}
+func (value *APLValueExternalCooldownTimeToNext) String() string {
+	return fmt.Sprintf("External Cooldown Time To Next(%s)", strings.TrimPrefix(value.cooldown.String(), "ExternalCooldown"))
+}
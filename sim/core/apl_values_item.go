@@ -0,0 +1,115 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/wowsims/sod/sim/core/proto"
+)
+
+// The on-use effect of whichever item is equipped in a slot. Items are looked up when used rather
+// than when the rotation is built, so weapon swaps change which effect is used.
+type APLItemSlot struct {
+	character *Character
+	slot      proto.ItemSlot
+
+	// Major cooldown spells of the items which can be equipped in the slot, by item ID.
+	spells map[int32]*Spell
+}
+
+func (rot *APLRotation) GetAPLItemSlot(slot proto.ItemSlot) *APLItemSlot {
+	player := rot.unit.Env.Raid.GetPlayerFromUnit(rot.unit)
+	if player == nil {
+		rot.ValidationWarning("%s does not have equipment", rot.unit.Label)
+		return nil
+	}
+	character := player.GetCharacter()
+
+	itemIDs := []int32{character.Equipment[slot].ID}
+	if character.ItemSwap.IsEnabled() && slot >= proto.ItemSlot_ItemSlotMainHand {
+		itemIDs = append(itemIDs, character.ItemSwap.GetItem(slot).ID)
+	}
+
+	itemSlot := &APLItemSlot{
+		character: character,
+		slot:      slot,
+		spells:    make(map[int32]*Spell),
+	}
+	for _, mcd := range character.initialMajorCooldowns {
+		for _, itemID := range itemIDs {
+			if itemID != 0 && (mcd.itemID == itemID || mcd.Spell.ActionID.ItemID == itemID) {
+				itemSlot.spells[itemID] = mcd.Spell
+			}
+		}
+	}
+
+	if len(itemSlot.spells) == 0 {
+		rot.ValidationWarning("No item with a use effect equipped in %s", itemSlot.Name())
+		return nil
+	}
+	return itemSlot
+}
+
+// Returns the spell of the item equipped right now, or nil if it doesn't have a use effect.
+func (itemSlot *APLItemSlot) Spell() *Spell {
+	return itemSlot.spells[itemSlot.character.Equipment[itemSlot.slot].ID]
+}
+
+func (itemSlot *APLItemSlot) Name() string {
+	return strings.TrimPrefix(itemSlot.slot.String(), "ItemSlot")
+}
+
+type APLValueItemSlotIsReady struct {
+	DefaultAPLValueImpl
+	itemSlot *APLItemSlot
+}
+
+func (rot *APLRotation) newValueItemSlotIsReady(config *proto.APLValueItemSlotIsReady) APLValue {
+	itemSlot := rot.GetAPLItemSlot(config.ItemSlot)
+	if itemSlot == nil {
+		return nil
+	}
+	return &APLValueItemSlotIsReady{
+		itemSlot: itemSlot,
+	}
+}
+func (value *APLValueItemSlotIsReady) Type() proto.APLValueType {
+	return proto.APLValueType_ValueTypeBool
+}
+func (value *APLValueItemSlotIsReady) GetBool(sim *Simulation) bool {
+	spell := value.itemSlot.Spell()
+	return spell != nil && spell.IsReady(sim)
+}
+func (value *APLValueItemSlotIsReady) String() string {
+	return fmt.Sprintf("Item Slot Is Ready(%s)", value.itemSlot.Name())
+}
+
+type APLValueItemSlotTimeToReady struct {
+	DefaultAPLValueImpl
+	itemSlot *APLItemSlot
+}
+
+func (rot *APLRotation) newValueItemSlotTimeToReady(config *proto.APLValueItemSlotTimeToReady) APLValue {
+	itemSlot := rot.GetAPLItemSlot(config.ItemSlot)
+	if itemSlot == nil {
+		return nil
+	}
+	return &APLValueItemSlotTimeToReady{
+		itemSlot: itemSlot,
+	}
+}
+func (value *APLValueItemSlotTimeToReady) Type() proto.APLValueType {
+	return proto.APLValueType_ValueTypeDuration
+}
+func (value *APLValueItemSlotTimeToReady) GetDuration(sim *Simulation) time.Duration {
+	spell := value.itemSlot.Spell()
+	if spell == nil {
+		// The item equipped right now has no use effect, so it never becomes ready.
+		return NeverExpires
+	}
+	return spell.TimeToReady(sim)
+}
+func (value *APLValueItemSlotTimeToReady) String() string {
+	return fmt.Sprintf("Item Slot Time To Ready(%s)", value.itemSlot.Name())
+}
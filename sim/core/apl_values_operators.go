@@ -27,6 +27,12 @@ func (rot *APLRotation) newValueConst(config *proto.APLValueConst) APLValue {
 		boolVal:   config.Val != "",
 	}
 
+	if config.Tunable != nil {
+		if _, err := parseAPLTunableRange(config.Tunable); err != nil {
+			rot.ValidationWarning("Invalid tunable range: %s", err)
+		}
+	}
+
 	if strings.ToLower(config.Val) == "true" {
 		result.boolVal = true
 		result.valType = proto.APLValueType_ValueTypeBool
@@ -249,3 +249,75 @@ func (value *APLValueSpellCurrentCost) GetFloat(_ *Simulation) float64 {
 func (value *APLValueSpellCurrentCost) String() string {
 	return fmt.Sprintf("CurrentCost(%s)", value.spell.ActionID)
 }
+
+type APLValueSpellExpectedDamage struct {
+	DefaultAPLValueImpl
+	spell *Spell
+	unit  UnitReference
+}
+
+// Returns the spell and target for values which use expected damage, which not every spell has.
+func (rot *APLRotation) getExpectedDamageSpell(spellId *proto.ActionID, targetRef *proto.UnitReference) (*Spell, UnitReference) {
+	spell := rot.GetAPLSpell(spellId)
+	if spell == nil {
+		return nil, UnitReference{}
+	}
+	if !spell.HasExpectedDamage() {
+		rot.ValidationWarning("%s does not support expected damage", spell.ActionID)
+		return nil, UnitReference{}
+	}
+	return spell, rot.GetTargetUnit(targetRef)
+}
+
+func (rot *APLRotation) newValueSpellExpectedDamage(config *proto.APLValueSpellExpectedDamage) APLValue {
+	spell, unit := rot.getExpectedDamageSpell(config.SpellId, config.TargetUnit)
+	if spell == nil || unit.Get() == nil {
+		return nil
+	}
+	return &APLValueSpellExpectedDamage{
+		spell: spell,
+		unit:  unit,
+	}
+}
+func (value *APLValueSpellExpectedDamage) Type() proto.APLValueType {
+	return proto.APLValueType_ValueTypeFloat
+}
+func (value *APLValueSpellExpectedDamage) GetFloat(sim *Simulation) float64 {
+	return value.spell.ExpectedDamage(sim, value.unit.Get())
+}
+func (value *APLValueSpellExpectedDamage) String() string {
+	return fmt.Sprintf("Expected Damage(%s)", value.spell.ActionID)
+}
+
+type APLValueSpellExpectedDamagePerCastTime struct {
+	DefaultAPLValueImpl
+	spell *Spell
+	unit  UnitReference
+}
+
+func (rot *APLRotation) newValueSpellExpectedDamagePerCastTime(config *proto.APLValueSpellExpectedDamagePerCastTime) APLValue {
+	spell, unit := rot.getExpectedDamageSpell(config.SpellId, config.TargetUnit)
+	if spell == nil || unit.Get() == nil {
+		return nil
+	}
+	return &APLValueSpellExpectedDamagePerCastTime{
+		spell: spell,
+		unit:  unit,
+	}
+}
+func (value *APLValueSpellExpectedDamagePerCastTime) Type() proto.APLValueType {
+	return proto.APLValueType_ValueTypeFloat
+}
+
+// Spells which take no time at all, i.e. instants off the GCD, return their expected damage.
+func (value *APLValueSpellExpectedDamagePerCastTime) GetFloat(sim *Simulation) float64 {
+	target := value.unit.Get()
+	damage := value.spell.ExpectedDamage(sim, target)
+	if castDuration := value.spell.ExpectedCastDuration(target); castDuration > 0 {
+		return damage / castDuration.Seconds()
+	}
+	return damage
+}
+func (value *APLValueSpellExpectedDamagePerCastTime) String() string {
+	return fmt.Sprintf("Expected Damage Per Cast Time(%s)", value.spell.ActionID)
+}
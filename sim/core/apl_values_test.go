@@ -1,6 +1,8 @@
 package core
 
 import (
+	"fmt"
+	"slices"
 	"testing"
 	"time"
 
@@ -42,3 +44,89 @@ func TestValueConst(t *testing.T) {
 		t.Fatalf("Unexpected coerced duration value %s", coercedDurVal.GetDuration(sim))
 	}
 }
+
+func TestValueForcedMovementTimeToNext(t *testing.T) {
+	sim := &Simulation{Environment: &Environment{}}
+	sim.Encounter.movement.windows = []movementWindow{
+		{start: time.Second * 10, duration: time.Second * 5},
+		{start: time.Second * 30, duration: time.Second * 5},
+	}
+	value := (&APLRotation{}).newValueForcedMovementTimeToNext(&proto.APLValueForcedMovementTimeToNext{})
+
+	for _, tc := range []struct {
+		currentTime time.Duration
+		expected    time.Duration
+	}{
+		{0, time.Second * 10},
+		{time.Second * 12, 0},
+		{time.Second * 15, time.Second * 15},
+		{time.Second * 40, NeverExpires},
+	} {
+		sim.CurrentTime = tc.currentTime
+		if timeToNext := value.GetDuration(sim); timeToNext != tc.expected {
+			t.Errorf("At %s, expected %s until the next movement but got %s", tc.currentTime, tc.expected, timeToNext)
+		}
+	}
+}
+
+func TestValueExternalCooldownTimeToNext(t *testing.T) {
+	sim := &Simulation{}
+	timed := &plannedCooldown{timings: []time.Duration{time.Second * 10, time.Second * 40}}
+	conditional := &plannedCooldown{condition: &APLValueConst{valType: proto.APLValueType_ValueTypeBool, boolVal: false}}
+
+	for _, tc := range []struct {
+		planned     []*plannedCooldown
+		currentTime time.Duration
+		expected    time.Duration
+	}{
+		{[]*plannedCooldown{timed}, 0, time.Second * 10},
+		{[]*plannedCooldown{timed}, time.Second * 15, time.Second * 25},
+		{[]*plannedCooldown{timed}, time.Second * 50, NeverExpires},
+		// A condition might never be true, so receivers shouldn't hold their cooldowns for it.
+		{[]*plannedCooldown{conditional}, time.Second * 15, NeverExpires},
+		{[]*plannedCooldown{conditional, timed}, time.Second * 15, time.Second * 25},
+		{nil, 0, NeverExpires},
+	} {
+		sim.CurrentTime = tc.currentTime
+		value := &APLValueExternalCooldownTimeToNext{planned: tc.planned}
+		if timeToNext := value.GetDuration(sim); timeToNext != tc.expected {
+			t.Errorf("At %s with %d planned cooldowns, expected %s until the next cast but got %s", tc.currentTime, len(tc.planned), tc.expected, timeToNext)
+		}
+	}
+}
+
+func TestValueSpellExpectedDamage(t *testing.T) {
+	runRotation := func(condition string) (float64, int32, float64) {
+		result := runFakeCasterSim(t, fakeCasterRequest(t, "cast_spell(spell:10197)"+condition+"\ncast_spell(spell:10149)\n", &proto.SimOptions{
+			Iterations: 20,
+			RandomSeed: 101,
+		}))
+		for _, action := range result.RaidMetrics.Parties[0].Players[0].Actions {
+			if action.Id.GetSpellId() == fakeFireBlastID {
+				return result.RaidMetrics.Dps.Avg, action.Targets[0].Casts, action.Targets[0].Damage / float64(action.Targets[0].Casts)
+			}
+		}
+		return result.RaidMetrics.Dps.Avg, 0, 0
+	}
+
+	// Evaluating expected damage has no side effects, so the results only change once it decides
+	// whether Fire Blast is cast.
+	dps, casts, avgDamage := runRotation("")
+	if casts == 0 {
+		t.Fatalf("No Fire Blast casts")
+	}
+	if alwaysDps, _, _ := runRotation(" if spell_expected_damage(spell:10197) > 0 and spell_expected_damage_per_cast_time(spell:10197) > 0"); alwaysDps != dps {
+		t.Errorf("Got %f dps when checking expected damage, expected %f", alwaysDps, dps)
+	}
+	if _, lowCasts, _ := runRotation(fmt.Sprintf(" if spell_expected_damage(spell:10197) > %f", avgDamage*0.8)); lowCasts != casts {
+		t.Errorf("Got %d Fire Blast casts when expecting more than 80%% of its average damage, expected %d", lowCasts, casts)
+	}
+	if _, highCasts, _ := runRotation(fmt.Sprintf(" if spell_expected_damage(spell:10197) > %f", avgDamage*1.2)); highCasts != 0 {
+		t.Errorf("Got %d Fire Blast casts when expecting more than 120%% of its average damage, expected none", highCasts)
+	}
+
+	rotationStats := fakeCasterRotationStats(fakeCasterRequest(t, "cast_spell(spell:10149) if spell_expected_damage(spell:10149) > 0\n", &proto.SimOptions{}))
+	if warnings := rotationStats.PriorityList[0].Warnings; !slices.Equal(warnings, []string{"{SpellID: 10149} does not support expected damage"}) {
+		t.Errorf("Unexpected warnings for Fireball: %q", warnings)
+	}
+}
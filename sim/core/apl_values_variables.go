@@ -0,0 +1,198 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/wowsims/sod/sim/core/proto"
+)
+
+// A named value declared on the rotation. Reading it evaluates its expression at most once per
+// decision, until Set Variable replaces it with a fixed result for the rest of the iteration.
+type aplVariable struct {
+	rot      *APLRotation
+	name     string
+	config   *proto.APLVariable
+	warnings *[]string
+
+	value    APLValue
+	building bool
+	built    bool
+
+	// Result of the expression during the decision with index cachedAt.
+	cached   APLValueConst
+	cachedAt int
+
+	// Result of the last Set Variable action, if any.
+	isSet  bool
+	setVal APLValueConst
+}
+
+func (rot *APLRotation) newAPLVariables(configs []*proto.APLVariable) {
+	rot.variables = make(map[string]*aplVariable, len(configs))
+	for i, config := range configs {
+		rot.doAndRecordWarnings(&rot.variableWarnings[i], false, func() {
+			if config.Name == "" {
+				rot.ValidationWarning("Variable must have a name")
+			} else if _, ok := rot.variables[config.Name]; ok {
+				rot.ValidationWarning("Duplicate variable name: '%s'", config.Name)
+			} else {
+				rot.variables[config.Name] = &aplVariable{
+					rot:      rot,
+					name:     config.Name,
+					config:   config,
+					warnings: &rot.variableWarnings[i],
+					cachedAt: -1,
+				}
+			}
+		})
+	}
+
+	// Variables are built in declaration order, or earlier if another variable refers to them.
+	for _, config := range configs {
+		if variable := rot.variables[config.Name]; variable != nil && variable.config == config && !variable.built {
+			rot.buildVariable(variable)
+		}
+	}
+}
+
+// Builds the expression of a variable, recording warnings against the variable itself rather than
+// the action which happened to refer to it first.
+func (rot *APLRotation) buildVariable(variable *aplVariable) {
+	variable.building = true
+	rot.buildingVariables = append(rot.buildingVariables, variable.name)
+	outerWarnings, outerParsingPrepull := rot.curWarnings, rot.parsingPrepull
+	rot.curWarnings, rot.parsingPrepull = nil, false
+
+	variable.value = rot.newAPLValue(variable.config.Value)
+	if variable.value == nil && len(rot.curWarnings) == 0 {
+		rot.ValidationWarning("Variable '%s' has no valid value", variable.name)
+	}
+
+	*variable.warnings = append(*variable.warnings, rot.curWarnings...)
+	rot.curWarnings, rot.parsingPrepull = outerWarnings, outerParsingPrepull
+	rot.buildingVariables = rot.buildingVariables[:len(rot.buildingVariables)-1]
+	variable.building = false
+	variable.built = true
+}
+
+func (rot *APLRotation) getVariable(name string) *aplVariable {
+	variable := rot.variables[name]
+	if variable == nil {
+		rot.ValidationWarning("No variable with name: '%s'", name)
+		return nil
+	}
+
+	if variable.building {
+		rot.ValidationWarning("Cyclic variable reference: %s", aplReferenceCycle(rot.buildingVariables, name))
+		return nil
+	}
+
+	if !variable.built {
+		rot.buildVariable(variable)
+	}
+	if variable.value == nil {
+		rot.ValidationWarning("Variable '%s' is invalid", name)
+		return nil
+	}
+	return variable
+}
+
+// Formats the chain of references from the first occurrence of name in building back to name.
+func aplReferenceCycle(building []string, name string) string {
+	cycleStart := 0
+	for i, buildingName := range building {
+		if buildingName == name {
+			cycleStart = i
+		}
+	}
+	cycle := append(append([]string{}, building[cycleStart:]...), name)
+	return strings.Join(cycle, " -> ")
+}
+
+func (variable *aplVariable) reset() {
+	variable.isSet = false
+	variable.cachedAt = -1
+}
+
+func (variable *aplVariable) set(sim *Simulation, value APLValue) {
+	variable.setVal.setFrom(sim, value, variable.value.Type())
+	variable.isSet = true
+}
+
+// Returns the value to read the variable from.
+func (variable *aplVariable) current(sim *Simulation) APLValue {
+	if variable.isSet {
+		return &variable.setVal
+	}
+
+	// Outside of the action loop the rotation state can change between reads, so nothing is cached.
+	rot := variable.rot
+	if !rot.inLoop {
+		return variable.value
+	}
+	if variable.cachedAt != rot.decisionIdx {
+		variable.cached.setFrom(sim, variable.value, variable.value.Type())
+		variable.cachedAt = rot.decisionIdx
+	}
+	return &variable.cached
+}
+
+// Stores the current result of source, as the given type. The source may read this value, so it
+// is only overwritten once the new result is known.
+func (value *APLValueConst) setFrom(sim *Simulation, source APLValue, valType proto.APLValueType) {
+	result := APLValueConst{valType: valType}
+	switch valType {
+	case proto.APLValueType_ValueTypeBool:
+		result.boolVal = source.GetBool(sim)
+	case proto.APLValueType_ValueTypeInt:
+		result.intVal = source.GetInt(sim)
+	case proto.APLValueType_ValueTypeFloat:
+		result.floatVal = source.GetFloat(sim)
+	case proto.APLValueType_ValueTypeDuration:
+		result.durationVal = source.GetDuration(sim)
+	case proto.APLValueType_ValueTypeString:
+		result.stringVal = source.GetString(sim)
+	}
+	*value = result
+}
+
+type APLValueVariable struct {
+	DefaultAPLValueImpl
+	variable *aplVariable
+}
+
+func (rot *APLRotation) newValueVariable(config *proto.APLValueVariable) APLValue {
+	variable := rot.getVariable(config.Name)
+	if variable == nil {
+		return nil
+	}
+	return &APLValueVariable{
+		variable: variable,
+	}
+}
+func (value *APLValueVariable) GetInnerValues() []APLValue {
+	return []APLValue{value.variable.value}
+}
+func (value *APLValueVariable) Type() proto.APLValueType {
+	return value.variable.value.Type()
+}
+func (value *APLValueVariable) GetBool(sim *Simulation) bool {
+	return value.variable.current(sim).GetBool(sim)
+}
+func (value *APLValueVariable) GetInt(sim *Simulation) int32 {
+	return value.variable.current(sim).GetInt(sim)
+}
+func (value *APLValueVariable) GetFloat(sim *Simulation) float64 {
+	return value.variable.current(sim).GetFloat(sim)
+}
+func (value *APLValueVariable) GetDuration(sim *Simulation) time.Duration {
+	return value.variable.current(sim).GetDuration(sim)
+}
+func (value *APLValueVariable) GetString(sim *Simulation) string {
+	return value.variable.current(sim).GetString(sim)
+}
+func (value *APLValueVariable) String() string {
+	return fmt.Sprintf("Variable(%s)", value.variable.name)
+}
@@ -0,0 +1,83 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/wowsims/sod/sim/core/proto"
+)
+
+func TestAPLVariables(t *testing.T) {
+	rsr := fakeCasterRequest(t, `variable casts = 0
+variable more_casts = variable("casts") < 3
+variable loop_a = variable("loop_b")
+variable loop_b = variable("loop_a") + 1
+
+strict_sequence(cast_spell(spell:10205), set_variable("casts", variable("casts") + 1)) if variable("more_casts")
+wait_until(variable("undefined"))
+cast_spell(spell:10149)
+`, &proto.SimOptions{
+		Iterations: 5,
+		RandomSeed: 101,
+	})
+
+	rotationStats := fakeCasterRotationStats(rsr)
+	if warnings := rotationStats.Variables[2].Warnings; len(warnings) != 1 || warnings[0] != "Variable 'loop_b' is invalid" {
+		t.Errorf("Unexpected warnings for loop_a: %v", warnings)
+	}
+	if warnings := rotationStats.Variables[3].Warnings; len(warnings) != 1 || warnings[0] != "Cyclic variable reference: loop_a -> loop_b -> loop_a" {
+		t.Errorf("Unexpected warnings for loop_b: %v", warnings)
+	}
+	if warnings := rotationStats.PriorityList[1].Warnings; len(warnings) != 1 || warnings[0] != "No variable with name: 'undefined'" {
+		t.Errorf("Unexpected warnings for undefined variable: %v", warnings)
+	}
+
+	// Set Variable keeps its result for the rest of the iteration, and is cleared before the next one.
+	result := runFakeCasterSim(t, rsr)
+	if casts := fakeCasterCasts(result, fakeScorchID); casts != 3*5 {
+		t.Errorf("Got %d Scorch casts, expected 3 per iteration", casts)
+	}
+}
+
+func TestAPLSetVariable(t *testing.T) {
+	// Set Variable doesn't end the decision, so Scorch sees the current time from the first cast on,
+	// rather than the declared value.
+	rsr := fakeCasterRequest(t, `variable now = 100s
+
+set_variable("now", current_time())
+cast_spell(spell:10205) if variable("now") < 6s
+cast_spell(spell:10149)
+`, &proto.SimOptions{
+		Iterations: 5,
+		RandomSeed: 101,
+	})
+
+	result := runFakeCasterSim(t, rsr)
+	if casts := fakeCasterCasts(result, fakeScorchID); casts != 4*5 {
+		t.Errorf("Got %d Scorch casts, expected 4 per iteration", casts)
+	}
+}
+
+func TestAPLVariableCounterReset(t *testing.T) {
+	sim := NewSim(fakeCasterRequest(t, `variable decisions = 0.0
+
+set_variable("decisions", variable("decisions") + 1)
+cast_spell(spell:10149)
+`, &proto.SimOptions{
+		RandomSeed: 101,
+	}))
+	rot := sim.Raid.AllPlayerUnits[0].Rotation
+	decisions := rot.variables["decisions"]
+
+	sim.reset()
+	sim.PrePull()
+	sim.runPendingActions()
+	sim.Cleanup()
+	if count := decisions.current(sim).GetFloat(sim); count < 20 {
+		t.Fatalf("Counted %.0f decisions in an iteration, expected at least one per Fireball", count)
+	}
+
+	sim.reset()
+	if count := decisions.current(sim).GetFloat(sim); count != 0 {
+		t.Errorf("Counted %.0f decisions after resetting, expected 0", count)
+	}
+}
@@ -202,6 +202,9 @@ func (aura *Aura) SetStacks(sim *Simulation, newStacks int32) {
 	if sim.Log != nil {
 		aura.Unit.Log(sim, "%s stacks: %d --> %d", aura.ActionID, oldStacks, newStacks)
 	}
+	if sim.combatLogEnabled {
+		sim.logAura(proto.CombatLogEvent_AuraStacksChanged, aura, oldStacks, newStacks)
+	}
 	aura.stacks = newStacks
 	if aura.OnStacksChange != nil {
 		aura.OnStacksChange(aura, sim, oldStacks, newStacks)
@@ -552,6 +555,9 @@ func (aura *Aura) Activate(sim *Simulation) {
 		if sim.Log != nil && !aura.ActionID.IsEmptyAction() {
 			aura.Unit.Log(sim, "Aura refreshed: %s", aura.ActionID)
 		}
+		if sim.combatLogEnabled && !aura.ActionID.IsEmptyAction() {
+			sim.logAura(proto.CombatLogEvent_AuraRefreshed, aura, aura.stacks, aura.stacks)
+		}
 		aura.Refresh(sim)
 		return
 	}
@@ -637,6 +643,9 @@ func (aura *Aura) Activate(sim *Simulation) {
 	if sim.Log != nil && !aura.ActionID.IsEmptyAction() {
 		aura.Unit.Log(sim, "Aura gained: %s", aura.ActionID)
 	}
+	if sim.combatLogEnabled && !aura.ActionID.IsEmptyAction() {
+		sim.logAura(proto.CombatLogEvent_AuraGained, aura, aura.stacks, aura.stacks)
+	}
 
 	// don't invoke possible callbacks until the internal state is consistent
 	if aura.OnGain != nil {
@@ -662,6 +671,9 @@ func (aura *Aura) Deactivate(sim *Simulation) {
 	if sim.Log != nil && !aura.ActionID.IsEmptyAction() {
 		aura.Unit.Log(sim, "Aura faded: %s", aura.ActionID)
 	}
+	if sim.combatLogEnabled && !aura.ActionID.IsEmptyAction() {
+		sim.logAura(proto.CombatLogEvent_AuraFaded, aura, aura.stacks, aura.stacks)
+	}
 
 	aura.expires = 0
 	if aura.activeIndex != Inactive {
@@ -861,6 +873,17 @@ func (at *auraTracker) OnPeriodicHealTaken(sim *Simulation, spell *Spell, result
 	}
 }
 
+// Folds in the aura metrics of the same unit from another Simulation.
+func (at *auraTracker) mergeMetrics(other *auraTracker) {
+	for i, aura := range at.auras {
+		otherAura := other.auras[i]
+		if otherAura.Label != aura.Label {
+			otherAura = other.GetAura(aura.Label)
+		}
+		aura.metrics.merge(&otherAura.metrics)
+	}
+}
+
 func (at *auraTracker) GetMetricsProto() []*proto.AuraMetrics {
 	metrics := make([]*proto.AuraMetrics, 0, len(at.auras))
 
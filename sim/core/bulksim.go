@@ -30,12 +30,27 @@ type bulkSimRunner struct {
 	SingleRaidSimRunner raidSimRunner
 	// Request used for this bulk simulation.
 	Request *proto.BulkSimRequest
+	// Optional file to save completed combos to, so the bulk simulation can be resumed.
+	CheckpointFile string
+	// Skip combos already saved to CheckpointFile by an earlier run of the same request.
+	Resume bool
+
+	checkpoint *bulkSimCheckpoint
 }
 
 func BulkSim(ctx context.Context, request *proto.BulkSimRequest, progress chan *proto.ProgressMetrics) *proto.BulkSimResult {
+	return BulkSimWithCheckpoint(ctx, request, progress, "", false)
+}
+
+// BulkSimWithCheckpoint runs a bulk simulation that saves each completed combo to checkpointFile.
+// If resume is set, combos saved there by an earlier, interrupted run of the same request are not
+// simmed again, and the final result is the same as if that run had finished.
+func BulkSimWithCheckpoint(ctx context.Context, request *proto.BulkSimRequest, progress chan *proto.ProgressMetrics, checkpointFile string, resume bool) *proto.BulkSimResult {
 	bulk := &bulkSimRunner{
 		SingleRaidSimRunner: runSim,
 		Request:             request,
+		CheckpointFile:      checkpointFile,
+		Resume:              resume,
 	}
 
 	result, err := bulk.Run(ctx, progress)
@@ -88,6 +103,17 @@ func (b *bulkSimRunner) Run(pctx context.Context, progress chan *proto.ProgressM
 	if playerCount != 1 || player == nil {
 		return nil, fmt.Errorf("bulksim: expected exactly 1 player, found %d", playerCount)
 	}
+	if b.CheckpointFile != "" {
+		requestHash, err := bulkSimRequestHash(b.Request)
+		if err != nil {
+			return nil, err
+		}
+		b.checkpoint, err = openBulkSimCheckpoint(b.CheckpointFile, requestHash, b.Resume)
+		if err != nil {
+			return nil, err
+		}
+		defer b.checkpoint.close()
+	}
 	if player.GetDatabase() != nil {
 		addToDatabase(player.GetDatabase())
 	}
@@ -168,7 +194,20 @@ func (b *bulkSimRunner) Run(pctx context.Context, progress chan *proto.ProgressM
 		return nil, fmt.Errorf("number of total iterations %d too large", maxIterations)
 	}
 
+	// Pick up from the fast mode round an earlier run was interrupted in.
+	if roundIters, roundCombos := b.checkpoint.resumeRound(validCombos); roundCombos != nil {
+		newIters = roundIters
+		validCombos = roundCombos
+		if base := b.checkpoint.baseResult(newIters); base != nil {
+			baseResult = &itemSubstitutionSimResult{Result: base}
+		}
+	}
+
 	for {
+		if err := b.checkpoint.saveRound(newIters, validCombos); err != nil {
+			return nil, err
+		}
+
 		var tempBase *itemSubstitutionSimResult
 		var err error
 		// TODO: we could theoretically make getRankedResults accept a channel of validCombos that stream in to it and launches sims as it gets them...
@@ -291,7 +330,20 @@ func (b *bulkSimRunner) getRankedResults(pctx context.Context, validCombos []sin
 
 	// launcher for all combos (limited by concurrency max)
 	go func() {
-		for _, singleCombo := range validCombos {
+		for i, singleCombo := range validCombos {
+			if saved := b.checkpoint.lookup(singleCombo.eq, iterations); saved != nil {
+				results <- &itemSubstitutionSimResult{
+					Request:      singleCombo.req,
+					Result:       saved,
+					Substitution: singleCombo.eq,
+					ChangeLog:    singleCombo.cl,
+					index:        i,
+				}
+				atomic.AddInt32(&totalCompletedIterations, int32(iterations))
+				atomic.AddInt32(&totalCompletedSims, 1)
+				continue
+			}
+
 			<-tickets
 			singleSimProgress := make(chan *proto.ProgressMetrics)
 			// watches this progress and pushes up to main reporter.
@@ -307,7 +359,7 @@ func (b *bulkSimRunner) getRankedResults(pctx context.Context, validCombos []sin
 				}
 			}(singleSimProgress)
 			// actually run the sim in here.
-			go func(sub singleBulkSim) {
+			go func(sub singleBulkSim, index int) {
 				// overwrite the requests iterations with the input for this function.
 				sub.req.SimOptions.Iterations = int32(iterations)
 				results <- &itemSubstitutionSimResult{
@@ -315,30 +367,36 @@ func (b *bulkSimRunner) getRankedResults(pctx context.Context, validCombos []sin
 					Result:       b.SingleRaidSimRunner(sub.req, singleSimProgress, false),
 					Substitution: sub.eq,
 					ChangeLog:    sub.cl,
+					index:        index,
 				}
 				atomic.AddInt32(&totalCompletedSims, 1)
 				tickets <- struct{}{} // when done, allow for new sim to be launched.
-			}(singleCombo)
+			}(singleCombo, i)
 		}
 	}()
 
 	rankedResults := make([]*itemSubstitutionSimResult, numCombinations)
 	var baseResult *itemSubstitutionSimResult
 
-	for i := range rankedResults {
+	for range rankedResults {
 		result := <-results
 		if result.Result == nil || result.Result.ErrorResult != "" {
 			cancel() // cancel reporter
 			return nil, nil, errors.New("simulation failed: " + result.Result.ErrorResult)
 		}
+		if err := b.checkpoint.saveResult(result.Substitution, iterations, result.Result); err != nil {
+			cancel() // cancel reporter
+			return nil, nil, err
+		}
 		if !result.Substitution.HasItemReplacements() {
 			baseResult = result
 		}
-		rankedResults[i] = result
+		// Keep combos in launch order, so ties rank the same however the sims finish.
+		rankedResults[result.index] = result
 	}
 	cancel() // cancel reporter
 
-	sort.Slice(rankedResults, func(i, j int) bool {
+	sort.SliceStable(rankedResults, func(i, j int) bool {
 		return rankedResults[i].Score() > rankedResults[j].Score()
 	})
 	return rankedResults, baseResult, nil
@@ -352,6 +410,9 @@ type itemSubstitutionSimResult struct {
 	Result       *proto.RaidSimResult
 	Substitution *equipmentSubstitution
 	ChangeLog    *raidSimRequestChangeLog
+
+	// Position of the combo in the list that was simmed.
+	index int
 }
 
 // Score used to rank results.
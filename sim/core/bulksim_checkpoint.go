@@ -0,0 +1,264 @@
+package core
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+
+	goproto "google.golang.org/protobuf/proto"
+
+	"github.com/wowsims/sod/sim/core/proto"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// bulkSimCheckpoint saves completed combo results to a file as a bulk sim runs, so that a
+// resumed run can skip them. The file holds one proto.BulkSimCheckpoint per line, each new line
+// adding results or replacing the round state, so saving a result is a single append.
+type bulkSimCheckpoint struct {
+	mu sync.Mutex
+
+	file        *os.File
+	requestHash string
+
+	results map[bulkSimCheckpointKey]*proto.RaidSimResult
+
+	// Fast mode round state, nil if no round has been saved.
+	round *proto.BulkSimCheckpoint
+}
+
+// Fast mode sims the same combo several times with increasing iterations, so results are keyed
+// by both.
+type bulkSimCheckpointKey struct {
+	substitutionHash string
+	iterations       int32
+}
+
+// bulkSimRequestHash returns a hash identifying the request, computed before the bulk sim
+// modifies it.
+func bulkSimRequestHash(request *proto.BulkSimRequest) (string, error) {
+	data, err := goproto.MarshalOptions{Deterministic: true}.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// openBulkSimCheckpoint opens the checkpoint file at path. If resume is set, progress already
+// saved there for the same request is loaded, otherwise the file is started over.
+func openBulkSimCheckpoint(path string, requestHash string, resume bool) (*bulkSimCheckpoint, error) {
+	checkpoint := &bulkSimCheckpoint{
+		requestHash: requestHash,
+		results:     make(map[bulkSimCheckpointKey]*proto.RaidSimResult),
+	}
+
+	if resume {
+		if err := checkpoint.load(path); err != nil {
+			return nil, err
+		}
+	}
+
+	// Rewrite everything loaded as a single line. This compacts the file and drops any line left
+	// incomplete when the previous run was interrupted, so appending after it is safe.
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, checkpoint.marshalAll(), 0666); err != nil {
+		return nil, fmt.Errorf("bulksim: failed to write checkpoint file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return nil, fmt.Errorf("bulksim: failed to write checkpoint file: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return nil, fmt.Errorf("bulksim: failed to open checkpoint file: %w", err)
+	}
+	checkpoint.file = file
+	return checkpoint, nil
+}
+
+func (checkpoint *bulkSimCheckpoint) load(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		// Nothing to resume yet.
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("bulksim: failed to read checkpoint file: %w", err)
+	}
+
+	lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+	for i, line := range lines {
+		record := &proto.BulkSimCheckpoint{}
+		if err := protojson.Unmarshal(line, record); err != nil {
+			if i == len(lines)-1 {
+				// Interrupted while appending this line.
+				break
+			}
+			return fmt.Errorf("bulksim: failed to parse checkpoint file line %d: %w", i+1, err)
+		}
+
+		if record.RequestHash != checkpoint.requestHash {
+			return fmt.Errorf("bulksim: checkpoint file %q was written for a different request", path)
+		}
+		if record.RoundIterations != 0 {
+			checkpoint.round = record
+		}
+		for _, result := range record.Results {
+			checkpoint.results[bulkSimCheckpointKey{result.SubstitutionHash, result.Iterations}] = result.Result
+		}
+	}
+	return nil
+}
+
+func (checkpoint *bulkSimCheckpoint) marshalAll() []byte {
+	record := &proto.BulkSimCheckpoint{
+		RequestHash: checkpoint.requestHash,
+	}
+	if checkpoint.round != nil {
+		record.RoundIterations = checkpoint.round.RoundIterations
+		record.RoundCombos = checkpoint.round.RoundCombos
+	}
+	for key, result := range checkpoint.results {
+		record.Results = append(record.Results, &proto.BulkSimCheckpointResult{
+			SubstitutionHash: key.substitutionHash,
+			Iterations:       key.iterations,
+			Result:           result,
+		})
+	}
+	return checkpoint.marshalLine(record)
+}
+
+func (checkpoint *bulkSimCheckpoint) marshalLine(record *proto.BulkSimCheckpoint) []byte {
+	// Non-multiline protojson output never contains newlines.
+	data, err := protojson.Marshal(record)
+	if err != nil {
+		panic(err)
+	}
+	return append(data, '\n')
+}
+
+func (checkpoint *bulkSimCheckpoint) append(record *proto.BulkSimCheckpoint) error {
+	record.RequestHash = checkpoint.requestHash
+	if _, err := checkpoint.file.Write(checkpoint.marshalLine(record)); err != nil {
+		return fmt.Errorf("bulksim: failed to write checkpoint file: %w", err)
+	}
+	return nil
+}
+
+// Returns the saved result for the given combo and iterations, or nil if there isn't one.
+func (checkpoint *bulkSimCheckpoint) lookup(sub *equipmentSubstitution, iterations int64) *proto.RaidSimResult {
+	if checkpoint == nil {
+		return nil
+	}
+	checkpoint.mu.Lock()
+	defer checkpoint.mu.Unlock()
+	return checkpoint.results[bulkSimCheckpointKey{sub.CanonicalHash(), int32(iterations)}]
+}
+
+// Saves the result for the given combo, unless it is already saved.
+func (checkpoint *bulkSimCheckpoint) saveResult(sub *equipmentSubstitution, iterations int64, result *proto.RaidSimResult) error {
+	if checkpoint == nil {
+		return nil
+	}
+	checkpoint.mu.Lock()
+	defer checkpoint.mu.Unlock()
+
+	key := bulkSimCheckpointKey{sub.CanonicalHash(), int32(iterations)}
+	if _, ok := checkpoint.results[key]; ok {
+		return nil
+	}
+
+	// Only keep what the bulk sim result uses, detailed metrics would make the file huge.
+	result = goproto.Clone(result).(*proto.RaidSimResult)
+	result.Logs = ""
+	result.EncounterMetrics = nil
+	for _, party := range result.GetRaidMetrics().GetParties() {
+		for _, player := range party.GetPlayers() {
+			player.Actions = nil
+			player.Auras = nil
+			player.Resources = nil
+			player.Pets = nil
+		}
+	}
+
+	checkpoint.results[key] = result
+	return checkpoint.append(&proto.BulkSimCheckpoint{
+		Results: []*proto.BulkSimCheckpointResult{{
+			SubstitutionHash: key.substitutionHash,
+			Iterations:       key.iterations,
+			Result:           result,
+		}},
+	})
+}
+
+// Saves the state of the fast mode round about to be run.
+func (checkpoint *bulkSimCheckpoint) saveRound(iterations int64, combos []singleBulkSim) error {
+	if checkpoint == nil {
+		return nil
+	}
+	checkpoint.mu.Lock()
+	defer checkpoint.mu.Unlock()
+
+	round := &proto.BulkSimCheckpoint{
+		RoundIterations: int32(iterations),
+	}
+	for _, combo := range combos {
+		round.RoundCombos = append(round.RoundCombos, combo.eq.CanonicalHash())
+	}
+	checkpoint.round = round
+	return checkpoint.append(round)
+}
+
+// Returns the saved fast mode round, restricting combos to the ones in it in their saved order,
+// or nil if there is no round to resume.
+func (checkpoint *bulkSimCheckpoint) resumeRound(combos []singleBulkSim) (int64, []singleBulkSim) {
+	if checkpoint == nil || checkpoint.round == nil {
+		return 0, nil
+	}
+
+	combosByHash := make(map[string]singleBulkSim, len(combos))
+	for _, combo := range combos {
+		combosByHash[combo.eq.CanonicalHash()] = combo
+	}
+
+	roundCombos := make([]singleBulkSim, 0, len(checkpoint.round.RoundCombos))
+	for _, hash := range checkpoint.round.RoundCombos {
+		combo, ok := combosByHash[hash]
+		if !ok {
+			return 0, nil
+		}
+		roundCombos = append(roundCombos, combo)
+	}
+	return int64(checkpoint.round.RoundIterations), roundCombos
+}
+
+// Returns the saved result for the unchanged equipment with the most iterations up to the given
+// amount, which is what an uninterrupted fast mode run would have kept as its base result.
+func (checkpoint *bulkSimCheckpoint) baseResult(maxIterations int64) *proto.RaidSimResult {
+	if checkpoint == nil {
+		return nil
+	}
+	checkpoint.mu.Lock()
+	defer checkpoint.mu.Unlock()
+
+	var best *proto.RaidSimResult
+	var bestIterations int32
+	for key, result := range checkpoint.results {
+		if key.substitutionHash == "" && int64(key.iterations) <= maxIterations && key.iterations > bestIterations {
+			best = result
+			bestIterations = key.iterations
+		}
+	}
+	return best
+}
+
+func (checkpoint *bulkSimCheckpoint) close() {
+	if checkpoint == nil {
+		return
+	}
+	checkpoint.file.Close()
+}
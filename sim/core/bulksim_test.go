@@ -2,11 +2,16 @@ package core
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/wowsims/sod/sim/core/proto"
 	"google.golang.org/protobuf/encoding/protojson"
+	goproto "google.golang.org/protobuf/proto"
 )
 
 const (
@@ -113,6 +118,124 @@ func TestBulkSim(t *testing.T) {
 	}
 }
 
+func TestBulkSimCheckpointResume(t *testing.T) {
+	addToDatabase(tinyItemDatabase)
+
+	newRequest := func() *proto.BulkSimRequest {
+		return &proto.BulkSimRequest{
+			BaseSettings: &proto.RaidSimRequest{
+				Raid: &proto.Raid{
+					Parties: []*proto.Party{{
+						Players: []*proto.Player{{Name: "Player", Equipment: createEquipmentFromItems()}},
+					}},
+				},
+				SimOptions: &proto.SimOptions{},
+			},
+			BulkSettings: &proto.BulkSettings{
+				Items: []*proto.ItemSpec{
+					{Id: itemStarshardEdge},
+					{Id: itemIronmender},
+					{Id: itemPillarOfFortitude},
+				},
+				Combinations:       true,
+				IterationsPerCombo: 100,
+			},
+		}
+	}
+
+	var numSims int32
+	fakeRunSim := func(rsr *proto.RaidSimRequest, progress chan *proto.ProgressMetrics, skipPresim bool) *proto.RaidSimResult {
+		atomic.AddInt32(&numSims, 1)
+		close(progress)
+
+		// Mainhand decides dps, so the offhand only combos tie with the base.
+		equipment := rsr.Raid.Parties[0].Players[0].Equipment
+		dps := float64(equipment.Items[proto.ItemSlot_ItemSlotMainHand].Id)
+		return &proto.RaidSimResult{
+			RaidMetrics: &proto.RaidMetrics{
+				Dps: &proto.DistributionMetrics{Avg: dps},
+				Parties: []*proto.PartyMetrics{{
+					Players: []*proto.UnitMetrics{{
+						Dps:     &proto.DistributionMetrics{Avg: dps},
+						Actions: []*proto.ActionMetrics{{Id: &proto.ActionID{}}},
+					}},
+				}},
+			},
+		}
+	}
+
+	checkpointFile := filepath.Join(t.TempDir(), "checkpoint.jsonl")
+	run := func(resume bool) *proto.BulkSimResult {
+		bulk := &bulkSimRunner{
+			SingleRaidSimRunner: fakeRunSim,
+			Request:             newRequest(),
+			CheckpointFile:      checkpointFile,
+			Resume:              resume,
+		}
+		result, err := bulk.Run(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("BulkSim() returned error: %v", err)
+		}
+		return result
+	}
+
+	want := run(false)
+	numCombos := atomic.SwapInt32(&numSims, 0)
+	if numCombos < 2 {
+		t.Fatalf("Expected several combos to be simmed, got %d", numCombos)
+	}
+
+	// Resuming a finished run shouldn't sim anything.
+	if got := run(true); !goproto.Equal(want, got) {
+		t.Fatalf("Resumed BulkSim() = %s, want %s", protojson.Format(got), protojson.Format(want))
+	}
+	if n := atomic.SwapInt32(&numSims, 0); n != 0 {
+		t.Fatalf("Resumed BulkSim() ran %d sims, want 0", n)
+	}
+
+	// Simulate being interrupted after the first result was saved, partway through writing the
+	// second. Resuming compacted the results into the first line, so split them back out.
+	data, err := os.ReadFile(checkpointFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := &proto.BulkSimCheckpoint{}
+	if err := protojson.Unmarshal([]byte(strings.Split(string(data), "\n")[0]), saved); err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	for _, result := range saved.Results[:2] {
+		line, err := protojson.Marshal(&proto.BulkSimCheckpoint{RequestHash: saved.RequestHash, Results: []*proto.BulkSimCheckpointResult{result}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, string(line))
+	}
+	lines[1] = lines[1][:len(lines[1])/2]
+	if err := os.WriteFile(checkpointFile, []byte(strings.Join(lines, "\n")), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := run(true); !goproto.Equal(want, got) {
+		t.Fatalf("Resumed BulkSim() = %s, want %s", protojson.Format(got), protojson.Format(want))
+	}
+	if n := atomic.SwapInt32(&numSims, 0); n != numCombos-1 {
+		t.Fatalf("Resumed BulkSim() ran %d sims, want %d", n, numCombos-1)
+	}
+
+	// A checkpoint can't be resumed with a different request.
+	bulk := &bulkSimRunner{
+		SingleRaidSimRunner: fakeRunSim,
+		Request:             newRequest(),
+		CheckpointFile:      checkpointFile,
+		Resume:              true,
+	}
+	bulk.Request.BulkSettings.IterationsPerCombo = 200
+	if _, err := bulk.Run(context.Background(), nil); err == nil {
+		t.Fatalf("BulkSim() resumed a checkpoint for a different request")
+	}
+}
+
 func TestGenerateAllEquipmentSubstitutions(t *testing.T) {
 	baseItems := make([]*proto.ItemSpec, len(proto.ItemSlot_name))
 	for i := range baseItems {
@@ -3,6 +3,8 @@ package core
 import (
 	"fmt"
 	"time"
+
+	"github.com/wowsims/sod/sim/core/proto"
 )
 
 // A cast corresponds to any action which causes the in-game castbar to be
@@ -20,6 +22,23 @@ type Hardcast struct {
 	Pushback   float64
 }
 
+// Stops the unit's hardcast before it completes, e.g. because it has to move. The spell's cost
+// and effects are only applied on completion, so nothing else needs undoing.
+func (unit *Unit) CancelHardcast(sim *Simulation) {
+	if unit.Hardcast.Expires <= sim.CurrentTime {
+		return
+	}
+
+	if sim.Log != nil {
+		unit.Log(sim, "Hardcast %s interrupted", unit.Hardcast.ActionID)
+	}
+	unit.Hardcast.Expires = startingCDTime
+	if unit.hardcastAction != nil && !unit.hardcastAction.consumed {
+		unit.hardcastAction.Cancel(sim)
+	}
+	unit.SetGCDTimer(sim, sim.CurrentTime)
+}
+
 // Input for constructing the CastSpell function for a spell.
 type CastConfig struct {
 	// Default cast values with all static effects applied.
@@ -214,6 +233,9 @@ func (spell *Spell) makeCastFunc(config CastConfig) CastSuccessFunc {
 				spell.Unit.Log(sim, "Casting %s (Cost = %0.03f, Cast Time = %s, Effective Time = %s)",
 					spell.ActionID, max(0, spell.CurCast.Cost), spell.CurCast.CastTime, spell.CurCast.EffectiveTime())
 			}
+			if sim.combatLogEnabled && !spell.Flags.Matches(SpellFlagNoLogs) {
+				sim.logCast(proto.CombatLogEvent_CastStarted, spell, target, max(0, spell.CurCast.Cost), spell.CurCast.CastTime)
+			}
 
 			spell.Unit.Hardcast = Hardcast{
 				Expires:  sim.CurrentTime + spell.CurCast.CastTime,
@@ -223,6 +245,9 @@ func (spell *Spell) makeCastFunc(config CastConfig) CastSuccessFunc {
 					if sim.Log != nil && !spell.Flags.Matches(SpellFlagNoLogs) {
 						spell.Unit.Log(sim, "Completed cast %s", spell.ActionID)
 					}
+					if sim.combatLogEnabled && !spell.Flags.Matches(SpellFlagNoLogs) {
+						sim.logCast(proto.CombatLogEvent_CastCompleted, spell, target, max(0, spell.CurCast.Cost), spell.CurCast.CastTime)
+					}
 
 					if spell.Cost != nil {
 						spell.Cost.SpendCost(sim, spell)
@@ -253,6 +278,10 @@ func (spell *Spell) makeCastFunc(config CastConfig) CastSuccessFunc {
 				spell.ActionID, max(0, spell.CurCast.Cost), spell.CurCast.CastTime, spell.CurCast.EffectiveTime())
 			spell.Unit.Log(sim, "Completed cast %s", spell.ActionID)
 		}
+		if sim.combatLogEnabled && !spell.Flags.Matches(SpellFlagNoLogs) {
+			sim.logCast(proto.CombatLogEvent_CastStarted, spell, target, max(0, spell.CurCast.Cost), spell.CurCast.CastTime)
+			sim.logCast(proto.CombatLogEvent_CastCompleted, spell, target, max(0, spell.CurCast.Cost), spell.CurCast.CastTime)
+		}
 
 		if spell.Cost != nil {
 			spell.Cost.SpendCost(sim, spell)
@@ -299,6 +328,10 @@ func (spell *Spell) makeCastFuncSimple() CastSuccessFunc {
 				spell.ActionID, 0.0, "0s", "0s")
 			spell.Unit.Log(sim, "Completed cast %s", spell.ActionID)
 		}
+		if sim.combatLogEnabled && !spell.Flags.Matches(SpellFlagNoLogs) {
+			sim.logCast(proto.CombatLogEvent_CastStarted, spell, target, 0, 0)
+			sim.logCast(proto.CombatLogEvent_CastCompleted, spell, target, 0, 0)
+		}
 
 		spell.applyEffects(sim, target)
 
@@ -317,6 +350,10 @@ func (spell *Spell) makeCastFuncAutosOrProcs() CastSuccessFunc {
 				spell.ActionID, 0.0, "0s", "0s")
 			spell.Unit.Log(sim, "Completed cast %s", spell.ActionID)
 		}
+		if sim.combatLogEnabled && !spell.Flags.Matches(SpellFlagNoLogs) {
+			sim.logCast(proto.CombatLogEvent_CastStarted, spell, target, 0, 0)
+			sim.logCast(proto.CombatLogEvent_CastCompleted, spell, target, 0, 0)
+		}
 
 		spell.applyEffects(sim, target)
 
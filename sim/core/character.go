@@ -354,7 +354,12 @@ func (character *Character) clearBuildPhaseAuras(phase CharacterBuildPhase) {
 func (character *Character) applyItemEffects(agent Agent) {
 	for slot, eq := range character.Equipment {
 		if applyItemEffect, ok := itemEffects[eq.ID]; ok {
+			numMajorCooldowns := len(character.initialMajorCooldowns)
 			applyItemEffect(agent)
+			// Remember which item added each use effect, since their spells usually have a spell ID.
+			for i := numMajorCooldowns; i < len(character.initialMajorCooldowns); i++ {
+				character.initialMajorCooldowns[i].itemID = eq.ID
+			}
 		}
 
 		if applyEnchantEffect, ok := enchantEffects[eq.Enchant.EffectID]; ok {
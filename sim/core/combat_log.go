@@ -0,0 +1,113 @@
+package core
+
+import (
+	"time"
+
+	"github.com/wowsims/sod/sim/core/proto"
+)
+
+// The structured combat log records the same events as the text log, as typed
+// proto.CombatLogEvents. It is enabled by SimOptions.CombatLog for the
+// iterations that have debug logs, so call sites should check
+// sim.CombatLogEnabled() before building events.
+
+func (sim *Simulation) CombatLogEnabled() bool {
+	return sim.combatLogEnabled
+}
+
+func (sim *Simulation) logCombatEvent(event *proto.CombatLogEvent) {
+	event.Timestamp = sim.CurrentTime.Seconds()
+	sim.combatLog = append(sim.combatLog, event)
+}
+
+func (sim *Simulation) logSpellResult(eventType proto.CombatLogEvent_Type, spell *Spell, isPeriodic bool, result *SpellResult) {
+	event := &proto.CombatLogEvent{
+		Type:     eventType,
+		Source:   spell.Unit.Env.GetUnitReference(spell.Unit),
+		Target:   spell.Unit.Env.GetUnitReference(result.Target),
+		ActionId: spell.ActionID.ToProto(),
+		Periodic: isPeriodic,
+		Outcome:  result.Outcome.ToProto(),
+		Threat:   result.Threat,
+	}
+	if eventType == proto.CombatLogEvent_Healing {
+		event.Healing = result.Damage
+	} else {
+		event.Damage = result.Damage
+	}
+	sim.logCombatEvent(event)
+}
+
+func (sim *Simulation) logCast(eventType proto.CombatLogEvent_Type, spell *Spell, target *Unit, cost float64, castTime time.Duration) {
+	sim.logCombatEvent(&proto.CombatLogEvent{
+		Type:     eventType,
+		Source:   spell.Unit.Env.GetUnitReference(spell.Unit),
+		Target:   spell.Unit.Env.GetUnitReference(target),
+		ActionId: spell.ActionID.ToProto(),
+		Cost:     cost,
+		CastTime: castTime.Seconds(),
+	})
+}
+
+func (sim *Simulation) logAura(eventType proto.CombatLogEvent_Type, aura *Aura, oldStacks int32, stacks int32) {
+	unitRef := aura.Unit.Env.GetUnitReference(aura.Unit)
+	sim.logCombatEvent(&proto.CombatLogEvent{
+		Type:      eventType,
+		Source:    unitRef,
+		Target:    unitRef,
+		ActionId:  aura.ActionID.ToProto(),
+		OldStacks: oldStacks,
+		Stacks:    stacks,
+	})
+}
+
+func (sim *Simulation) logResource(metrics *ResourceMetrics, gain float64, actualGain float64) {
+	unitRef := sim.GetUnitReference(metrics.unit)
+	sim.logCombatEvent(&proto.CombatLogEvent{
+		Type:               proto.CombatLogEvent_Resource,
+		Source:             unitRef,
+		Target:             unitRef,
+		ActionId:           metrics.ActionID.ToProto(),
+		ResourceType:       metrics.Type,
+		ResourceGain:       gain,
+		ResourceActualGain: actualGain,
+	})
+}
+
+func (sim *Simulation) logAPLAction(unit *Unit, action *APLAction) {
+	sim.logCombatEvent(&proto.CombatLogEvent{
+		Type:      proto.CombatLogEvent_AplAction,
+		Source:    unit.Env.GetUnitReference(unit),
+		AplAction: action.impl.String(),
+	})
+}
+
+func (sim *Simulation) logAPLWait(eventType proto.CombatLogEvent_Type, unit *Unit, action APLActionImpl, waitTime time.Duration) {
+	sim.logCombatEvent(&proto.CombatLogEvent{
+		Type:      eventType,
+		Source:    unit.Env.GetUnitReference(unit),
+		AplAction: action.String(),
+		WaitTime:  waitTime.Seconds(),
+	})
+}
+
+func (ho HitOutcome) ToProto() *proto.HitOutcomeFlags {
+	flags := &proto.HitOutcomeFlags{
+		Miss:   ho.Matches(OutcomeMiss),
+		Hit:    ho.Matches(OutcomeHit),
+		Dodge:  ho.Matches(OutcomeDodge),
+		Glance: ho.Matches(OutcomeGlance),
+		Parry:  ho.Matches(OutcomeParry),
+		Block:  ho.Matches(OutcomeBlock),
+		Crit:   ho.Matches(OutcomeCrit),
+		Crush:  ho.Matches(OutcomeCrush),
+	}
+	if ho.Matches(OutcomePartial1_4) {
+		flags.PartialResist = 0.25
+	} else if ho.Matches(OutcomePartial2_4) {
+		flags.PartialResist = 0.5
+	} else if ho.Matches(OutcomePartial3_4) {
+		flags.PartialResist = 0.75
+	}
+	return flags
+}
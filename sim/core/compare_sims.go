@@ -0,0 +1,131 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/wowsims/sod/sim/core/proto"
+	googleProto "google.golang.org/protobuf/proto"
+)
+
+func CompareSims(request *proto.CompareSimsRequest, progress chan *proto.ProgressMetrics) *proto.CompareSimsResult {
+	simOptions := googleProto.Clone(request.Baseline.SimOptions).(*proto.SimOptions)
+	simOptions.SaveAllValues = true
+
+	// Iterations are compared in pairs, so every sim needs to run all of them.
+	simOptions.TargetDpsStderr = 0
+	simOptions.TargetDpsCiWidth = 0
+
+	// Make sure an RNG seed is always set, so every sim uses the same one. When there is no
+	// user-supplied seed it needs to be a randomly-selected seed though, so that run-run
+	// differences still exist.
+	if simOptions.RandomSeed == 0 {
+		simOptions.RandomSeed = time.Now().UnixNano()
+	}
+
+	// Like stat weights, use test-level RNG controls so that a change only affects the rolls
+	// it is involved in, which keeps the paired iterations as close as possible.
+	simOptions.IsTest = true
+
+	requests := make([]*proto.RaidSimRequest, 0, len(request.Variants)+1)
+	for _, rsr := range append([]*proto.RaidSimRequest{request.Baseline}, request.Variants...) {
+		simRequest := googleProto.Clone(rsr).(*proto.RaidSimRequest)
+		simRequest.SimOptions = googleProto.Clone(simOptions).(*proto.SimOptions)
+		requests = append(requests, simRequest)
+	}
+
+	results := make([]*proto.RaidSimResult, len(requests))
+
+	iterationsTotal := simOptions.Iterations * int32(len(requests))
+	simsTotal := int32(len(requests))
+	var iterationsDone int32
+	var simsCompleted int32
+
+	concurrency := max(runtime.NumCPU()-1, 1)
+	tickets := make(chan struct{}, concurrency)
+	for i := 0; i < concurrency; i++ {
+		tickets <- struct{}{}
+	}
+
+	var waitGroup sync.WaitGroup
+	for i, simRequest := range requests {
+		waitGroup.Add(1)
+		go func(i int, simRequest *proto.RaidSimRequest) {
+			defer waitGroup.Done()
+			// wait until we have CPU time available.
+			<-tickets
+			defer func() { tickets <- struct{}{} }()
+
+			reporter := make(chan *proto.ProgressMetrics, 10)
+			go RunSim(simRequest, reporter)
+
+			var localIterations int32
+			for metrics := range reporter {
+				atomic.AddInt32(&iterationsDone, metrics.CompletedIterations-localIterations)
+				localIterations = metrics.CompletedIterations
+				if metrics.FinalRaidResult != nil {
+					atomic.AddInt32(&simsCompleted, 1)
+				}
+				if progress != nil {
+					progress <- &proto.ProgressMetrics{
+						TotalIterations:     iterationsTotal,
+						CompletedIterations: atomic.LoadInt32(&iterationsDone),
+						CompletedSims:       atomic.LoadInt32(&simsCompleted),
+						TotalSims:           simsTotal,
+					}
+				}
+				if metrics.FinalRaidResult != nil {
+					results[i] = metrics.FinalRaidResult
+					break
+				}
+			}
+		}(i, simRequest)
+	}
+	waitGroup.Wait()
+
+	for i, result := range results {
+		if result == nil {
+			// The progress channel closed without sending a final result.
+			name := "Baseline"
+			if i > 0 {
+				name = fmt.Sprintf("Variant %d", i)
+			}
+			return &proto.CompareSimsResult{
+				ErrorResult: fmt.Sprintf("%s sim finished without a result", name),
+			}
+		}
+		if result.ErrorResult != "" {
+			return &proto.CompareSimsResult{
+				ErrorResult: result.ErrorResult,
+			}
+		}
+	}
+
+	baselineDps := results[0].RaidMetrics.Dps
+	compareResult := &proto.CompareSimsResult{
+		BaselineDps: baselineDps.Avg,
+	}
+	for _, result := range results[1:] {
+		variantDps := result.RaidMetrics.Dps
+
+		var delta aggregator
+		for i := range variantDps.AllValues {
+			delta.add(variantDps.AllValues[i] - baselineDps.AllValues[i])
+		}
+		mean, stdev := delta.meanAndStdDev()
+		margin := confidenceZ95 * stdev / math.Sqrt(float64(delta.n))
+
+		compareResult.Variants = append(compareResult.Variants, &proto.CompareSimsVariantResult{
+			Dps:            variantDps.Avg,
+			DpsDelta:       mean,
+			DpsDeltaStdev:  stdev,
+			DpsDeltaCiLow:  mean - margin,
+			DpsDeltaCiHigh: mean + margin,
+		})
+	}
+	return compareResult
+}
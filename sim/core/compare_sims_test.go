@@ -0,0 +1,42 @@
+package core
+
+import (
+	"math"
+	"testing"
+
+	"github.com/wowsims/sod/sim/core/proto"
+	"github.com/wowsims/sod/sim/core/stats"
+)
+
+func TestCompareSims(t *testing.T) {
+	rotation := "cast_spell(spell:10149)\n"
+	baseline := fakeCasterRequest(t, rotation, &proto.SimOptions{
+		Iterations: 50,
+		RandomSeed: 101,
+	})
+	moreSpellPower := fakeCasterRequest(t, rotation, nil)
+	moreSpellPower.Raid.Parties[0].Players[0].BonusStats = &proto.UnitStats{
+		Stats: stats.Stats{stats.SpellPower: 100}.ToFloatArray(),
+	}
+
+	result := RunCompareSims(&proto.CompareSimsRequest{
+		Baseline: baseline,
+		Variants: []*proto.RaidSimRequest{fakeCasterRequest(t, rotation, nil), moreSpellPower},
+	})
+	if result.ErrorResult != "" {
+		t.Fatalf("Compare failed with error: %s", result.ErrorResult)
+	}
+
+	same := result.Variants[0]
+	if same.Dps != result.BaselineDps || same.DpsDelta != 0 || same.DpsDeltaCiLow != 0 || same.DpsDeltaCiHigh != 0 {
+		t.Errorf("Identical variant differs from baseline: %v", same)
+	}
+
+	better := result.Variants[1]
+	if math.Abs(better.DpsDelta-(better.Dps-result.BaselineDps)) > 1e-6 {
+		t.Errorf("Dps delta %f doesn't match the difference in dps %f", better.DpsDelta, better.Dps-result.BaselineDps)
+	}
+	if better.DpsDeltaCiLow <= 0 || better.DpsDeltaCiHigh < better.DpsDeltaCiLow {
+		t.Errorf("Expected a positive confidence interval for more spell power, got [%f, %f]", better.DpsDeltaCiLow, better.DpsDeltaCiHigh)
+	}
+}
@@ -0,0 +1,111 @@
+package core
+
+import (
+	"slices"
+	"time"
+
+	"github.com/wowsims/sod/sim/core/proto"
+)
+
+// Forced movement makes every player move at fixed or random times during the fight, interrupting
+// their hardcasts. Windows with a distance also take players out of melee range until they run back.
+
+// Normal run speed, in yards per second.
+const RunSpeed = 7.0
+
+type movementWindow struct {
+	start    time.Duration
+	duration time.Duration
+	distance float64
+}
+
+type encounterMovement struct {
+	config *proto.EncounterMovement
+
+	// The fixed windows, and those of the current iteration including random ones, sorted by start.
+	fixedWindows []movementWindow
+	windows      []movementWindow
+}
+
+func newEncounterMovement(config *proto.EncounterMovement) encounterMovement {
+	movement := encounterMovement{config: config}
+	for _, window := range config.GetWindows() {
+		if window.Duration <= 0 {
+			continue
+		}
+		movement.fixedWindows = append(movement.fixedWindows, movementWindow{
+			start:    DurationFromSeconds(max(window.Start, 0)),
+			duration: DurationFromSeconds(window.Duration),
+			distance: max(window.Distance, 0),
+		})
+	}
+	movement.fixedWindows = mergeMovementWindows(movement.fixedWindows)
+	return movement
+}
+
+// Sorts the windows by start and merges those which overlap, so players only run out once and
+// come back when the last of them ends.
+func mergeMovementWindows(windows []movementWindow) []movementWindow {
+	slices.SortStableFunc(windows, func(w1, w2 movementWindow) int {
+		return int(w1.start - w2.start)
+	})
+
+	merged := windows[:0]
+	for _, window := range windows {
+		if n := len(merged); n > 0 && window.start <= merged[n-1].start+merged[n-1].duration {
+			last := &merged[n-1]
+			last.duration = max(last.duration, window.start+window.duration-last.start)
+			last.distance = max(last.distance, window.distance)
+			continue
+		}
+		merged = append(merged, window)
+	}
+	return merged
+}
+
+func (movement *encounterMovement) reset(sim *Simulation) {
+	movement.windows = append(movement.windows[:0], movement.fixedWindows...)
+
+	if config := movement.config; config.GetRandomInterval() > 0 && config.RandomDuration > 0 {
+		duration := DurationFromSeconds(config.RandomDuration)
+		nextInterval := func() time.Duration {
+			variation := config.RandomIntervalVariation * (2*sim.RandomFloat("Forced Movement") - 1)
+			return DurationFromSeconds(max(config.RandomInterval+variation, 0))
+		}
+		for start := nextInterval(); start < sim.Duration; start += duration + nextInterval() {
+			movement.windows = append(movement.windows, movementWindow{
+				start:    start,
+				duration: duration,
+				distance: max(config.RandomDistance, 0),
+			})
+		}
+		movement.windows = mergeMovementWindows(movement.windows)
+	}
+
+	for _, window := range movement.windows {
+		window := window
+		StartDelayedAction(sim, DelayedActionOptions{
+			DoAt: window.start,
+			OnAction: func(sim *Simulation) {
+				for _, unit := range sim.Raid.AllPlayerUnits {
+					unit.MoveAwayFor(window.duration, window.distance, sim)
+				}
+			},
+		})
+	}
+}
+
+// Returns the time until players next have to move, 0 while they are moving, or NeverExpires if
+// they don't have to move again.
+func (movement *encounterMovement) timeToNext(sim *Simulation) time.Duration {
+	for _, window := range movement.windows {
+		if window.start+window.duration > sim.CurrentTime {
+			return max(window.start-sim.CurrentTime, 0)
+		}
+	}
+	return NeverExpires
+}
+
+func (encounter *Encounter) ForcedMovementTimeToNext(sim *Simulation) time.Duration {
+	return encounter.movement.timeToNext(sim)
+}
@@ -0,0 +1,244 @@
+package core
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/wowsims/sod/sim/core/proto"
+)
+
+func TestMergeMovementWindows(t *testing.T) {
+	window := func(start, duration, distance float64) movementWindow {
+		return movementWindow{start: DurationFromSeconds(start), duration: DurationFromSeconds(duration), distance: distance}
+	}
+
+	for _, tc := range []struct {
+		name     string
+		windows  []movementWindow
+		expected []movementWindow
+	}{
+		{
+			name:     "Separate",
+			windows:  []movementWindow{window(30, 5, 0), window(10, 5, 10)},
+			expected: []movementWindow{window(10, 5, 10), window(30, 5, 0)},
+		},
+		{
+			name:     "Overlapping",
+			windows:  []movementWindow{window(10, 10, 20), window(15, 10, 20)},
+			expected: []movementWindow{window(10, 15, 20)},
+		},
+		{
+			name:     "Nested",
+			windows:  []movementWindow{window(10, 20, 5), window(15, 5, 20)},
+			expected: []movementWindow{window(10, 20, 20)},
+		},
+		{
+			name:     "Touching",
+			windows:  []movementWindow{window(10, 5, 0), window(15, 5, 0), window(19, 10, 0), window(40, 1, 0)},
+			expected: []movementWindow{window(10, 19, 0), window(40, 1, 0)},
+		},
+	} {
+		if merged := mergeMovementWindows(tc.windows); !slices.Equal(merged, tc.expected) {
+			t.Errorf("%s: got windows %v, expected %v", tc.name, merged, tc.expected)
+		}
+	}
+}
+
+func setupMovementSim(movement *proto.EncounterMovement) (*Simulation, *Unit) {
+	sim := NewSim(&proto.RaidSimRequest{
+		SimOptions: &proto.SimOptions{
+			RandomSeed: 100,
+		},
+		Raid: SinglePlayerRaidProto(&proto.Player{
+			Class:     proto.Class_ClassShaman,
+			Spec:      &proto.Player_ElementalShaman{},
+			Equipment: &proto.EquipmentSpec{},
+		}, &proto.PartyBuffs{}, &proto.RaidBuffs{}, &proto.Debuffs{}),
+		Encounter: &proto.Encounter{
+			Targets:  []*proto.Target{{Name: "target", Level: 63, MobType: proto.MobType_MobTypeDemon}},
+			Duration: 60,
+			Movement: movement,
+		},
+	})
+	return sim, sim.Raid.AllPlayerUnits[0]
+}
+
+type movementState struct {
+	moving   bool
+	distance float64
+}
+
+// Runs an iteration, returning whether the unit was moving and its distance at each of the times.
+func runMovementSim(sim *Simulation, unit *Unit, times []float64, setup func(sim *Simulation)) []movementState {
+	sim.reset()
+	sim.PrePull()
+	if setup != nil {
+		setup(sim)
+	}
+
+	states := make([]movementState, len(times))
+	for i, seconds := range times {
+		i := i
+		StartDelayedAction(sim, DelayedActionOptions{
+			DoAt: DurationFromSeconds(seconds),
+			OnAction: func(sim *Simulation) {
+				states[i] = movementState{moving: unit.Moving, distance: unit.DistanceFromTarget}
+			},
+		})
+	}
+	sim.runPendingActions()
+	return states
+}
+
+func TestOverlappingMovementWindows(t *testing.T) {
+	// The windows are merged into one from 10s to 25s, so players run 20 yards out until ~12.9s and
+	// back from ~22.1s.
+	sim, unit := setupMovementSim(&proto.EncounterMovement{
+		Windows: []*proto.MovementWindow{
+			{Start: 10, Duration: 10, Distance: 20},
+			{Start: 15, Duration: 10, Distance: 20},
+		},
+	})
+	start := unit.StartDistanceFromTarget
+	states := runMovementSim(sim, unit, []float64{11, 14, 20, 23, 26, 50}, nil)
+	for i, expected := range []movementState{
+		{true, start},
+		{false, start + 20},
+		{false, start + 20},
+		{true, start + 20},
+		{false, start},
+		{false, start},
+	} {
+		if states[i] != expected {
+			t.Errorf("Movement state %d is %v, expected %v", i, states[i], expected)
+		}
+	}
+}
+
+func TestOverlappingMoveAwayFor(t *testing.T) {
+	// Overlapping movements which aren't merged still keep the unit moving until the last one ends,
+	// and bring it back to where it started.
+	sim, unit := setupMovementSim(nil)
+	start := unit.StartDistanceFromTarget
+	states := runMovementSim(sim, unit, []float64{16, 18, 21, 23, 26, 50}, func(sim *Simulation) {
+		for _, seconds := range []float64{10, 15} {
+			StartDelayedAction(sim, DelayedActionOptions{
+				DoAt: DurationFromSeconds(seconds),
+				OnAction: func(sim *Simulation) {
+					unit.MoveAwayFor(time.Second*10, 20, sim)
+				},
+			})
+		}
+	})
+	for i, expected := range []movementState{
+		{true, start + 20},
+		{true, start + 40},
+		{false, start + 20},
+		{true, start + 20},
+		{false, start},
+		{false, start},
+	} {
+		if states[i] != expected {
+			t.Errorf("Movement state %d is %v, expected %v", i, states[i], expected)
+		}
+	}
+}
+
+func TestMoveForDuringMoveAwayFor(t *testing.T) {
+	// Stepping out of something while running away doesn't stop the unit before it gets there.
+	sim, unit := setupMovementSim(nil)
+	start := unit.StartDistanceFromTarget
+	states := runMovementSim(sim, unit, []float64{11.5, 12.5, 14, 16}, func(sim *Simulation) {
+		StartDelayedAction(sim, DelayedActionOptions{
+			DoAt: time.Second * 10,
+			OnAction: func(sim *Simulation) {
+				unit.MoveAwayFor(time.Second*10, 20, sim)
+			},
+		})
+		StartDelayedAction(sim, DelayedActionOptions{
+			DoAt: time.Second * 10,
+			OnAction: func(sim *Simulation) {
+				unit.MoveFor(time.Second, sim)
+			},
+		})
+		StartDelayedAction(sim, DelayedActionOptions{
+			DoAt: time.Second * 15,
+			OnAction: func(sim *Simulation) {
+				unit.MoveFor(time.Millisecond*1500, sim)
+			},
+		})
+	})
+	for i, expected := range []movementState{
+		{true, start},
+		{true, start},
+		{false, start + 20},
+		{true, start + 20},
+	} {
+		if states[i] != expected {
+			t.Errorf("Movement state %d is %v, expected %v", i, states[i], expected)
+		}
+	}
+}
+
+func TestRandomMovementWindows(t *testing.T) {
+	sim, _ := setupMovementSim(&proto.EncounterMovement{
+		RandomInterval:          10,
+		RandomIntervalVariation: 5,
+		RandomDuration:          3,
+		RandomDistance:          10,
+	})
+	sim.reset()
+
+	windows := sim.Encounter.movement.windows
+	if len(windows) < 3 {
+		t.Fatalf("Got %d random movement windows in %s, expected at least 3", len(windows), sim.Duration)
+	}
+	previousEnd := time.Duration(0)
+	for i, window := range windows {
+		if window.duration != time.Second*3 || window.distance != 10 {
+			t.Errorf("Window %d lasts %s and moves %f yards, expected 3s and 10 yards", i, window.duration, window.distance)
+		}
+		if interval := window.start - previousEnd; interval < time.Second*5 || interval > time.Second*15 {
+			t.Errorf("Window %d starts %s after the previous one, expected between 5s and 15s", i, interval)
+		}
+		if window.start >= sim.Duration {
+			t.Errorf("Window %d starts at %s, after the fight ends", i, window.start)
+		}
+		previousEnd = window.start + window.duration
+	}
+}
+
+func TestForcedMovementInterruptsHardcasts(t *testing.T) {
+	rsr := fakeCasterRequest(t, "cast_spell(spell:10149)\n", &proto.SimOptions{
+		Iterations:          1,
+		RandomSeed:          101,
+		DebugFirstIteration: true,
+		CombatLog:           true,
+	})
+	rsr.Encounter.Movement = &proto.EncounterMovement{
+		Windows: []*proto.MovementWindow{{Start: 10, Duration: 5}},
+	}
+	result := runFakeCasterSim(t, rsr)
+
+	// Hardcasts in progress when the window starts are interrupted, and none start during it.
+	castStart := 0.0
+	hardcasts := 0
+	for _, event := range result.CombatLog {
+		if event.Source.GetType() != proto.UnitReference_Player || event.ActionId.GetSpellId() != fakeFireballID {
+			continue
+		}
+		switch event.Type {
+		case proto.CombatLogEvent_CastStarted:
+			castStart = event.Timestamp
+		case proto.CombatLogEvent_CastCompleted:
+			hardcasts++
+			if event.Timestamp > 10 && castStart < 15 {
+				t.Errorf("Hardcast from %fs to %fs overlaps the movement window", castStart, event.Timestamp)
+			}
+		}
+	}
+	if hardcasts == 0 {
+		t.Errorf("No hardcasts in combat log")
+	}
+}
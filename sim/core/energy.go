@@ -147,6 +147,9 @@ func (eb *energyBar) addEnergyInternal(sim *Simulation, amount float64, metrics
 
 	newEnergy := min(eb.currentEnergy+amount, eb.maxEnergy)
 	metrics.AddEvent(amount, newEnergy-eb.currentEnergy)
+	if sim.combatLogEnabled {
+		sim.logResource(metrics, amount, newEnergy-eb.currentEnergy)
+	}
 
 	if sim.Log != nil {
 		eb.unit.Log(sim, "Gained %0.3f energy from %s (%0.3f --> %0.3f).", amount, metrics.ActionID, eb.currentEnergy, newEnergy)
@@ -169,6 +172,9 @@ func (eb *energyBar) SpendEnergy(sim *Simulation, amount float64, metrics *Resou
 
 	newEnergy := eb.currentEnergy - amount
 	metrics.AddEvent(-amount, -amount)
+	if sim.combatLogEnabled {
+		sim.logResource(metrics, -amount, -amount)
+	}
 
 	if sim.Log != nil {
 		eb.unit.Log(sim, "Spent %0.3f energy from %s (%0.3f --> %0.3f).", amount, metrics.ActionID, eb.currentEnergy, newEnergy)
@@ -196,6 +202,9 @@ func (eb *energyBar) ResetEnergyTick(sim *Simulation) {
 func (eb *energyBar) AddComboPoints(sim *Simulation, pointsToAdd int32, metrics *ResourceMetrics) {
 	newComboPoints := min(eb.comboPoints+pointsToAdd, 5)
 	metrics.AddEvent(float64(pointsToAdd), float64(newComboPoints-eb.comboPoints))
+	if sim.combatLogEnabled {
+		sim.logResource(metrics, float64(pointsToAdd), float64(newComboPoints-eb.comboPoints))
+	}
 
 	if sim.Log != nil {
 		eb.unit.Log(sim, "Gained %d combo points from %s (%d --> %d)", pointsToAdd, metrics.ActionID, eb.comboPoints, newComboPoints)
@@ -209,6 +218,9 @@ func (eb *energyBar) SpendComboPoints(sim *Simulation, metrics *ResourceMetrics)
 		eb.unit.Log(sim, "Spent %d combo points from %s (%d --> %d).", eb.comboPoints, metrics.ActionID, eb.comboPoints, 0)
 	}
 	metrics.AddEvent(float64(-eb.comboPoints), float64(-eb.comboPoints))
+	if sim.combatLogEnabled {
+		sim.logResource(metrics, float64(-eb.comboPoints), float64(-eb.comboPoints))
+	}
 	eb.comboPoints = 0
 }
 
@@ -176,6 +176,7 @@ func (env *Environment) finalize(raidProto *proto.Raid, _ *proto.Encounter, raid
 			char.Rotation = char.newAPLRotation(playerProto.Rotation)
 		}
 	}
+	env.Raid.finalizeCooldownPlan()
 
 	env.setupAttackTables()
 
@@ -257,14 +258,9 @@ func (env *Environment) IsFinalized() bool {
 }
 
 func (env *Environment) reset(sim *Simulation) {
-	// Reset primary targets damage taken for tracking health fights.
-	env.Encounter.DamageTaken = 0
-
 	// Targets need to be reset before the raid, so that players can check for
 	// the presence of permanent target auras in their Reset handlers.
-	for _, target := range env.Encounter.Targets {
-		target.Reset(sim)
-	}
+	env.Encounter.reset(sim)
 
 	env.Raid.reset(sim)
 }
@@ -274,8 +270,16 @@ func (env *Environment) GetMaxDuration() time.Duration {
 	return env.BaseDuration + env.DurationVariation
 }
 
+// Returns the number of active targets. Before the first iteration all targets count as
+// active, so that spells can size their buffers for the most targets they could hit.
 func (env *Environment) GetNumTargets() int32 {
-	return int32(len(env.Encounter.Targets))
+	return int32(len(env.Encounter.ActiveTargets))
+}
+
+// Shortens results, sized when a spell was registered for the most targets it can hit, to
+// the number of targets that are currently active.
+func (env *Environment) LimitToActiveTargets(results []*SpellResult) []*SpellResult {
+	return results[:max(min(len(results), len(env.Encounter.ActiveTargets)), 1)]
 }
 
 func (env *Environment) GetTarget(index int32) *Target {
@@ -353,6 +357,37 @@ func (env *Environment) GetUnit(ref *proto.UnitReference, contextUnit *Unit) *Un
 	return nil
 }
 
+// Inverse of GetUnit, returns the absolute reference to the given unit.
+func (env *Environment) GetUnitReference(unit *Unit) *proto.UnitReference {
+	if unit == nil {
+		return nil
+	}
+
+	switch unit.Type {
+	case PlayerUnit:
+		return &proto.UnitReference{Type: proto.UnitReference_Player, Index: unit.Index}
+	case EnemyUnit:
+		return &proto.UnitReference{Type: proto.UnitReference_Target, Index: unit.Index}
+	case PetUnit:
+		for _, player := range env.Raid.AllPlayerUnits {
+			if player.Type != PlayerUnit || player.Index != unit.Index {
+				continue
+			}
+			for i, pet := range env.Raid.GetPlayerFromUnit(player).GetCharacter().PetAgents {
+				if &pet.GetCharacter().Unit == unit {
+					return &proto.UnitReference{
+						Type:  proto.UnitReference_Pet,
+						Index: int32(i),
+						Owner: &proto.UnitReference{Type: proto.UnitReference_Player, Index: unit.Index},
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
 // Registers a callback to this Character which will be invoked BEFORE all Units
 // are finalized, but after they are all initialized and have other effects applied.
 func (env *Environment) RegisterPreFinalizeEffect(preFinalizeEffect PostFinalizeEffect) {
@@ -59,6 +59,9 @@ func (fb *focusBar) AddFocus(sim *Simulation, amount float64, metrics *ResourceM
 
 	newFocus := min(fb.currentFocus+amount, MaxFocus)
 	metrics.AddEvent(amount, newFocus-fb.currentFocus)
+	if sim.combatLogEnabled {
+		sim.logResource(metrics, amount, newFocus-fb.currentFocus)
+	}
 
 	if sim.Log != nil {
 		fb.unit.Log(sim, "Gained %0.3f focus from %s (%0.3f --> %0.3f).", amount, metrics.ActionID, fb.currentFocus, newFocus)
@@ -78,6 +81,9 @@ func (fb *focusBar) SpendFocus(sim *Simulation, amount float64, metrics *Resourc
 
 	newFocus := fb.currentFocus - amount
 	metrics.AddEvent(-amount, -amount)
+	if sim.combatLogEnabled {
+		sim.logResource(metrics, -amount, -amount)
+	}
 
 	if sim.Log != nil {
 		fb.unit.Log(sim, "Spent %0.3f focus from %s (%0.3f --> %0.3f).", amount, metrics.ActionID, fb.currentFocus, newFocus)
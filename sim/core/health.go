@@ -53,6 +53,9 @@ func (hb *healthBar) GainHealth(sim *Simulation, amount float64, metrics *Resour
 	oldHealth := hb.currentHealth
 	newHealth := min(oldHealth+amount, hb.unit.MaxHealth())
 	metrics.AddEvent(amount, newHealth-oldHealth)
+	if sim.combatLogEnabled {
+		sim.logResource(metrics, amount, newHealth-oldHealth)
+	}
 
 	if sim.Log != nil {
 		hb.unit.Log(sim, "Gained %0.3f health from %s (%0.3f --> %0.3f).", amount, metrics.ActionID, oldHealth, newHealth)
@@ -70,6 +73,9 @@ func (hb *healthBar) RemoveHealth(sim *Simulation, amount float64) {
 	newHealth := max(oldHealth-amount, 0)
 	metrics := hb.DamageTakenHealthMetrics
 	metrics.AddEvent(-amount, newHealth-oldHealth)
+	if sim.combatLogEnabled {
+		sim.logResource(metrics, -amount, newHealth-oldHealth)
+	}
 
 	// TMI calculations need timestamps and Max HP information for each damage taken event
 	if hb.unit.Metrics.isTanking {
@@ -65,6 +65,9 @@ type MajorCooldown struct {
 
 	// Whether this MCD is currently disabled.
 	disabled bool
+
+	// ID of the item with this use effect, if any.
+	itemID int32
 }
 
 func (mcd *MajorCooldown) ReadyAt() time.Duration {
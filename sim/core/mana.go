@@ -83,6 +83,9 @@ func (unit *Unit) AddMana(sim *Simulation, amount float64, metrics *ResourceMetr
 	oldMana := unit.CurrentMana()
 	newMana := min(oldMana+amount, unit.MaxMana())
 	metrics.AddEvent(amount, newMana-oldMana)
+	if sim.combatLogEnabled {
+		sim.logResource(metrics, amount, newMana-oldMana)
+	}
 
 	if sim.Log != nil {
 		unit.Log(sim, "Gained %0.3f mana from %s (%0.3f --> %0.3f).", amount, metrics.ActionID, oldMana, newMana)
@@ -99,6 +102,9 @@ func (unit *Unit) SpendMana(sim *Simulation, amount float64, metrics *ResourceMe
 
 	newMana := unit.CurrentMana() - amount
 	metrics.AddEvent(-amount, -amount)
+	if sim.combatLogEnabled {
+		sim.logResource(metrics, -amount, -amount)
+	}
 
 	if sim.Log != nil {
 		unit.Log(sim, "Spent %0.3f mana from %s (%0.3f --> %0.3f).", amount, metrics.ActionID, unit.CurrentMana(), newMana)
@@ -2,6 +2,7 @@ package core
 
 import (
 	"math"
+	"slices"
 	"time"
 
 	"github.com/wowsims/sod/sim/core/proto"
@@ -18,12 +19,21 @@ type DistributionMetrics struct {
 
 	// Aggregate values. These are updated after each iteration.
 	aggregator
-	max     float64
-	min     float64
-	maxSeed int64
-	minSeed int64
-	hist    map[int32]int32 // rounded DPS to count
-	sample  []float64
+	max          float64
+	min          float64
+	maxSeed      int64
+	minSeed      int64
+	maxIteration int32
+	minIteration int32
+	hist         map[int32]int32 // rounded DPS to count
+	histBucket   float64         // width of the hist buckets
+	sample       []float64
+
+	// Iteration index of each value in sample, in increasing order.
+	sampleIterations []int32
+
+	// Records each iteration's Total instead of the Total per second.
+	perIteration bool
 }
 
 func (distMetrics *DistributionMetrics) reset() {
@@ -32,33 +42,102 @@ func (distMetrics *DistributionMetrics) reset() {
 
 // This should be called when a Sim iteration is complete.
 func (distMetrics *DistributionMetrics) doneIteration(sim *Simulation) {
-	dps := distMetrics.Total / sim.Duration.Seconds()
+	dps := distMetrics.Total
+	if !distMetrics.perIteration {
+		dps /= sim.Duration.Seconds()
+	} else if distMetrics.histBucket == 0 {
+		// As coarse as 10 DPS over the whole fight, so the hist doesn't grow with the duration.
+		distMetrics.histBucket = 10 * max(sim.BaseDuration.Seconds(), 1)
+	}
 	distMetrics.add(dps)
 
 	if sim.Options.SaveAllValues {
 		if cap(distMetrics.sample) < int(sim.Options.Iterations) {
 			distMetrics.sample = make([]float64, 0, sim.Options.Iterations)
+			distMetrics.sampleIterations = make([]int32, 0, sim.Options.Iterations)
 		}
 		distMetrics.sample = append(distMetrics.sample, dps)
+		distMetrics.sampleIterations = append(distMetrics.sampleIterations, sim.iteration)
 	}
 
 	if dps > distMetrics.max {
 		distMetrics.max = dps
 		distMetrics.maxSeed = sim.rand.GetSeed()
+		distMetrics.maxIteration = sim.iteration
 	}
 	if dps <= distMetrics.min || distMetrics.min < 0 {
 		distMetrics.min = dps
 		distMetrics.minSeed = sim.rand.GetSeed()
+		distMetrics.minIteration = sim.iteration
 	}
 
-	dpsRounded := int32(math.Round(dps/10) * 10)
+	dpsRounded := int32(math.Round(dps/distMetrics.histBucket) * distMetrics.histBucket)
 	distMetrics.hist[dpsRounded]++
 }
 
+// Folds in the metrics from another Simulation, which ran different iterations.
+// The result is the same as if all iterations had been recorded here in order.
+func (distMetrics *DistributionMetrics) merge(other *DistributionMetrics) {
+	if other.n == 0 {
+		return
+	}
+
+	distMetrics.aggregator = *distMetrics.aggregator.merge(&other.aggregator)
+	distMetrics.mergeSample(other)
+	if distMetrics.histBucket == 0 {
+		distMetrics.histBucket = other.histBucket
+	}
+
+	// Ties go to the first iteration for max, and the last one for min, like doneIteration().
+	if other.max > distMetrics.max || (other.max == distMetrics.max && other.maxIteration < distMetrics.maxIteration) {
+		distMetrics.max = other.max
+		distMetrics.maxSeed = other.maxSeed
+		distMetrics.maxIteration = other.maxIteration
+	}
+	if other.min < distMetrics.min || (other.min == distMetrics.min && other.minIteration > distMetrics.minIteration) || distMetrics.min < 0 {
+		distMetrics.min = other.min
+		distMetrics.minSeed = other.minSeed
+		distMetrics.minIteration = other.minIteration
+	}
+
+	for dpsRounded, count := range other.hist {
+		distMetrics.hist[dpsRounded] += count
+	}
+}
+
+// Merges the other sample into this one, keeping the values in iteration order.
+func (distMetrics *DistributionMetrics) mergeSample(other *DistributionMetrics) {
+	if len(other.sample) == 0 {
+		return
+	}
+	if n := len(distMetrics.sampleIterations); n == 0 || distMetrics.sampleIterations[n-1] < other.sampleIterations[0] {
+		distMetrics.sample = append(distMetrics.sample, other.sample...)
+		distMetrics.sampleIterations = append(distMetrics.sampleIterations, other.sampleIterations...)
+		return
+	}
+
+	sample := make([]float64, 0, len(distMetrics.sample)+len(other.sample))
+	sampleIterations := make([]int32, 0, cap(sample))
+	i, j := 0, 0
+	for i < len(distMetrics.sample) || j < len(other.sample) {
+		if j == len(other.sample) || (i < len(distMetrics.sample) && distMetrics.sampleIterations[i] < other.sampleIterations[j]) {
+			sample = append(sample, distMetrics.sample[i])
+			sampleIterations = append(sampleIterations, distMetrics.sampleIterations[i])
+			i++
+		} else {
+			sample = append(sample, other.sample[j])
+			sampleIterations = append(sampleIterations, other.sampleIterations[j])
+			j++
+		}
+	}
+	distMetrics.sample = sample
+	distMetrics.sampleIterations = sampleIterations
+}
+
 func (distMetrics *DistributionMetrics) ToProto() *proto.DistributionMetrics {
 	mean, stdev := distMetrics.meanAndStdDev()
 
-	return &proto.DistributionMetrics{
+	protoMetrics := &proto.DistributionMetrics{
 		Avg:       mean,
 		Stdev:     stdev,
 		Max:       distMetrics.max,
@@ -68,15 +147,58 @@ func (distMetrics *DistributionMetrics) ToProto() *proto.DistributionMetrics {
 		Hist:      distMetrics.hist,
 		AllValues: distMetrics.sample,
 	}
+
+	if len(distMetrics.hist) > 0 {
+		buckets := make([]int32, 0, len(distMetrics.hist))
+		for bucket := range distMetrics.hist {
+			buckets = append(buckets, bucket)
+		}
+		slices.Sort(buckets)
+		protoMetrics.P5 = distMetrics.percentile(buckets, 0.05)
+		protoMetrics.P50 = distMetrics.percentile(buckets, 0.5)
+		protoMetrics.P95 = distMetrics.percentile(buckets, 0.95)
+	}
+
+	return protoMetrics
+}
+
+// Estimates the p-th percentile from the hist, assuming the values are spread
+// evenly within each bucket. Takes the sorted hist buckets.
+func (distMetrics *DistributionMetrics) percentile(buckets []int32, p float64) float64 {
+	total := int32(0)
+	for _, count := range distMetrics.hist {
+		total += count
+	}
+
+	rank := p * float64(total)
+	below := 0.0
+	for _, bucket := range buckets {
+		count := float64(distMetrics.hist[bucket])
+		if below+count >= rank {
+			estimate := float64(bucket) + distMetrics.histBucket*((rank-below)/count-0.5)
+			return min(max(estimate, distMetrics.min), distMetrics.max)
+		}
+		below += count
+	}
+	return distMetrics.max
 }
 
 func NewDistributionMetrics() DistributionMetrics {
 	return DistributionMetrics{
-		hist: make(map[int32]int32),
-		min:  -1,
+		hist:       make(map[int32]int32),
+		histBucket: 10,
+		min:        -1,
 	}
 }
 
+// Like NewDistributionMetrics, but for values per iteration rather than per second.
+func newPerIterationDistributionMetrics() DistributionMetrics {
+	distMetrics := NewDistributionMetrics()
+	distMetrics.perIteration = true
+	distMetrics.histBucket = 0
+	return distMetrics
+}
+
 type UnitMetrics struct {
 	dps    DistributionMetrics
 	dpasp  DistributionMetrics
@@ -94,9 +216,15 @@ type UnitMetrics struct {
 
 	// Aggregate values. These are updated after each iteration.
 	numItersDead int32
-	oomTimeSum   float64
+	oomTimeSum   exactSum
 	actions      map[ActionID]*ActionMetrics
 	resources    []*ResourceMetrics
+
+	// Only set if timeline metrics are enabled.
+	timeline *timelineMetrics
+
+	// Only set if APL profiling is enabled.
+	aplProfile *aplProfile
 }
 
 // Metrics for the current iteration, for 1 agent. Keep this as a separate
@@ -118,6 +246,16 @@ type ActionMetrics struct {
 
 	// Metrics for this action, for each possible target.
 	Targets []TargetedActionMetrics
+
+	// Damage done to opponents by this action, per iteration.
+	damage DistributionMetrics
+}
+
+func newActionMetrics(isMelee bool) *ActionMetrics {
+	return &ActionMetrics{
+		IsMelee: isMelee,
+		damage:  newPerIterationDistributionMetrics(),
+	}
 }
 
 type tmiListItem struct {
@@ -125,6 +263,20 @@ type tmiListItem struct {
 	WeightedDamage float64
 }
 
+func (actionMetrics *ActionMetrics) merge(other *ActionMetrics) {
+	if len(actionMetrics.Targets) == 0 {
+		actionMetrics.Targets = make([]TargetedActionMetrics, len(other.Targets))
+		for i := range actionMetrics.Targets {
+			actionMetrics.Targets[i].UnitIndex = other.Targets[i].UnitIndex
+		}
+	}
+
+	for i := range other.Targets {
+		actionMetrics.Targets[i].merge(&other.Targets[i])
+	}
+	actionMetrics.damage.merge(&other.damage)
+}
+
 func (actionMetrics *ActionMetrics) ToProto(actionID ActionID) *proto.ActionMetrics {
 	targetMetrics := make([]*proto.TargetedActionMetrics, 0, len(actionMetrics.Targets))
 	for _, tam := range actionMetrics.Targets {
@@ -135,6 +287,7 @@ func (actionMetrics *ActionMetrics) ToProto(actionID ActionID) *proto.ActionMetr
 		Id:      actionID.ToProto(),
 		IsMelee: actionMetrics.IsMelee,
 		Targets: targetMetrics,
+		Damage:  actionMetrics.damage.ToProto(),
 	}
 }
 
@@ -150,13 +303,19 @@ type SpellMetrics struct {
 	Parries int32
 	Blocks  int32
 
-	// Partial or full resists aren't tracked, at the moment, cp. applyResistances()
+	PartialResists1_4 int32 // Landed hits and ticks resisted by 25%.
+	PartialResists2_4 int32 // Landed hits and ticks resisted by 50%.
+	PartialResists3_4 int32 // Landed hits and ticks resisted by 75%.
+	BinaryMisses      int32 // Misses of binary spells, also counted in Misses.
 
 	TotalDamage    float64 // Damage done by all casts of this spell.
 	TotalThreat    float64 // Threat generated by all casts of this spell.
 	TotalHealing   float64 // Healing done by all casts of this spell.
 	TotalShielding float64 // Shielding done by all casts of this spell.
 	TotalCastTime  time.Duration
+
+	TotalArmorMitigated  float64 // Damage prevented by armor.
+	TotalResistMitigated float64 // Damage prevented by partial resists.
 }
 
 type TargetedActionMetrics struct {
@@ -171,11 +330,41 @@ type TargetedActionMetrics struct {
 	Blocks  int32
 	Glances int32
 
-	Damage    float64
-	Threat    float64
-	Healing   float64
-	Shielding float64
+	PartialResists1_4 int32
+	PartialResists2_4 int32
+	PartialResists3_4 int32
+	BinaryMisses      int32
+
+	damage    exactSum
+	threat    exactSum
+	healing   exactSum
+	shielding exactSum
 	CastTime  time.Duration
+
+	armorMitigated  exactSum
+	resistMitigated exactSum
+}
+
+func (tam *TargetedActionMetrics) merge(other *TargetedActionMetrics) {
+	tam.Casts += other.Casts
+	tam.Hits += other.Hits
+	tam.Crits += other.Crits
+	tam.Misses += other.Misses
+	tam.Dodges += other.Dodges
+	tam.Parries += other.Parries
+	tam.Blocks += other.Blocks
+	tam.Glances += other.Glances
+	tam.PartialResists1_4 += other.PartialResists1_4
+	tam.PartialResists2_4 += other.PartialResists2_4
+	tam.PartialResists3_4 += other.PartialResists3_4
+	tam.BinaryMisses += other.BinaryMisses
+	tam.damage.merge(&other.damage)
+	tam.threat.merge(&other.threat)
+	tam.healing.merge(&other.healing)
+	tam.shielding.merge(&other.shielding)
+	tam.CastTime += other.CastTime
+	tam.armorMitigated.merge(&other.armorMitigated)
+	tam.resistMitigated.merge(&other.resistMitigated)
 }
 
 func (tam *TargetedActionMetrics) ToProto() *proto.TargetedActionMetrics {
@@ -190,11 +379,18 @@ func (tam *TargetedActionMetrics) ToProto() *proto.TargetedActionMetrics {
 		Parries:    tam.Parries,
 		Blocks:     tam.Blocks,
 		Glances:    tam.Glances,
-		Damage:     tam.Damage,
-		Threat:     tam.Threat,
-		Healing:    tam.Healing,
-		Shielding:  tam.Shielding,
+		Damage:     tam.damage.value(),
+		Threat:     tam.threat.value(),
+		Healing:    tam.healing.value(),
+		Shielding:  tam.shielding.value(),
 		CastTimeMs: float64(tam.CastTime.Milliseconds()),
+
+		PartialResists_1_4: tam.PartialResists1_4,
+		PartialResists_2_4: tam.PartialResists2_4,
+		PartialResists_3_4: tam.PartialResists3_4,
+		BinaryMisses:       tam.BinaryMisses,
+		ArmorMitigated:     tam.armorMitigated.value(),
+		ResistMitigated:    tam.resistMitigated.value(),
 	}
 }
 
@@ -215,12 +411,18 @@ type ResourceMetrics struct {
 	ActionID ActionID
 	Type     proto.ResourceType
 
+	// Unit the resource belongs to, used for combat log events.
+	unit *Unit
+
+	// Metrics for the current iteration.
 	Events     int32
 	Gain       float64
 	ActualGain float64
 
-	EventsFromPreviousIterations     int32
-	ActualGainFromPreviousIterations float64
+	// Aggregate values. These are updated after each iteration.
+	eventsSum     int32
+	gainSum       exactSum
+	actualGainSum exactSum
 }
 
 func (resourceMetrics *ResourceMetrics) ToProto() *proto.ResourceMetrics {
@@ -228,21 +430,36 @@ func (resourceMetrics *ResourceMetrics) ToProto() *proto.ResourceMetrics {
 		Id:   resourceMetrics.ActionID.ToProto(),
 		Type: resourceMetrics.Type,
 
-		Events:     resourceMetrics.Events,
-		Gain:       resourceMetrics.Gain,
-		ActualGain: resourceMetrics.ActualGain,
+		Events:     resourceMetrics.eventsSum,
+		Gain:       resourceMetrics.gainSum.value(),
+		ActualGain: resourceMetrics.actualGainSum.value(),
 	}
 }
 
 func (resourceMetrics *ResourceMetrics) reset() {
-	resourceMetrics.EventsFromPreviousIterations = resourceMetrics.Events
-	resourceMetrics.ActualGainFromPreviousIterations = resourceMetrics.ActualGain
+	resourceMetrics.Events = 0
+	resourceMetrics.Gain = 0
+	resourceMetrics.ActualGain = 0
+}
+
+// This should be called when a Sim iteration is complete.
+func (resourceMetrics *ResourceMetrics) doneIteration() {
+	resourceMetrics.eventsSum += resourceMetrics.Events
+	resourceMetrics.gainSum.add(resourceMetrics.Gain)
+	resourceMetrics.actualGainSum.add(resourceMetrics.ActualGain)
+}
+
+func (resourceMetrics *ResourceMetrics) merge(other *ResourceMetrics) {
+	resourceMetrics.eventsSum += other.eventsSum
+	resourceMetrics.gainSum.merge(&other.gainSum)
+	resourceMetrics.actualGainSum.merge(&other.actualGainSum)
 }
+
 func (resourceMetrics *ResourceMetrics) EventsForCurrentIteration() int32 {
-	return resourceMetrics.Events - resourceMetrics.EventsFromPreviousIterations
+	return resourceMetrics.Events
 }
 func (resourceMetrics *ResourceMetrics) ActualGainForCurrentIteration() float64 {
-	return resourceMetrics.ActualGain - resourceMetrics.ActualGainFromPreviousIterations
+	return resourceMetrics.ActualGain
 }
 
 func (resourceMetrics *ResourceMetrics) AddEvent(gain float64, actualGain float64) {
@@ -260,24 +477,30 @@ func (unitMetrics *UnitMetrics) NewResourceMetrics(actionID ActionID, resourceTy
 	return newMetrics
 }
 
+func (unit *Unit) newResourceMetrics(actionID ActionID, resourceType proto.ResourceType) *ResourceMetrics {
+	newMetrics := unit.Metrics.NewResourceMetrics(actionID, resourceType)
+	newMetrics.unit = unit
+	return newMetrics
+}
+
 // Convenience helpers for NewResourceMetrics.
 func (unit *Unit) NewHealthMetrics(actionID ActionID) *ResourceMetrics {
-	return unit.Metrics.NewResourceMetrics(actionID, proto.ResourceType_ResourceTypeHealth)
+	return unit.newResourceMetrics(actionID, proto.ResourceType_ResourceTypeHealth)
 }
 func (unit *Unit) NewManaMetrics(actionID ActionID) *ResourceMetrics {
-	return unit.Metrics.NewResourceMetrics(actionID, proto.ResourceType_ResourceTypeMana)
+	return unit.newResourceMetrics(actionID, proto.ResourceType_ResourceTypeMana)
 }
 func (unit *Unit) NewRageMetrics(actionID ActionID) *ResourceMetrics {
-	return unit.Metrics.NewResourceMetrics(actionID, proto.ResourceType_ResourceTypeRage)
+	return unit.newResourceMetrics(actionID, proto.ResourceType_ResourceTypeRage)
 }
 func (unit *Unit) NewEnergyMetrics(actionID ActionID) *ResourceMetrics {
-	return unit.Metrics.NewResourceMetrics(actionID, proto.ResourceType_ResourceTypeEnergy)
+	return unit.newResourceMetrics(actionID, proto.ResourceType_ResourceTypeEnergy)
 }
 func (unit *Unit) NewComboPointMetrics(actionID ActionID) *ResourceMetrics {
-	return unit.Metrics.NewResourceMetrics(actionID, proto.ResourceType_ResourceTypeComboPoints)
+	return unit.newResourceMetrics(actionID, proto.ResourceType_ResourceTypeComboPoints)
 }
 func (unit *Unit) NewFocusMetrics(actionID ActionID) *ResourceMetrics {
-	return unit.Metrics.NewResourceMetrics(actionID, proto.ResourceType_ResourceTypeFocus)
+	return unit.newResourceMetrics(actionID, proto.ResourceType_ResourceTypeFocus)
 }
 
 // Adds the results of a spell to the character metrics.
@@ -285,7 +508,7 @@ func (unitMetrics *UnitMetrics) addSpellMetrics(spell *Spell, actionID ActionID,
 	actionMetrics, ok := unitMetrics.actions[actionID]
 
 	if !ok {
-		actionMetrics = &ActionMetrics{IsMelee: spell.Flags.Matches(SpellFlagMeleeMetrics)}
+		actionMetrics = newActionMetrics(spell.Flags.Matches(SpellFlagMeleeMetrics))
 		unitMetrics.actions[actionID] = actionMetrics
 	}
 
@@ -307,16 +530,23 @@ func (unitMetrics *UnitMetrics) addSpellMetrics(spell *Spell, actionID ActionID,
 		tam.Parries += spellTargetMetrics.Parries
 		tam.Blocks += spellTargetMetrics.Blocks
 		tam.Glances += spellTargetMetrics.Glances
-		tam.Damage += spellTargetMetrics.TotalDamage
-		tam.Threat += spellTargetMetrics.TotalThreat
-		tam.Healing += spellTargetMetrics.TotalHealing
-		tam.Shielding += spellTargetMetrics.TotalShielding
+		tam.PartialResists1_4 += spellTargetMetrics.PartialResists1_4
+		tam.PartialResists2_4 += spellTargetMetrics.PartialResists2_4
+		tam.PartialResists3_4 += spellTargetMetrics.PartialResists3_4
+		tam.BinaryMisses += spellTargetMetrics.BinaryMisses
+		tam.damage.add(spellTargetMetrics.TotalDamage)
+		tam.threat.add(spellTargetMetrics.TotalThreat)
+		tam.healing.add(spellTargetMetrics.TotalHealing)
+		tam.shielding.add(spellTargetMetrics.TotalShielding)
 		tam.CastTime += spellTargetMetrics.TotalCastTime
+		tam.armorMitigated.add(spellTargetMetrics.TotalArmorMitigated)
+		tam.resistMitigated.add(spellTargetMetrics.TotalResistMitigated)
 
 		target := spell.Unit.AttackTables[i][proto.CastType_CastTypeMainHand].Defender
 		target.Metrics.dtps.Total += spellTargetMetrics.TotalDamage
 
 		if spell.Unit.IsOpponent(target) {
+			actionMetrics.damage.Total += spellTargetMetrics.TotalDamage
 			unitMetrics.dps.Total += spellTargetMetrics.TotalDamage
 			unitMetrics.threat.Total += spellTargetMetrics.TotalThreat
 		} else {
@@ -361,6 +591,10 @@ func (unitMetrics *UnitMetrics) reset() {
 	unitMetrics.tto.reset()
 	unitMetrics.CharacterIterationMetrics = CharacterIterationMetrics{}
 
+	for _, actionMetrics := range unitMetrics.actions {
+		actionMetrics.damage.reset()
+	}
+
 	for _, resourceMetrics := range unitMetrics.resources {
 		resourceMetrics.reset()
 	}
@@ -396,6 +630,14 @@ func (unitMetrics *UnitMetrics) doneIteration(unit *Unit, sim *Simulation) {
 		unitMetrics.tmi.Total *= sim.Duration.Seconds()
 	}
 
+	if unitMetrics.timeline != nil {
+		unitMetrics.timeline.doneIteration()
+	}
+
+	if unitMetrics.aplProfile != nil {
+		unitMetrics.aplProfile.doneIteration(sim)
+	}
+
 	unitMetrics.dps.doneIteration(sim)
 	unitMetrics.dpasp.doneIteration(sim)
 	unitMetrics.threat.doneIteration(sim)
@@ -404,10 +646,66 @@ func (unitMetrics *UnitMetrics) doneIteration(unit *Unit, sim *Simulation) {
 	unitMetrics.hps.doneIteration(sim)
 	unitMetrics.tto.doneIteration(sim)
 
-	unitMetrics.oomTimeSum += unitMetrics.OOMTime.Seconds()
+	for _, actionMetrics := range unitMetrics.actions {
+		actionMetrics.damage.doneIteration(sim)
+	}
+
+	unitMetrics.oomTimeSum.add(unitMetrics.OOMTime.Seconds())
 	if unitMetrics.Died {
 		unitMetrics.numItersDead++
 	}
+
+	for _, resourceMetrics := range unitMetrics.resources {
+		resourceMetrics.doneIteration()
+	}
+}
+
+// Folds in the metrics of the same unit from another Simulation, whose
+// iterations all come after the ones already recorded here.
+func (unitMetrics *UnitMetrics) merge(other *UnitMetrics) {
+	unitMetrics.dps.merge(&other.dps)
+	unitMetrics.dpasp.merge(&other.dpasp)
+	unitMetrics.threat.merge(&other.threat)
+	unitMetrics.dtps.merge(&other.dtps)
+	unitMetrics.tmi.merge(&other.tmi)
+	unitMetrics.hps.merge(&other.hps)
+	unitMetrics.tto.merge(&other.tto)
+
+	unitMetrics.numItersDead += other.numItersDead
+	unitMetrics.oomTimeSum.merge(&other.oomTimeSum)
+
+	if unitMetrics.timeline != nil {
+		unitMetrics.timeline.merge(other.timeline)
+	}
+	if unitMetrics.aplProfile != nil {
+		unitMetrics.aplProfile.merge(other.aplProfile)
+	}
+
+	for actionID, otherAction := range other.actions {
+		action, ok := unitMetrics.actions[actionID]
+		if !ok {
+			action = newActionMetrics(otherAction.IsMelee)
+			unitMetrics.actions[actionID] = action
+		}
+		action.merge(otherAction)
+	}
+
+	// Resource metrics can also be registered during the sim, e.g. the first time a spell heals a
+	// target, so the other unit may have them in a different order or have ones this unit doesn't.
+	for i, otherResource := range other.resources {
+		matches := func(rm *ResourceMetrics) bool {
+			return rm.ActionID == otherResource.ActionID && rm.Type == otherResource.Type
+		}
+		var resource *ResourceMetrics
+		if i < len(unitMetrics.resources) && matches(unitMetrics.resources[i]) {
+			resource = unitMetrics.resources[i]
+		} else if idx := slices.IndexFunc(unitMetrics.resources, matches); idx >= 0 {
+			resource = unitMetrics.resources[idx]
+		} else {
+			resource = unitMetrics.NewResourceMetrics(otherResource.ActionID, otherResource.Type)
+		}
+		resource.merge(otherResource)
+	}
 }
 
 func (unitMetrics *UnitMetrics) calculateTMI(unit *Unit, sim *Simulation) float64 {
@@ -492,7 +790,7 @@ func (unitMetrics *UnitMetrics) ToProto() *proto.UnitMetrics {
 		Tmi:           unitMetrics.tmi.ToProto(),
 		Hps:           unitMetrics.hps.ToProto(),
 		Tto:           unitMetrics.tto.ToProto(),
-		SecondsOomAvg: unitMetrics.oomTimeSum / n,
+		SecondsOomAvg: unitMetrics.oomTimeSum.value() / n,
 		ChanceOfDeath: float64(unitMetrics.numItersDead) / n,
 	}
 
@@ -503,11 +801,18 @@ func (unitMetrics *UnitMetrics) ToProto() *proto.UnitMetrics {
 
 	protoMetrics.Resources = make([]*proto.ResourceMetrics, 0, len(unitMetrics.resources))
 	for _, resource := range unitMetrics.resources {
-		if resource.Events > 0 {
+		if resource.eventsSum > 0 {
 			protoMetrics.Resources = append(protoMetrics.Resources, resource.ToProto())
 		}
 	}
 
+	if unitMetrics.timeline != nil {
+		protoMetrics.Timeline = unitMetrics.timeline.ToProto(unitMetrics.dps.n)
+	}
+	if unitMetrics.aplProfile != nil {
+		protoMetrics.AplProfile = unitMetrics.aplProfile.ToProto(unitMetrics.dps.n)
+	}
+
 	return protoMetrics
 }
 
@@ -534,6 +839,11 @@ func (auraMetrics *AuraMetrics) doneIteration() {
 	auraMetrics.procsSum += auraMetrics.Procs
 }
 
+func (auraMetrics *AuraMetrics) merge(other *AuraMetrics) {
+	auraMetrics.aggregator = *auraMetrics.aggregator.merge(&other.aggregator)
+	auraMetrics.procsSum += other.procsSum
+}
+
 func (auraMetrics *AuraMetrics) ToProto() *proto.AuraMetrics {
 	mean, stdev := auraMetrics.meanAndStdDev()
 
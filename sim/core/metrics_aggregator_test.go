@@ -0,0 +1,214 @@
+package core
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/wowsims/sod/sim/core/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func init() {
+	RegisterAgentFactory(
+		proto.Player_HolyPaladin{},
+		proto.Spec_SpecHolyPaladin,
+		NewFakeHealer,
+		func(player *proto.Player, spec interface{}) {
+			playerSpec, ok := spec.(*proto.Player_HolyPaladin)
+			if !ok {
+				panic("Invalid spec value for Holy Paladin!")
+			}
+			player.Spec = playerSpec
+		},
+	)
+}
+
+// Heals a random player once per iteration, so which players have health metrics for the heal
+// depends on the iterations that were run.
+type FakeHealer struct {
+	FakeAgent
+}
+
+func NewFakeHealer(char *Character, _ *proto.Player) Agent {
+	healer := &FakeHealer{
+		FakeAgent: FakeAgent{
+			Character: *char,
+		},
+	}
+
+	healer.Init = func() {
+		healer.Spell = healer.RegisterSpell(SpellConfig{
+			ActionID:    ActionID{SpellID: 19750},
+			SpellSchool: SpellSchoolHoly,
+			ProcMask:    ProcMaskSpellHealing,
+			Flags:       SpellFlagHelpful,
+
+			DamageMultiplier: 1,
+			ThreatMultiplier: 1,
+
+			ApplyEffects: func(sim *Simulation, target *Unit, spell *Spell) {
+				spell.CalcAndDealHealing(sim, target, 100, spell.OutcomeHealing)
+			},
+		})
+	}
+
+	return healer
+}
+
+func (healer *FakeHealer) Reset(sim *Simulation) {
+	StartDelayedAction(sim, DelayedActionOptions{
+		DoAt: time.Second * 10,
+		OnAction: func(sim *Simulation) {
+			players := sim.Raid.AllPlayerUnits
+			target := players[int(sim.RandomFloat("Fake Heal")*float64(len(players)))]
+			healer.Spell.Cast(sim, target)
+		},
+	})
+}
+
+func TestUnitMetricsMergeResources(t *testing.T) {
+	mana := ActionID{SpellID: 1}
+	heal := ActionID{SpellID: 2}
+	addIteration := func(unitMetrics *UnitMetrics, actionID ActionID, resourceType proto.ResourceType, gain float64) {
+		for _, resource := range unitMetrics.resources {
+			if resource.ActionID == actionID && resource.Type == resourceType {
+				resource.Events, resource.Gain, resource.ActualGain = 1, gain, gain
+				resource.doneIteration()
+				return
+			}
+		}
+		t.Fatalf("No resource metrics for %s", actionID)
+	}
+
+	unitMetrics := NewUnitMetrics()
+	unitMetrics.NewResourceMetrics(mana, proto.ResourceType_ResourceTypeMana)
+	addIteration(&unitMetrics, mana, proto.ResourceType_ResourceTypeMana, 10)
+
+	// The other unit registered an extra metric before the shared one.
+	other := NewUnitMetrics()
+	other.NewResourceMetrics(heal, proto.ResourceType_ResourceTypeHealth)
+	other.NewResourceMetrics(mana, proto.ResourceType_ResourceTypeMana)
+	addIteration(&other, heal, proto.ResourceType_ResourceTypeHealth, 5)
+	addIteration(&other, mana, proto.ResourceType_ResourceTypeMana, 20)
+
+	unitMetrics.merge(&other)
+	if len(unitMetrics.resources) != 2 {
+		t.Fatalf("Got %d resource metrics after merging, expected 2", len(unitMetrics.resources))
+	}
+	for i, expected := range []struct {
+		actionID ActionID
+		events   int32
+		gain     float64
+	}{
+		{mana, 2, 30},
+		{heal, 1, 5},
+	} {
+		resource := unitMetrics.resources[i]
+		if resource.ActionID != expected.actionID || resource.eventsSum != expected.events || resource.gainSum.value() != expected.gain {
+			t.Errorf("Resource metrics %d are %s with %d events and %f gain, expected %s with %d events and %f gain",
+				i, resource.ActionID, resource.eventsSum, resource.gainSum.value(), expected.actionID, expected.events, expected.gain)
+		}
+	}
+}
+
+func TestWorkersHealingMetrics(t *testing.T) {
+	player := func(spec interface{}) *proto.Player {
+		return WithSpec(&proto.Player{
+			Class:     proto.Class_ClassShaman,
+			Equipment: &proto.EquipmentSpec{},
+		}, spec)
+	}
+	party := &proto.Party{Players: []*proto.Player{player(&proto.Player_HolyPaladin{})}}
+	for i := 0; i < 4; i++ {
+		party.Players = append(party.Players, player(&proto.Player_ElementalShaman{}))
+	}
+	rsr := &proto.RaidSimRequest{
+		Raid:      &proto.Raid{Parties: []*proto.Party{party}},
+		Encounter: MakeSingleTargetEncounter(60, 0),
+		SimOptions: &proto.SimOptions{
+			Iterations: 20,
+			RandomSeed: 101,
+		},
+	}
+
+	expected := RunRaidSim(rsr)
+	if expected.ErrorResult != "" {
+		t.Fatalf("Sim failed with error: %s", expected.ErrorResult)
+	}
+
+	for _, workers := range []int32{2, 3, 7} {
+		rsr.SimOptions.Workers = workers
+		result := RunRaidSim(rsr)
+		if result.ErrorResult != "" {
+			t.Fatalf("Sim with %d workers failed with error: %s", workers, result.ErrorResult)
+		}
+		diff := cmp.Diff(expected, result, protocmp.Transform(), protocmp.SortRepeated(func(a, b *proto.ActionMetrics) bool {
+			return a.Id.String() < b.Id.String()
+		}))
+		if diff != "" {
+			t.Fatalf("Result with %d workers differs from single-threaded result: %s", workers, diff)
+		}
+	}
+}
+
+func TestDistributionMetricsPercentiles(t *testing.T) {
+	distMetrics := NewDistributionMetrics()
+	distMetrics.hist = map[int32]int32{10: 2, 20: 4, 30: 3, 40: 1}
+	distMetrics.min = 7
+	distMetrics.max = 41
+
+	protoMetrics := distMetrics.ToProto()
+	for _, tc := range []struct {
+		name     string
+		value    float64
+		expected float64
+	}{
+		{"p5", protoMetrics.P5, 7.5},
+		{"p50", protoMetrics.P50, 22.5},
+		{"p95", protoMetrics.P95, 40},
+	} {
+		if math.Abs(tc.value-tc.expected) > 1e-9 {
+			t.Errorf("Estimated %s as %f, expected %f", tc.name, tc.value, tc.expected)
+		}
+	}
+
+	// Estimates don't go beyond the values actually seen.
+	distMetrics.hist = map[int32]int32{40: 1}
+	distMetrics.min = 38
+	if p5 := distMetrics.ToProto().P5; p5 != 38 {
+		t.Errorf("Estimated p5 as %f, expected the min 38", p5)
+	}
+}
+
+func TestActionDamageDistributions(t *testing.T) {
+	rsr := fakeCasterRequest(t, "cast_spell(spell:10197)\ncast_spell(spell:10149)\n", &proto.SimOptions{
+		Iterations: 100,
+		RandomSeed: 101,
+	})
+	rsr.Encounter.DurationVariation = 0
+	result := runFakeCasterSim(t, rsr)
+	if fakeCasterCasts(result, fakeFireBlastID) == 0 {
+		t.Fatalf("No Fire Blast casts")
+	}
+
+	player := result.RaidMetrics.Parties[0].Players[0]
+	totalDamage := 0.0
+	for _, action := range player.Actions {
+		dist := action.Damage
+		totalDamage += dist.Avg
+		if !(dist.Min <= dist.P5 && dist.P5 <= dist.P50 && dist.P50 <= dist.P95 && dist.P95 <= dist.Max) {
+			t.Errorf("Action %v has unordered distribution: min %f, p5 %f, p50 %f, p95 %f, max %f", action.Id, dist.Min, dist.P5, dist.P50, dist.P95, dist.Max)
+		}
+		if len(dist.AllValues) != 0 {
+			t.Errorf("Action %v has %d values, expected them only with SaveAllValues", action.Id, len(dist.AllValues))
+		}
+		if action.Id.GetSpellId() == fakeFireBlastID && dist.Max-dist.Min < 200 {
+			t.Errorf("Fire Blast damage ranges from %f to %f, expected it to vary by more than a roll", dist.Min, dist.Max)
+		}
+	}
+	if expected := player.Dps.Avg * 60; math.Abs(totalDamage-expected) > 1e-6*expected {
+		t.Errorf("Action damage adds up to %f, expected %f", totalDamage, expected)
+	}
+}
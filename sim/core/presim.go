@@ -31,10 +31,9 @@ type PresimOptions struct {
 	OnPresimResult func(presimResult *proto.UnitMetrics, iterations int32, duration time.Duration) bool
 }
 
-func (sim *Simulation) runPresims(request *proto.RaidSimRequest) *proto.RaidSimResult {
-	const numPresimIterations = 100
-
-	// Run presims if requested.
+// Returns the presim options for each Agent that wants a presim, indexed by
+// Character.Index, along with the number of such Agents.
+func (sim *Simulation) getPresimOptions(request *proto.RaidSimRequest) ([]*PresimOptions, int) {
 	raidPresimOptions := make([]*PresimOptions, 25)
 	remainingAgents := 0
 	for _, party := range sim.Raid.Parties {
@@ -60,6 +59,14 @@ func (sim *Simulation) runPresims(request *proto.RaidSimRequest) *proto.RaidSimR
 			remainingAgents++
 		}
 	}
+	return raidPresimOptions, remainingAgents
+}
+
+func (sim *Simulation) runPresims(request *proto.RaidSimRequest) *proto.RaidSimResult {
+	const numPresimIterations = 100
+
+	// Run presims if requested.
+	raidPresimOptions, remainingAgents := sim.getPresimOptions(request)
 
 	// Base presim request.
 	// Define this outside the loop so that, as Agents iteratively update their
@@ -70,6 +77,11 @@ func (sim *Simulation) runPresims(request *proto.RaidSimRequest) *proto.RaidSimR
 	presimRequest.SimOptions.Debug = false
 	presimRequest.SimOptions.DebugFirstIteration = false
 	presimRequest.SimOptions.Iterations = numPresimIterations
+	presimRequest.SimOptions.Workers = 0 // Too few iterations to be worth splitting.
+	presimRequest.SimOptions.TimelineMetrics = false
+	presimRequest.SimOptions.AplProfile = false
+	presimRequest.SimOptions.TargetDpsStderr = 0
+	presimRequest.SimOptions.TargetDpsCiWidth = 0
 	duration := DurationFromSeconds(presimRequest.Encounter.Duration)
 
 	var lastResult *proto.RaidSimResult
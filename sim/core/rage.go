@@ -117,6 +117,9 @@ func (rb *rageBar) AddRage(sim *Simulation, amount float64, metrics *ResourceMet
 
 	newRage := min(rb.currentRage+amount, MaxRage)
 	metrics.AddEvent(amount, newRage-rb.currentRage)
+	if sim.combatLogEnabled {
+		sim.logResource(metrics, amount, newRage-rb.currentRage)
+	}
 
 	if sim.Log != nil {
 		rb.unit.Log(sim, "Gained %0.3f rage from %s (%0.3f --> %0.3f).", amount, metrics.ActionID, rb.currentRage, newRage)
@@ -142,6 +145,9 @@ func (rb *rageBar) SpendRage(sim *Simulation, amount float64, metrics *ResourceM
 
 	newRage := rb.currentRage - amount
 	metrics.AddEvent(-amount, -amount)
+	if sim.combatLogEnabled {
+		sim.logResource(metrics, -amount, -amount)
+	}
 
 	if sim.Log != nil {
 		rb.unit.Log(sim, "Spent %0.3f rage from %s (%0.3f --> %0.3f).", amount, metrics.ActionID, rb.currentRage, newRage)
@@ -91,6 +91,11 @@ func (party *Party) doneIteration(sim *Simulation) {
 	party.hpsMetrics.doneIteration(sim)
 }
 
+func (party *Party) mergeMetrics(other *Party) {
+	party.dpsMetrics.merge(&other.dpsMetrics)
+	party.hpsMetrics.merge(&other.hpsMetrics)
+}
+
 func (party *Party) GetMetrics() *proto.PartyMetrics {
 	metrics := &proto.PartyMetrics{
 		Dps: party.dpsMetrics.ToProto(),
@@ -127,6 +132,8 @@ type Raid struct {
 	replenishmentUnits         []*Unit   // All units who can receive replenishment.
 	curReplenishmentUnits      [][]*Unit // Units that currently have replenishment active, separated by source.
 	leftoverReplenishmentUnits []*Unit   // Units without replenishment currently active.
+
+	cooldownPlan []*plannedCooldown
 }
 
 func (raid *Raid) GetActiveUnits() []*Unit {
@@ -302,6 +309,8 @@ func (raid *Raid) applyCharacterEffects(raidConfig *proto.Raid) *proto.RaidStats
 		raidStats.Parties = append(raidStats.Parties, partyStats)
 	}
 
+	raidStats.CooldownPlan = raid.initializeCooldownPlan(raidConfig.CooldownPlan)
+
 	return raidStats
 }
 
@@ -364,6 +373,7 @@ func (raid *Raid) reset(sim *Simulation) {
 	for _, party := range raid.Parties {
 		party.reset(sim)
 	}
+	raid.resetCooldownPlan(sim)
 	raid.dpsMetrics.reset()
 	raid.hpsMetrics.reset()
 }
@@ -379,6 +389,20 @@ func (raid *Raid) doneIteration(sim *Simulation) {
 	raid.hpsMetrics.doneIteration(sim)
 }
 
+// Folds in the raid, party and unit metrics from another Simulation built from
+// the same request, whose iterations all come after the ones recorded here.
+func (raid *Raid) mergeMetrics(other *Raid) {
+	raid.dpsMetrics.merge(&other.dpsMetrics)
+	raid.hpsMetrics.merge(&other.hpsMetrics)
+
+	for i, party := range raid.Parties {
+		party.mergeMetrics(other.Parties[i])
+	}
+	for i, unit := range raid.AllUnits {
+		unit.mergeMetrics(other.AllUnits[i])
+	}
+}
+
 func (raid *Raid) GetMetrics() *proto.RaidMetrics {
 	metrics := &proto.RaidMetrics{
 		Dps: raid.dpsMetrics.ToProto(),
@@ -0,0 +1,203 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/wowsims/sod/sim/core/proto"
+)
+
+// The raid cooldown plan casts external cooldowns such as Power Infusion on specific players, at
+// fixed times or whenever a condition is true, so buffs from several players can be lined up.
+// Receivers can check when their next buff is planned with the External Cooldown Time To Next
+// value, and hold their own cooldowns for it.
+
+// Action tag of the auras applied by the plan. Individual buff approximations use -1, and players
+// casting these on each other use their raid index.
+const cooldownPlanActionTag = -2
+
+type externalCooldownConfig struct {
+	cooldown time.Duration
+	aura     func(character *Character) *Aura
+}
+
+var externalCooldownConfigs = map[proto.ExternalCooldown]externalCooldownConfig{
+	proto.ExternalCooldown_ExternalCooldownPowerInfusion: {
+		cooldown: PowerInfusionCD,
+		aura: func(character *Character) *Aura {
+			return PowerInfusionAura(&character.Unit, cooldownPlanActionTag)
+		},
+	},
+	proto.ExternalCooldown_ExternalCooldownInnervate: {
+		cooldown: InnervateCD,
+		aura: func(character *Character) *Aura {
+			return InnervateAura(character, cooldownPlanActionTag)
+		},
+	},
+	proto.ExternalCooldown_ExternalCooldownBloodlust: {
+		cooldown: BloodlustCD,
+		aura: func(character *Character) *Aura {
+			// Bloodlust also buffs the pets, so their auras need to exist before the sim starts.
+			for _, pet := range character.Pets {
+				BloodlustAura(&pet.Character, cooldownPlanActionTag)
+			}
+			return BloodlustAura(character, cooldownPlanActionTag)
+		},
+	},
+}
+
+type plannedCooldown struct {
+	config    *proto.RaidCooldownAssignment
+	warnings  *[]string
+	cooldown  proto.ExternalCooldown
+	character *Character
+	aura      *Aura
+
+	// Either the timings or the condition are used.
+	timings   []time.Duration
+	condition APLValue
+	cd        time.Duration
+
+	// Earliest time the condition can cast the buff again in the current iteration.
+	readyAt time.Duration
+}
+
+// Resolves the targets of the plan and registers the auras it applies, returning the warnings of
+// each assignment.
+func (raid *Raid) initializeCooldownPlan(plan *proto.RaidCooldownPlan) []*proto.APLActionStats {
+	stats := make([]*proto.APLActionStats, len(plan.GetAssignments()))
+	for i, config := range plan.GetAssignments() {
+		stats[i] = &proto.APLActionStats{}
+		warn := func(format string, args ...interface{}) {
+			stats[i].Warnings = append(stats[i].Warnings, fmt.Sprintf(format, args...))
+		}
+
+		cooldownConfig, ok := externalCooldownConfigs[config.Cooldown]
+		if !ok {
+			warn("Unknown external cooldown: %s", config.Cooldown)
+			continue
+		}
+
+		var character *Character
+		if config.Target != nil && config.Target.Type == proto.UnitReference_Player {
+			for _, party := range raid.Parties {
+				for _, player := range party.Players {
+					if player.GetCharacter().Index == config.Target.Index {
+						character = player.GetCharacter()
+					}
+				}
+			}
+		}
+		if character == nil {
+			warn("Target must be a player in the raid")
+			continue
+		}
+
+		planned := &plannedCooldown{
+			config:    config,
+			warnings:  &stats[i].Warnings,
+			cooldown:  config.Cooldown,
+			character: character,
+			aura:      cooldownConfig.aura(character),
+			cd:        cooldownConfig.cooldown,
+		}
+
+		if config.Condition != nil {
+			if len(config.Timings) > 0 {
+				warn("Timings are ignored when a condition is set")
+			}
+		} else {
+			for _, timing := range config.Timings {
+				if timing < 0 {
+					warn("Timings must not be negative")
+					continue
+				}
+				planned.timings = append(planned.timings, DurationFromSeconds(timing))
+			}
+			slices.Sort(planned.timings)
+			for j := 1; j < len(planned.timings); j++ {
+				if planned.timings[j]-planned.timings[j-1] < planned.cd {
+					warn("Timings %s and %s are closer than the %s cooldown", planned.timings[j-1], planned.timings[j], planned.cd)
+				}
+			}
+			if len(config.Timings) == 0 {
+				warn("Needs timings or a condition")
+			}
+		}
+
+		raid.cooldownPlan = append(raid.cooldownPlan, planned)
+	}
+	return stats
+}
+
+// Builds the conditions of the plan, which needs the rotations of the targets.
+func (raid *Raid) finalizeCooldownPlan() {
+	for _, planned := range raid.cooldownPlan {
+		if planned.config.Condition == nil {
+			continue
+		}
+
+		rot := planned.character.Rotation
+		if rot == nil {
+			*planned.warnings = append(*planned.warnings, fmt.Sprintf("%s has no rotation to evaluate the condition", planned.character.Label))
+			continue
+		}
+		rot.doAndRecordWarnings(planned.warnings, false, func() {
+			planned.condition = rot.coerceTo(rot.newAPLValue(planned.config.Condition), proto.APLValueType_ValueTypeBool)
+			if planned.condition != nil {
+				finalizeAPLValues(rot, planned.condition)
+			}
+		})
+		if planned.condition != nil {
+			rot.plannedCooldowns = append(rot.plannedCooldowns, planned)
+		}
+	}
+}
+
+func finalizeAPLValues(rot *APLRotation, value APLValue) {
+	value.Finalize(rot)
+	for _, inner := range value.GetInnerValues() {
+		if inner != nil {
+			finalizeAPLValues(rot, inner)
+		}
+	}
+}
+
+func (raid *Raid) resetCooldownPlan(sim *Simulation) {
+	for _, planned := range raid.cooldownPlan {
+		planned.readyAt = 0
+		for _, timing := range planned.timings {
+			aura := planned.aura
+			StartDelayedAction(sim, DelayedActionOptions{
+				DoAt: timing,
+				OnAction: func(sim *Simulation) {
+					aura.Activate(sim)
+				},
+			})
+		}
+	}
+}
+
+// Casts the buffs whose conditions are true, before the target chooses its next action.
+func (planned *plannedCooldown) tryCast(sim *Simulation) {
+	if sim.CurrentTime >= planned.readyAt && planned.condition.GetBool(sim) {
+		planned.aura.Activate(sim)
+		planned.readyAt = sim.CurrentTime + planned.cd
+	}
+}
+
+// Returns the time until the next timed cast of the buff on the target. Buffs cast on a condition
+// can't be predicted, so they return NeverExpires rather than making the target hold its own
+// cooldowns for a cast which may never come.
+func (planned *plannedCooldown) timeToNext(sim *Simulation) time.Duration {
+	if planned.condition != nil {
+		return NeverExpires
+	}
+	for _, timing := range planned.timings {
+		if timing >= sim.CurrentTime {
+			return timing - sim.CurrentTime
+		}
+	}
+	return NeverExpires
+}
@@ -0,0 +1,74 @@
+package core
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/wowsims/sod/sim/core/proto"
+)
+
+func TestRaidCooldownPlan(t *testing.T) {
+	self := &proto.UnitReference{Type: proto.UnitReference_Player, Index: 0}
+	powerInfusion := func(timings ...float64) *proto.RaidCooldownAssignment {
+		return &proto.RaidCooldownAssignment{
+			Cooldown: proto.ExternalCooldown_ExternalCooldownPowerInfusion,
+			Target:   self,
+			Timings:  timings,
+		}
+	}
+	parseCondition := func(text string) *proto.APLValue {
+		rotation, err := APLRotationFromText("type: TypeAPL\nwait(1s) if " + text + "\n")
+		if err != nil {
+			t.Fatalf("Failed to parse condition: %s", err)
+		}
+		return rotation.PriorityList[0].Action.Condition
+	}
+	simDps := func(rotationText string, assignments ...*proto.RaidCooldownAssignment) (float64, []*proto.APLActionStats) {
+		rsr := fakeCasterRequest(t, rotationText+"\n", &proto.SimOptions{
+			Iterations: 20,
+			RandomSeed: 101,
+		})
+		rsr.Raid.CooldownPlan = &proto.RaidCooldownPlan{Assignments: assignments}
+		result := runFakeCasterSim(t, rsr)
+		stats := ComputeStats(&proto.ComputeStatsRequest{Raid: rsr.Raid, Encounter: rsr.Encounter})
+		return result.RaidMetrics.Dps.Avg, stats.RaidStats.CooldownPlan
+	}
+
+	fireball := "cast_spell(spell:10149)"
+	baselineDps, _ := simDps(fireball)
+	plannedDps, stats := simDps(fireball, powerInfusion(0, 180))
+	if len(stats) != 1 || len(stats[0].Warnings) != 0 {
+		t.Fatalf("Unexpected warnings: %v", stats)
+	}
+	if plannedDps <= baselineDps {
+		t.Errorf("Expected Power Infusion to increase dps, got %f vs %f", plannedDps, baselineDps)
+	}
+
+	conditional := powerInfusion()
+	conditional.Condition = parseCondition("current_time() >= 0s")
+	if conditionalDps, _ := simDps(fireball, conditional); conditionalDps <= baselineDps {
+		t.Errorf("Expected a condition which is always true to cast Power Infusion, got %f vs %f", conditionalDps, baselineDps)
+	}
+
+	// Without a plan the buff is never coming, so the condition doesn't hold back any casts.
+	held := fireball + " if external_cooldown_time_to_next(ExternalCooldownPowerInfusion) > 5s"
+	if unplannedDps, _ := simDps(held); unplannedDps != baselineDps {
+		t.Errorf("Expected the same dps without a plan, got %f vs %f", unplannedDps, baselineDps)
+	}
+	if heldDps, _ := simDps(held, powerInfusion(10)); heldDps >= plannedDps {
+		t.Errorf("Expected casts to be held for the planned buff, got %f vs %f", heldDps, plannedDps)
+	}
+
+	invalidTarget := powerInfusion(0)
+	invalidTarget.Target = &proto.UnitReference{Type: proto.UnitReference_Player, Index: 5}
+	_, stats = simDps(fireball, powerInfusion(0, 60), invalidTarget, powerInfusion())
+	for i, expected := range []string{
+		"Timings 0s and 1m0s are closer than the 3m0s cooldown",
+		"Target must be a player in the raid",
+		"Needs timings or a condition",
+	} {
+		if !slices.Contains(stats[i].Warnings, expected) {
+			t.Errorf("Expected warning %q for assignment %d, got %v", expected, i, stats[i].Warnings)
+		}
+	}
+}
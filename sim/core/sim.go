@@ -3,13 +3,13 @@ package core
 import (
 	"fmt"
 	"log"
-	"math"
 	"math/rand"
 	"runtime"
 	"runtime/debug"
 	"slices"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/wowsims/sod/sim/core/proto"
@@ -24,8 +24,9 @@ type Simulation struct {
 
 	Options *proto.SimOptions
 
-	rand  Rand
-	rseed int64
+	rand      Rand
+	rseed     int64 // Seed of the current iteration.
+	iteration int32 // Index of the current iteration.
 
 	// Used for testing only, see RandomFloat().
 	isTest    bool
@@ -41,15 +42,21 @@ type Simulation struct {
 
 	Log func(string, ...interface{})
 
+	// Structured combat log, see combat_log.go.
+	combatLogEnabled bool
+	combatLog        []*proto.CombatLogEvent
+
+	// Timeline metrics, see timeline_metrics.go.
+	timelineEnabled bool
+
 	executePhase int32 // 20, 25, or 35 for the respective execute range, 100 otherwise
 
 	executePhaseCallbacks []func(*Simulation, int32) // 2nd parameter is 35 for 35%, 25 for 25% and 20 for 20%
 
 	nextExecuteDuration time.Duration
-	nextExecuteDamage   float64
+	nextExecuteHealth   float64 // Health percent of the execute target, in health fights.
 
 	endOfCombatDuration time.Duration
-	endOfCombatDamage   float64
 
 	minTrackerTime time.Duration
 	trackers       []*auraTracker
@@ -59,6 +66,9 @@ type Simulation struct {
 
 	minTaskTime time.Duration
 	tasks       []Task
+
+	// Extra Simulations built from the same request, used to run iterations in parallel.
+	workers []*Simulation
 }
 
 func (sim *Simulation) rescheduleTracker(trackerTime time.Duration) {
@@ -174,6 +184,10 @@ func runSim(rsr *proto.RaidSimRequest, progress chan *proto.ProgressMetrics, ski
 		}
 	}
 
+	if sim.Options.Workers > 1 && !sim.Options.Debug {
+		sim.workers = sim.newWorkers(rsr, !skipPresim)
+	}
+
 	// using a variable here allows us to mutate it in the deferred recover, sending out error info
 	result = sim.run()
 
@@ -191,7 +205,7 @@ func newSimWithEnv(env *Environment, simOptions *proto.SimOptions) *Simulation {
 		rseed = time.Now().UnixNano()
 	}
 
-	return &Simulation{
+	sim := &Simulation{
 		Environment: env,
 		Options:     simOptions,
 
@@ -201,6 +215,9 @@ func newSimWithEnv(env *Environment, simOptions *proto.SimOptions) *Simulation {
 		isTest:    simOptions.IsTest,
 		testRands: make(map[string]Rand),
 	}
+	sim.setupTimelineMetrics()
+	sim.setupAPLProfiles()
+	return sim
 }
 
 // Returns a random float64 between 0.0 (inclusive) and 1.0 (exclusive).
@@ -229,6 +246,9 @@ func (sim *Simulation) labelRand(label string) Rand {
 
 func (sim *Simulation) reseedRands(i int64) {
 	rseed := sim.Options.RandomSeed + i
+	// Labels first used later in the iteration are seeded from it too, rather than from the
+	// initial seed, so their rolls don't depend on which iterations a worker ran before.
+	sim.rseed = rseed
 	sim.rand.Seed(rseed)
 
 	if sim.isTest {
@@ -284,6 +304,7 @@ func (sim *Simulation) run() *proto.RaidSimResult {
 		sim.Log = func(message string, vals ...interface{}) {
 			logsBuffer.WriteString(fmt.Sprintf("[%0.2f] "+message+"\n", append([]interface{}{sim.CurrentTime.Seconds()}, vals...)...))
 		}
+		sim.combatLogEnabled = sim.Options.CombatLog
 	}
 
 	// Uncomment this to print logs directly to console.
@@ -294,56 +315,79 @@ func (sim *Simulation) run() *proto.RaidSimResult {
 
 	sim.runOnce()
 	firstIterationDuration := sim.Duration
-	if sim.Encounter.EndFightAtHealth != 0 {
-		firstIterationDuration = sim.CurrentTime
-	}
 	totalDuration := firstIterationDuration
 
 	if !sim.Options.Debug {
 		sim.Log = nil
+		sim.combatLogEnabled = false
 	}
 
-	var st time.Time
-	for i := int32(1); i < sim.Options.Iterations; i++ {
-		// fmt.Printf("Iteration: %d\n", i)
-		if sim.ProgressReport != nil && time.Since(st) > time.Millisecond*100 {
-			metrics := sim.Raid.GetMetrics()
-			sim.ProgressReport(&proto.ProgressMetrics{TotalIterations: sim.Options.Iterations, CompletedIterations: i, Dps: metrics.Dps.Avg, Hps: metrics.Hps.Avg})
-			runtime.Gosched() // ensure that reporting threads are given time to report, mostly only important in wasm (only 1 thread)
-			st = time.Now()
-		}
-
-		// Before each iteration, reset state to seed+iterations
-		sim.reseedRands(int64(i))
-
-		sim.runOnce()
-		iterDuration := sim.Duration
-		if sim.Encounter.EndFightAtHealth != 0 {
-			iterDuration = sim.CurrentTime
-		}
-		totalDuration += iterDuration
+	completedIterations := int32(1)
+	if sim.hasPrecisionTarget() {
+		totalDuration += sim.runUntilPrecise(&completedIterations)
+	} else if len(sim.workers) > 0 {
+		totalDuration += sim.runConcurrently(1, sim.Options.Iterations, &completedIterations)
+		sim.mergeWorkerMetrics()
+	} else {
+		totalDuration += sim.runIterations(1, sim.Options.Iterations, &completedIterations)
 	}
+	iterations := completedIterations
+
+	dpsStderr, dpsCiWidth := dpsPrecision(&sim.Raid.dpsMetrics.aggregator)
 	result := &proto.RaidSimResult{
 		RaidMetrics:      sim.Raid.GetMetrics(),
 		EncounterMetrics: sim.Encounter.GetMetricsProto(),
 
 		Logs:                   logsBuffer.String(),
+		CombatLog:              sim.combatLog,
 		FirstIterationDuration: firstIterationDuration.Seconds(),
-		AvgIterationDuration:   totalDuration.Seconds() / float64(sim.Options.Iterations),
+		AvgIterationDuration:   totalDuration.Seconds() / float64(iterations),
+
+		Iterations: iterations,
+		DpsStderr:  dpsStderr,
+		DpsCiWidth: dpsCiWidth,
 	}
 
 	// Final progress report
 	if sim.ProgressReport != nil {
-		sim.ProgressReport(&proto.ProgressMetrics{TotalIterations: sim.Options.Iterations, CompletedIterations: sim.Options.Iterations, Dps: result.RaidMetrics.Dps.Avg, FinalRaidResult: result})
+		sim.ProgressReport(&proto.ProgressMetrics{TotalIterations: sim.Options.Iterations, CompletedIterations: iterations, Dps: result.RaidMetrics.Dps.Avg, FinalRaidResult: result})
 	}
 
-	if d := sim.Options.Iterations; d > 3000 {
+	if d := iterations; d > 3000 {
 		log.Printf("running %d iterations took %s", d, time.Since(t0))
 	}
 
 	return result
 }
 
+// Runs iterations [start, end), counting each one towards completedIterations.
+func (sim *Simulation) runIterations(start int32, end int32, completedIterations *int32) time.Duration {
+	var totalDuration time.Duration
+	var st time.Time
+	for i := start; i < end; i++ {
+		// fmt.Printf("Iteration: %d\n", i)
+		if sim.ProgressReport != nil && time.Since(st) > time.Millisecond*100 {
+			sim.reportProgress(atomic.LoadInt32(completedIterations))
+			st = time.Now()
+		}
+
+		// Before each iteration, reset state to seed+iterations
+		sim.reseedRands(int64(i))
+		sim.iteration = i
+
+		sim.runOnce()
+		totalDuration += sim.Duration
+		atomic.AddInt32(completedIterations, 1)
+	}
+	return totalDuration
+}
+
+func (sim *Simulation) reportProgress(completedIterations int32) {
+	metrics := sim.Raid.GetMetrics()
+	sim.ProgressReport(&proto.ProgressMetrics{TotalIterations: sim.Options.Iterations, CompletedIterations: completedIterations, Dps: metrics.Dps.Avg, Hps: metrics.Hps.Avg})
+	runtime.Gosched() // ensure that reporting threads are given time to report, mostly only important in wasm (only 1 thread)
+}
+
 // RunOnce is the main event loop. It will run the simulation for number of seconds.
 func (sim *Simulation) runOnce() {
 	sim.reset()
@@ -381,12 +425,10 @@ func (sim *Simulation) reset() {
 	sim.nextExecutePhase()
 	sim.executePhaseCallbacks = nil
 
-	// Use duration as an end check if not using health.
+	// Use duration as an end check if not using health, otherwise the fight ends once all targets have died.
 	sim.endOfCombatDuration = sim.Duration
-	sim.endOfCombatDamage = math.MaxFloat64
 	if sim.Encounter.EndFightAtHealth > 0 {
 		sim.endOfCombatDuration = NeverExpires
-		sim.endOfCombatDamage = sim.Encounter.EndFightAtHealth
 	}
 
 	sim.CurrentTime = 0
@@ -432,6 +474,15 @@ func (sim *Simulation) PrePull() {
 }
 
 func (sim *Simulation) Cleanup() {
+	// Health fights last until the last target died, which is when the event loop stopped.
+	if sim.Encounter.EndFightAtHealth > 0 {
+		sim.Duration = max(sim.CurrentTime, time.Millisecond)
+	}
+
+	if sim.timelineEnabled {
+		sim.recordTimeline(sim.Duration)
+	}
+
 	// The last event loop will leave CurrentTime at some value close to but not
 	// quite at the Duration. Explicitly set this so that accesses to CurrentTime
 	// during the doneIteration phase will return the Duration value, which is
@@ -468,7 +519,7 @@ func (sim *Simulation) Step() bool {
 	pa := sim.pendingActions[last]
 
 	if pa.NextActionAt >= sim.minWeaponAttackTime && sim.minWeaponAttackTime <= sim.minTaskTime {
-		if sim.minWeaponAttackTime > sim.endOfCombatDuration || sim.Encounter.DamageTaken > sim.endOfCombatDamage {
+		if sim.minWeaponAttackTime > sim.endOfCombatDuration || sim.Encounter.allTargetsDead {
 			return true
 		}
 		sim.advanceWeaponAttacks()
@@ -476,7 +527,7 @@ func (sim *Simulation) Step() bool {
 	}
 
 	if pa.NextActionAt >= sim.minTaskTime {
-		if sim.minTaskTime > sim.endOfCombatDuration || sim.Encounter.DamageTaken > sim.endOfCombatDamage {
+		if sim.minTaskTime > sim.endOfCombatDuration || sim.Encounter.allTargetsDead {
 			return true
 		}
 		sim.advanceTasks()
@@ -488,7 +539,7 @@ func (sim *Simulation) Step() bool {
 		return false
 	}
 
-	if pa.NextActionAt > sim.endOfCombatDuration || sim.Encounter.DamageTaken > sim.endOfCombatDamage {
+	if pa.NextActionAt > sim.endOfCombatDuration || sim.Encounter.allTargetsDead {
 		return true
 	}
 
@@ -528,12 +579,21 @@ func (sim *Simulation) advanceTasks() {
 
 // Advance moves time forward counting down auras, CDs, mana regen, etc
 func (sim *Simulation) advance(nextTime time.Duration) {
+	if sim.timelineEnabled {
+		sim.recordTimeline(nextTime)
+	}
 	sim.CurrentTime = nextTime
 
 	// this is a loop to handle duplicate ExecuteProportions, e.g. if they're all set to 100%, you reach
 	// execute phases 35%, 25%, and 20% in the first advance() call.
-	for sim.CurrentTime >= sim.nextExecuteDuration || sim.Encounter.DamageTaken >= sim.nextExecuteDamage {
+	for sim.CurrentTime >= sim.nextExecuteDuration || sim.Encounter.executeHealthPercent() <= sim.nextExecuteHealth {
 		sim.nextExecutePhase()
+		if sim.combatLogEnabled {
+			sim.logCombatEvent(&proto.CombatLogEvent{
+				Type:         proto.CombatLogEvent_ExecutePhase,
+				ExecutePhase: sim.executePhase,
+			})
+		}
 		for _, callback := range sim.executePhaseCallbacks {
 			callback(sim, sim.executePhase)
 		}
@@ -547,19 +607,19 @@ func (sim *Simulation) advance(nextTime time.Duration) {
 	}
 }
 
-// nextExecutePhase updates nextExecuteDuration and nextExecuteDamage based on executePhase.
+// nextExecutePhase updates nextExecuteDuration and nextExecuteHealth based on executePhase.
 func (sim *Simulation) nextExecutePhase() {
-	setup := func(phase int32, damage float64, health float64) {
+	setup := func(phase int32, healthPercent float64, proportion float64) {
 		sim.executePhase = phase
 		if sim.Encounter.EndFightAtHealth > 0 {
-			sim.nextExecuteDamage = (1 - damage) * sim.Encounter.EndFightAtHealth
+			sim.nextExecuteHealth = healthPercent
 		} else {
-			sim.nextExecuteDuration = time.Duration((1 - health) * float64(sim.Duration))
+			sim.nextExecuteDuration = time.Duration((1 - proportion) * float64(sim.Duration))
 		}
 	}
 
 	sim.nextExecuteDuration = NeverExpires
-	sim.nextExecuteDamage = math.MaxFloat64
+	sim.nextExecuteHealth = -1
 
 	switch sim.executePhase {
 	case 0: // reset, waiting for 35%
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/wowsims/sod/sim/core/proto"
+	googleProto "google.golang.org/protobuf/proto"
+)
+
+// Iterations can be split across several worker Simulations, configured with
+// SimOptions.Workers. Each iteration reseeds from its own index, so it doesn't
+// matter which worker runs it, and metrics are accumulated with exact sums so
+// merging the workers' metrics gives the same bits regardless of how the
+// iterations were split.
+
+// Builds the extra Simulations needed to run this one's iterations in
+// parallel. Each worker is built from the same request, so its Environment
+// matches this one.
+func (sim *Simulation) newWorkers(rsr *proto.RaidSimRequest, runPresims bool) []*Simulation {
+	numWorkers := min(sim.Options.Workers, sim.Options.Iterations-1) - 1
+
+	var workers []*Simulation
+	for i := int32(0); i < numWorkers; i++ {
+		workerRequest := googleProto.Clone(rsr).(*proto.RaidSimRequest)
+		worker := NewSim(workerRequest)
+
+		// Presims are deterministic, so this leaves the worker's Agents configured
+		// exactly like ours.
+		if _, numPresimAgents := worker.getPresimOptions(workerRequest); runPresims && numPresimAgents > 0 {
+			worker.runPresims(workerRequest)
+		}
+
+		workers = append(workers, worker)
+	}
+	return workers
+}
+
+// Copies the fight duration state, which the presim and the first iteration
+// of a health-based fight can update.
+func (sim *Simulation) syncDuration(other *Simulation) {
+	sim.BaseDuration = other.BaseDuration
+	sim.Duration = other.Duration
+	sim.CurrentTime = other.CurrentTime
+	sim.Encounter.DurationIsEstimate = other.Encounter.DurationIsEstimate
+}
+
+// Runs iterations [start, end) split into contiguous shards, the first on this
+// Simulation and the rest on its workers, counting each one towards
+// completedIterations. The workers keep their metrics until mergeWorkerMetrics().
+func (sim *Simulation) runConcurrently(start int32, end int32, completedIterations *int32) time.Duration {
+	numShards := int64(len(sim.workers) + 1)
+	numIterations := int64(end - start)
+	shardStart := func(shard int64) int32 {
+		return start + int32(numIterations*shard/numShards)
+	}
+
+	durations := make([]time.Duration, numShards)
+	workerErrors := make([]string, numShards)
+
+	var waitGroup sync.WaitGroup
+	for i, worker := range sim.workers {
+		worker.syncDuration(sim)
+
+		waitGroup.Add(1)
+		go func(worker *Simulation, shard int64) {
+			defer waitGroup.Done()
+			defer func() {
+				if err := recover(); err != nil {
+					workerErrors[shard] = fmt.Sprintf("%v\nWorker Stack Trace:\n%s", err, debug.Stack())
+				}
+			}()
+			durations[shard] = worker.runIterations(shardStart(shard), shardStart(shard+1), completedIterations)
+		}(worker, int64(i+1))
+	}
+
+	durations[0] = sim.runIterations(shardStart(0), shardStart(1), completedIterations)
+
+	done := make(chan struct{})
+	go func() {
+		waitGroup.Wait()
+		close(done)
+	}()
+	for waiting := true; waiting; {
+		select {
+		case <-done:
+			waiting = false
+		case <-time.After(time.Millisecond * 100):
+			if sim.ProgressReport != nil {
+				sim.reportProgress(atomic.LoadInt32(completedIterations))
+			}
+		}
+	}
+
+	for _, err := range workerErrors {
+		if err != "" {
+			panic(err)
+		}
+	}
+
+	var totalDuration time.Duration
+	for _, duration := range durations {
+		totalDuration += duration
+	}
+	return totalDuration
+}
+
+// Folds the workers' metrics into this Simulation's.
+func (sim *Simulation) mergeWorkerMetrics() {
+	for _, worker := range sim.workers {
+		sim.Raid.mergeMetrics(worker.Raid)
+		sim.Encounter.mergeMetrics(&worker.Encounter)
+	}
+}
@@ -0,0 +1,61 @@
+package core
+
+import (
+	"math"
+	"time"
+)
+
+// How often, in iterations, to check whether the precision target has been reached.
+const precisionCheckInterval = 100
+
+func (sim *Simulation) hasPrecisionTarget() bool {
+	return sim.Options.TargetDpsStderr > 0 || sim.Options.TargetDpsCiWidth > 0
+}
+
+// Returns the standard error and 95% confidence interval width of the mean of the values in
+// the aggregator.
+func dpsPrecision(dps *aggregator) (float64, float64) {
+	if dps.n < 2 {
+		return 0, 0
+	}
+	_, stdev := dps.meanAndStdDev()
+	stderr := stdev / math.Sqrt(float64(dps.n))
+	return stderr, 2 * confidenceZ95 * stderr
+}
+
+func (sim *Simulation) reachedPrecisionTarget() bool {
+	// The workers' metrics haven't been merged yet, so combine them here.
+	dps := &sim.Raid.dpsMetrics.aggregator
+	for _, worker := range sim.workers {
+		dps = dps.merge(&worker.Raid.dpsMetrics.aggregator)
+	}
+
+	stderr, ciWidth := dpsPrecision(dps)
+	return (sim.Options.TargetDpsStderr > 0 && stderr < sim.Options.TargetDpsStderr) ||
+		(sim.Options.TargetDpsCiWidth > 0 && ciWidth < sim.Options.TargetDpsCiWidth)
+}
+
+// Runs iterations in batches until the raid dps reaches the precision target or all
+// iterations have run. Batches always end on a multiple of precisionCheckInterval, so the
+// iterations run don't depend on the number of workers.
+func (sim *Simulation) runUntilPrecise(completedIterations *int32) time.Duration {
+	var totalDuration time.Duration
+	for start := *completedIterations; start < sim.Options.Iterations; {
+		end := min(sim.Options.Iterations, (start/precisionCheckInterval+1)*precisionCheckInterval)
+		if len(sim.workers) > 0 {
+			totalDuration += sim.runConcurrently(start, end, completedIterations)
+		} else {
+			totalDuration += sim.runIterations(start, end, completedIterations)
+		}
+		start = end
+
+		if sim.reachedPrecisionTarget() {
+			break
+		}
+	}
+
+	if len(sim.workers) > 0 {
+		sim.mergeWorkerMetrics()
+	}
+	return totalDuration
+}
@@ -0,0 +1,39 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/wowsims/sod/sim/core/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestPrecisionTarget(t *testing.T) {
+	rsr := fakeCasterRequest(t, "cast_spell(spell:10197)\ncast_spell(spell:10149)\n", &proto.SimOptions{
+		Iterations:      5000,
+		RandomSeed:      101,
+		SaveAllValues:   true,
+		TargetDpsStderr: 2,
+	})
+
+	expected := runFakeCasterSim(t, rsr)
+	if expected.Iterations >= rsr.SimOptions.Iterations || expected.Iterations%100 != 0 {
+		t.Errorf("Expected to stop early on a multiple of 100 iterations, ran %d", expected.Iterations)
+	}
+	if expected.DpsStderr >= rsr.SimOptions.TargetDpsStderr {
+		t.Errorf("Stopped with dps standard error %f, expected less than %f", expected.DpsStderr, rsr.SimOptions.TargetDpsStderr)
+	}
+	if n := int32(len(expected.RaidMetrics.Dps.AllValues)); n != expected.Iterations {
+		t.Errorf("Got %d dps values for %d iterations", n, expected.Iterations)
+	}
+
+	// Workers run each batch of iterations in shards, so their values must be put back in order.
+	rsr.SimOptions.Workers = 3
+	result := runFakeCasterSim(t, rsr)
+	diff := cmp.Diff(expected, result, protocmp.Transform(), protocmp.SortRepeated(func(a, b *proto.ActionMetrics) bool {
+		return a.Id.String() < b.Id.String()
+	}))
+	if diff != "" {
+		t.Fatalf("Result with 3 workers differs from single-threaded result: %s", diff)
+	}
+}
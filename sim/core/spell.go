@@ -119,6 +119,8 @@ type Spell struct {
 	// Optional field. Calculates expected average damage.
 	expectedInitialDamageInternal ExpectedDamageCalculator
 	expectedTickDamageInternal    ExpectedDamageCalculator
+	// Set while calculating expected damage, which must not roll or log anything.
+	calculatingExpectedDamage bool
 
 	// The current or most recent cast data.
 	CurCast Cast
@@ -546,6 +548,10 @@ func (spell *Spell) SkipCastAndApplyEffects(sim *Simulation, target *Unit) {
 			spell.ActionID, spell.DefaultCast.Cost, time.Duration(0))
 		spell.Unit.Log(sim, "Completed cast %s", spell.ActionID)
 	}
+	if sim.combatLogEnabled && !spell.Flags.Matches(SpellFlagNoLogs) {
+		sim.logCast(proto.CombatLogEvent_CastStarted, spell, target, spell.DefaultCast.Cost, 0)
+		sim.logCast(proto.CombatLogEvent_CastCompleted, spell, target, spell.DefaultCast.Cost, 0)
+	}
 	spell.applyEffects(sim, target)
 }
 
@@ -557,9 +563,8 @@ func (spell *Spell) applyEffects(sim *Simulation, target *Unit) {
 }
 
 func (spell *Spell) ApplyAOEThreatIgnoreMultipliers(threatAmount float64) {
-	numTargets := spell.Unit.Env.GetNumTargets()
-	for i := int32(0); i < numTargets; i++ {
-		spell.SpellMetrics[i].TotalThreat += threatAmount
+	for _, target := range spell.Unit.Env.Encounter.ActiveTargetUnits {
+		spell.SpellMetrics[target.UnitIndex].TotalThreat += threatAmount
 	}
 }
 func (spell *Spell) ApplyAOEThreat(threatAmount float64) {
@@ -575,20 +580,64 @@ func (spell *Spell) finalizeExpectedDamage(result *SpellResult) {
 	}
 	result.inUse = false
 }
-func (spell *Spell) ExpectedInitialDamage(sim *Simulation, target *Unit) float64 {
-	result := spell.expectedInitialDamageInternal(sim, target, spell, false)
+func (spell *Spell) calcExpectedDamage(sim *Simulation, target *Unit, calculator ExpectedDamageCalculator, useSnapshot bool) float64 {
+	spell.calculatingExpectedDamage = true
+	result := calculator(sim, target, spell, useSnapshot)
+	spell.calculatingExpectedDamage = false
 	spell.finalizeExpectedDamage(result)
 	return result.Damage
 }
+func (spell *Spell) ExpectedInitialDamage(sim *Simulation, target *Unit) float64 {
+	return spell.calcExpectedDamage(sim, target, spell.expectedInitialDamageInternal, false)
+}
 func (spell *Spell) ExpectedTickDamage(sim *Simulation, target *Unit) float64 {
-	result := spell.expectedTickDamageInternal(sim, target, spell, false)
-	spell.finalizeExpectedDamage(result)
-	return result.Damage
+	return spell.calcExpectedDamage(sim, target, spell.expectedTickDamageInternal, false)
 }
 func (spell *Spell) ExpectedTickDamageFromCurrentSnapshot(sim *Simulation, target *Unit) float64 {
-	result := spell.expectedTickDamageInternal(sim, target, spell, true)
-	spell.finalizeExpectedDamage(result)
-	return result.Damage
+	return spell.calcExpectedDamage(sim, target, spell.expectedTickDamageInternal, true)
+}
+
+func (spell *Spell) HasExpectedDamage() bool {
+	return spell.expectedInitialDamageInternal != nil || spell.expectedTickDamageInternal != nil
+}
+
+// The dot whose ticks are part of a cast of this spell on the given target, if any.
+func (spell *Spell) expectedDot(target *Unit) *Dot {
+	if spell.aoeDot != nil {
+		return spell.aoeDot
+	} else if spell.dots != nil {
+		return spell.dots.Get(target)
+	}
+	return nil
+}
+
+// Returns the expected damage of a full cast of this spell on the target, including every tick of
+// its dot or channel, based on the current auras of the caster and target.
+func (spell *Spell) ExpectedDamage(sim *Simulation, target *Unit) float64 {
+	damage := 0.0
+	if spell.expectedInitialDamageInternal != nil {
+		damage += spell.ExpectedInitialDamage(sim, target)
+	}
+	if spell.expectedTickDamageInternal != nil {
+		if dot := spell.expectedDot(target); dot != nil {
+			damage += spell.ExpectedTickDamage(sim, target) * float64(dot.NumberOfTicks)
+		}
+	}
+	return damage
+}
+
+// Returns how long a cast of this spell keeps the caster busy, including its channel, or its GCD
+// if that is longer.
+func (spell *Spell) ExpectedCastDuration(target *Unit) time.Duration {
+	duration := spell.CastTime()
+	if dot := spell.expectedDot(target); dot != nil && dot.isChanneled {
+		tickLength := dot.TickLength
+		if dot.AffectedByCastSpeed {
+			tickLength = spell.Unit.ApplyCastSpeedForSpell(tickLength, spell)
+		}
+		duration += tickLength * time.Duration(dot.NumberOfTicks)
+	}
+	return max(duration, spell.DefaultCast.GCD)
 }
 
 // Time until either the cast is finished or GCD is ready again, whichever is longer
@@ -86,6 +86,9 @@ func (dot *Dot) OutcomeMagicHitAndSnapshotCrit(sim *Simulation, result *SpellRes
 		result.Outcome = OutcomeMiss
 		result.Damage = 0
 		dot.Spell.SpellMetrics[result.Target.UnitIndex].Misses++
+		if dot.Spell.Flags.Matches(SpellFlagBinary) {
+			dot.Spell.SpellMetrics[result.Target.UnitIndex].BinaryMisses++
+		}
 	}
 }
 
@@ -103,6 +106,9 @@ func (spell *Spell) OutcomeMagicHitAndCrit(sim *Simulation, result *SpellResult,
 		result.Outcome = OutcomeMiss
 		result.Damage = 0
 		spell.SpellMetrics[result.Target.UnitIndex].Misses++
+		if spell.Flags.Matches(SpellFlagBinary) {
+			spell.SpellMetrics[result.Target.UnitIndex].BinaryMisses++
+		}
 	}
 }
 
@@ -149,6 +155,9 @@ func (spell *Spell) OutcomeMagicHit(sim *Simulation, result *SpellResult, attack
 		result.Outcome = OutcomeMiss
 		result.Damage = 0
 		spell.SpellMetrics[result.Target.UnitIndex].Misses++
+		if spell.Flags.Matches(SpellFlagBinary) {
+			spell.SpellMetrics[result.Target.UnitIndex].BinaryMisses++
+		}
 	}
 }
 
@@ -617,6 +626,22 @@ func (spell *Spell) OutcomeExpectedMagicHitAndCrit(_ *Simulation, result *SpellR
 	result.Damage *= averageMultiplier
 }
 
+// Expected value of OutcomeMeleeSpecialHitAndCrit. Blocks are ignored, since they only remove a
+// flat amount of damage.
+func (spell *Spell) OutcomeExpectedMeleeSpecialHitAndCrit(_ *Simulation, result *SpellResult, attackTable *AttackTable) {
+	landChance := 1.0
+	landChance -= max(0, attackTable.BaseMissChance-spell.PhysicalHitChance(attackTable))
+	landChance -= max(0, attackTable.BaseDodgeChance)
+	if spell.Unit.PseudoStats.InFrontOfTarget {
+		landChance -= max(0, attackTable.BaseParryChance)
+	}
+
+	averageMultiplier := max(0, landChance)
+	averageMultiplier += averageMultiplier * max(0, spell.PhysicalCritChance(attackTable)) * (spell.CritMultiplier(attackTable) - 1)
+
+	result.Damage *= averageMultiplier
+}
+
 func (dot *Dot) OutcomeExpectedMagicSnapshotCrit(_ *Simulation, result *SpellResult, attackTable *AttackTable) {
 	averageMultiplier := 1.0
 	averageMultiplier += dot.SnapshotCritChance * (dot.Spell.CritMultiplier(attackTable) - 1)
@@ -37,6 +37,13 @@ func (spell *Spell) ResistanceMultiplier(sim *Simulation, isPeriodic bool, attac
 		return 1, OutcomeEmpty
 	}
 
+	// Expected damage uses the average partial resist instead of a roll. Each threshold is the
+	// chance of resisting at least another 25% of the damage.
+	if spell.calculatingExpectedDamage {
+		threshold00, threshold25, threshold50 := attackTable.GetPartialResistThresholds(spell, spell.Flags.Matches(SpellFlagPureDot))
+		return 1 - 0.25*(threshold00+threshold25+threshold50), OutcomeEmpty
+	}
+
 	resistanceRoll := sim.RandomFloat("Partial Resist")
 
 	threshold00, threshold25, threshold50 := attackTable.GetPartialResistThresholds(spell, spell.Flags.Matches(SpellFlagPureDot))
@@ -241,3 +241,69 @@ func Test_ResistBinary(t *testing.T) {
 		}
 	}
 }
+
+func Test_ResistExpectedDamage(t *testing.T) {
+	attacker := &Unit{
+		Type:  PlayerUnit,
+		Level: 60,
+		stats: stats.Stats{},
+	}
+	defender := &Unit{
+		Type:  EnemyUnit,
+		Level: attacker.Level + 3,
+		stats: stats.Stats{},
+	}
+
+	attackTable := NewAttackTable(attacker, defender, nil)
+
+	schoolMask := SpellSchoolFromIndex(stats.SchoolIndexNature)
+	spell := &Spell{
+		SpellSchool:               schoolMask,
+		SchoolIndex:               schoolMask.GetSchoolIndex(),
+		SchoolBaseIndices:         schoolMask.GetBaseIndices(),
+		calculatingExpectedDamage: true,
+	}
+
+	// Expected damage doesn't roll, so no sim is needed, and uses the average mitigation.
+	defender.stats[stats.NatureResistance] = 200
+	dmgMult, outcome := spell.ResistanceMultiplier(nil, false, attackTable)
+	if !CloseEnough(dmgMult, 1-0.545, 0.01) || outcome != OutcomeEmpty {
+		t.Errorf("Expected damage multiplier at 200 resistance was %.3f with outcome %d, expected %.3f and empty outcome!", dmgMult, outcome, 1-0.545)
+	}
+
+	for resist := 0.0; resist < float64(attacker.Level)*5.0; resist += 1.0 {
+		defender.stats[stats.NatureResistance] = resist
+		avgResist, _, _, _, _ := GetChancesAndMitFromThresholds(attackTable.GetPartialResistThresholds(spell, false))
+		if dmgMult, _ := spell.ResistanceMultiplier(nil, false, attackTable); !CloseEnough(dmgMult, 1-avgResist, 1e-9) {
+			t.Errorf("Expected damage multiplier at %.0f resistance was %.3f, expected %.3f!", resist, dmgMult, 1-avgResist)
+			return
+		}
+	}
+}
+
+func Test_MitigationMetrics(t *testing.T) {
+	target := &Unit{UnitIndex: 0}
+	spell := &Spell{SpellMetrics: make([]SpellMetrics, 1)}
+
+	for _, result := range []*SpellResult{
+		{Target: target, Outcome: OutcomeHit | OutcomePartial1_4, Damage: 75, ResistanceMultiplier: 0.75},
+		{Target: target, Outcome: OutcomeCrit | OutcomePartial3_4, Damage: 50, ResistanceMultiplier: 0.25},
+		{Target: target, Outcome: OutcomeHit, Damage: 80, ResistanceMultiplier: 0.8},
+		// Misses and unmitigated hits don't prevent any damage.
+		{Target: target, Outcome: OutcomeMiss, ResistanceMultiplier: 0.5},
+		{Target: target, Outcome: OutcomeHit, Damage: 100, ResistanceMultiplier: 1},
+	} {
+		spell.addMitigationMetrics(result)
+	}
+
+	metrics := spell.SpellMetrics[0]
+	if metrics.PartialResists1_4 != 1 || metrics.PartialResists2_4 != 0 || metrics.PartialResists3_4 != 1 {
+		t.Errorf("Got partial resists %d/%d/%d, expected 1/0/1", metrics.PartialResists1_4, metrics.PartialResists2_4, metrics.PartialResists3_4)
+	}
+	if !CloseEnough(metrics.TotalResistMitigated, 25+150, 1e-9) {
+		t.Errorf("Got %.3f damage mitigated by resists, expected %.3f", metrics.TotalResistMitigated, 25.0+150)
+	}
+	if !CloseEnough(metrics.TotalArmorMitigated, 20, 1e-9) {
+		t.Errorf("Got %.3f damage mitigated by armor, expected %.3f", metrics.TotalArmorMitigated, 20.0)
+	}
+}
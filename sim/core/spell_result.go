@@ -3,6 +3,7 @@ package core
 import (
 	"fmt"
 
+	"github.com/wowsims/sod/sim/core/proto"
 	"github.com/wowsims/sod/sim/core/stats"
 )
 
@@ -31,6 +32,7 @@ func (spell *Spell) NewResult(target *Unit) *SpellResult {
 	result.Damage = 0
 	result.Threat = 0
 	result.Outcome = OutcomeEmpty // for blocks
+	result.ResistanceMultiplier = 1
 	result.inUse = true
 
 	return result
@@ -225,7 +227,7 @@ func (spell *Spell) calcDamageInternal(sim *Simulation, target *Unit, baseDamage
 	result := spell.NewResult(target)
 	result.Damage = baseDamage
 
-	if sim.Log == nil {
+	if sim.Log == nil || spell.calculatingExpectedDamage {
 		result.Damage *= attackerMultiplier
 		result.applyResistances(sim, spell, isPeriodic, attackTable)
 		result.applyTargetModifiers(spell, attackTable, isPeriodic)
@@ -323,15 +325,18 @@ func (spell *Spell) CalcAndDealOutcome(sim *Simulation, target *Unit, outcomeApp
 
 // Applies the fully computed spell result to the sim.
 func (spell *Spell) dealDamageInternal(sim *Simulation, isPeriodic bool, result *SpellResult) {
+	// Targets only take damage while they are active, and until they die.
+	if result.Target.Type == EnemyUnit {
+		result.Damage = sim.Encounter.Targets[result.Target.Index].takeDamage(sim, result.Damage)
+	}
+
 	if sim.CurrentTime >= 0 {
 		spell.SpellMetrics[result.Target.UnitIndex].TotalDamage += result.Damage
 		spell.SpellMetrics[result.Target.UnitIndex].TotalThreat += result.Threat
-	}
-
-	// Mark total damage done in raid so far for health based fights.
-	// Don't include damage done by EnemyUnits to Players
-	if result.Target.Type == EnemyUnit {
-		sim.Encounter.DamageTaken += result.Damage
+		spell.addMitigationMetrics(result)
+		if sim.timelineEnabled && spell.Unit.IsOpponent(result.Target) {
+			spell.Unit.Metrics.timeline.addDamage(sim, result.Damage)
+		}
 	}
 
 	if sim.Log != nil {
@@ -341,6 +346,9 @@ func (spell *Spell) dealDamageInternal(sim *Simulation, isPeriodic bool, result
 			spell.Unit.Log(sim, "%s %s %s. (Threat: %0.3f)", result.Target.LogLabel(), spell.ActionID, result.DamageString(), result.Threat)
 		}
 	}
+	if sim.combatLogEnabled {
+		sim.logSpellResult(proto.CombatLogEvent_Damage, spell, isPeriodic, result)
+	}
 
 	if !spell.Flags.Matches(SpellFlagNoOnDamageDealt) {
 		if isPeriodic {
@@ -354,6 +362,31 @@ func (spell *Spell) dealDamageInternal(sim *Simulation, isPeriodic bool, result
 
 	spell.DisposeResult(result)
 }
+
+// Tracks partial resists and the damage prevented by armor or resistances. The damage prevented
+// is what the final damage would have been without the resistance multiplier.
+func (spell *Spell) addMitigationMetrics(result *SpellResult) {
+	if !result.Landed() || result.ResistanceMultiplier <= 0 || result.ResistanceMultiplier >= 1 {
+		return
+	}
+
+	spellMetrics := &spell.SpellMetrics[result.Target.UnitIndex]
+	mitigated := result.Damage/result.ResistanceMultiplier - result.Damage
+
+	if result.Outcome.Matches(OutcomePartial) {
+		if result.Outcome.Matches(OutcomePartial1_4) {
+			spellMetrics.PartialResists1_4++
+		} else if result.Outcome.Matches(OutcomePartial2_4) {
+			spellMetrics.PartialResists2_4++
+		} else {
+			spellMetrics.PartialResists3_4++
+		}
+		spellMetrics.TotalResistMitigated += mitigated
+	} else {
+		spellMetrics.TotalArmorMitigated += mitigated
+	}
+}
+
 func (spell *Spell) DealDamage(sim *Simulation, result *SpellResult) {
 	spell.dealDamageInternal(sim, false, result)
 }
@@ -443,6 +476,9 @@ func (spell *Spell) dealHealingInternal(sim *Simulation, isPeriodic bool, result
 			spell.Unit.Log(sim, "%s %s %s. (Threat: %0.3f)", result.Target.LogLabel(), spell.ActionID, result.HealingString(), result.Threat)
 		}
 	}
+	if sim.combatLogEnabled {
+		sim.logSpellResult(proto.CombatLogEvent_Healing, spell, isPeriodic, result)
+	}
 
 	if isPeriodic {
 		spell.Unit.OnPeriodicHealDealt(sim, spell, result)
@@ -14,19 +14,35 @@ type Encounter struct {
 	Targets           []*Target
 	TargetUnits       []*Unit
 
+	// Targets that are currently in the fight, in the same order as Targets. Adds are only
+	// active between spawning and despawning, and targets stop being active when they die.
+	ActiveTargets     []*Target
+	ActiveTargetUnits []*Unit
+
 	ExecuteProportion_20 float64
 	ExecuteProportion_25 float64
 	ExecuteProportion_35 float64
 
 	EndFightAtHealth float64
 	// DamageTaken is used to track health fights instead of duration fights.
-	//  Once primary target has taken its health worth of damage, fight ends.
+	//  It only counts damage towards the health of targets, so overkill is excluded.
 	DamageTaken float64
 	// In health fight: set to true until we get something to base on
 	DurationIsEstimate bool
 
+	// In health fights, execute phases follow the health of the first target with health,
+	// and the fight ends once every target with health has died. Adds don't count for either.
+	executeTarget  *Target
+	targetsAlive   int
+	allTargetsDead bool
+
+	// Time until every target with health died, only recorded in health fights.
+	ttkMetrics DistributionMetrics
+
 	// Value to multiply by, for damage spells which are subject to the aoe cap.
 	aoeCapMultiplier float64
+
+	movement encounterMovement
 }
 
 func NewEncounter(options *proto.Encounter) Encounter {
@@ -40,14 +56,20 @@ func NewEncounter(options *proto.Encounter) Encounter {
 		ExecuteProportion_25: max(options.ExecuteProportion_25, 0),
 		ExecuteProportion_35: max(options.ExecuteProportion_35, 0),
 		Targets:              []*Target{},
+		ttkMetrics:           NewDistributionMetrics(),
+		movement:             newEncounterMovement(options.Movement),
 	}
 	// If UseHealth is set, we use the sum of targets health.
+	defaultHealth := false
 	if options.UseHealth {
 		for _, t := range options.Targets {
-			encounter.EndFightAtHealth += t.Stats[stats.Health]
+			if !isAdd(t) {
+				encounter.EndFightAtHealth += t.Stats[stats.Health]
+			}
 		}
 		if encounter.EndFightAtHealth == 0 {
 			encounter.EndFightAtHealth = 1 // default to something so we don't instantly end without anything.
+			defaultHealth = true
 		}
 	}
 
@@ -64,6 +86,28 @@ func NewEncounter(options *proto.Encounter) Encounter {
 		encounter.TargetUnits = append(encounter.TargetUnits, &target.Unit)
 	}
 
+	// Each target with health dies once it has taken that much damage. Adds with health always
+	// die, other targets only in health fights.
+	if defaultHealth {
+		encounter.Targets[0].stats[stats.Health] = 1
+	}
+	for _, target := range encounter.Targets {
+		if target.stats[stats.Health] <= 0 {
+			continue
+		}
+		if target.isAdd {
+			target.EnableHealthBar()
+		} else if options.UseHealth {
+			target.EnableHealthBar()
+			target.endsFight = true
+			if encounter.executeTarget == nil {
+				encounter.executeTarget = target
+			}
+		}
+	}
+	encounter.ActiveTargets = encounter.Targets
+	encounter.ActiveTargetUnits = encounter.TargetUnits
+
 	if encounter.EndFightAtHealth > 0 {
 		// Until we pre-sim set duration to 10m
 		encounter.Duration = time.Minute * 10
@@ -79,7 +123,30 @@ func (encounter *Encounter) AOECapMultiplier() float64 {
 	return encounter.aoeCapMultiplier
 }
 func (encounter *Encounter) updateAOECapMultiplier() {
-	encounter.aoeCapMultiplier = min(10/float64(len(encounter.Targets)), 1)
+	encounter.aoeCapMultiplier = min(10/float64(max(len(encounter.ActiveTargets), 1)), 1)
+}
+
+// Returns the remaining health percent of the target that decides execute phases, or 1
+// when there is none.
+func (encounter *Encounter) executeHealthPercent() float64 {
+	if encounter.executeTarget == nil {
+		return 1
+	}
+	return encounter.executeTarget.CurrentHealthPercent()
+}
+
+func (encounter *Encounter) reset(sim *Simulation) {
+	encounter.DamageTaken = 0
+	encounter.allTargetsDead = false
+	encounter.targetsAlive = 0
+	for _, target := range encounter.Targets {
+		target.Reset(sim)
+		if target.endsFight {
+			encounter.targetsAlive++
+		}
+	}
+	encounter.updateActiveTargets()
+	encounter.movement.reset(sim)
 }
 
 func (encounter *Encounter) doneIteration(sim *Simulation) {
@@ -87,12 +154,31 @@ func (encounter *Encounter) doneIteration(sim *Simulation) {
 		target := encounter.Targets[i]
 		target.doneIteration(sim)
 	}
+
+	if encounter.EndFightAtHealth > 0 {
+		// Hack because of the way DistributionMetrics does its calculations.
+		encounter.ttkMetrics.Total = sim.Duration.Seconds() * sim.Duration.Seconds()
+		encounter.ttkMetrics.doneIteration(sim)
+	}
+}
+
+func (encounter *Encounter) mergeMetrics(other *Encounter) {
+	for i, targetUnit := range encounter.TargetUnits {
+		targetUnit.mergeMetrics(other.TargetUnits[i])
+	}
+	for i, target := range encounter.Targets {
+		target.ttkMetrics.merge(&other.Targets[i].ttkMetrics)
+	}
+	encounter.ttkMetrics.merge(&other.ttkMetrics)
 }
 
 func (encounter *Encounter) GetMetricsProto() *proto.EncounterMetrics {
 	metrics := &proto.EncounterMetrics{
 		Targets: make([]*proto.UnitMetrics, len(encounter.Targets)),
 	}
+	if encounter.EndFightAtHealth > 0 {
+		metrics.Ttk = encounter.ttkMetrics.ToProto()
+	}
 
 	i := 0
 	for _, target := range encounter.Targets {
@@ -108,6 +194,18 @@ type Target struct {
 	Unit
 
 	AI TargetAI
+
+	addLifecycle
+
+	// Set for targets whose death counts towards the end of a health fight.
+	endsFight  bool
+	deathTime  time.Duration
+	ttkMetrics DistributionMetrics
+
+	// Damage taken towards the target's health since it last spawned, used to estimate when it
+	// will die.
+	lifeStart       time.Duration
+	lifeDamageTaken float64
 }
 
 func NewTarget(options *proto.Target, targetIndex int32) *Target {
@@ -130,6 +228,8 @@ func NewTarget(options *proto.Target, targetIndex int32) *Target {
 
 			StatDependencyManager: stats.NewStatDependencyManager(),
 		},
+		addLifecycle: newAddLifecycle(options),
+		ttkMetrics:   NewDistributionMetrics(),
 	}
 	defaultRaidBossLevel := int32(CharacterMaxLevel + 3)
 	target.GCD = target.NewTimer()
@@ -143,8 +243,9 @@ func NewTarget(options *proto.Target, targetIndex int32) *Target {
 	target.PseudoStats.InFrontOfTarget = true
 	target.PseudoStats.DamageSpread = options.DamageSpread
 
-	preset := GetPresetTargetWithID(options.Id)
-	if preset != nil && preset.AI != nil {
+	if options.Script != nil {
+		target.AI = newScriptedTargetAI(options.Script)
+	} else if preset := GetPresetTargetWithID(options.Id); preset != nil && preset.AI != nil {
 		target.AI = preset.AI()
 	}
 
@@ -154,17 +255,128 @@ func NewTarget(options *proto.Target, targetIndex int32) *Target {
 func (target *Target) Reset(sim *Simulation) {
 	target.Unit.reset(sim, nil)
 	target.SetGCDTimer(sim, 0)
+	target.resetLife(sim)
 	if target.AI != nil {
 		target.AI.Reset(sim)
 	}
+	if target.isAdd {
+		target.resetAdd(sim)
+	}
+}
+
+func (target *Target) doneIteration(sim *Simulation) {
+	target.Unit.doneIteration(sim)
+
+	if target.endsFight {
+		// Hack because of the way DistributionMetrics does its calculations.
+		target.ttkMetrics.Total = target.deathTime.Seconds() * sim.Duration.Seconds()
+		target.ttkMetrics.doneIteration(sim)
+	}
+}
+
+// Removes damage from the health of the target, if it has any, and returns the part of it that
+// the target took. Inactive targets take no damage, and damage past a target's death is discarded.
+func (target *Target) takeDamage(sim *Simulation, damage float64) float64 {
+	if !target.IsEnabled() {
+		return 0
+	}
+	if damage <= 0 {
+		return damage
+	}
+
+	if !target.HasHealthBar() {
+		return damage
+	}
+
+	damage = min(damage, target.CurrentHealth())
+	if target.endsFight {
+		sim.Encounter.DamageTaken += damage
+	}
+	target.lifeDamageTaken += damage
+	target.RemoveHealth(sim, damage)
+	if target.CurrentHealth() <= 0 {
+		target.die(sim)
+	}
+	return damage
+}
+
+func (target *Target) die(sim *Simulation) {
+	if sim.Log != nil {
+		target.Log(sim, "Died")
+	}
+	target.logLifecycleEvent(sim, proto.CombatLogEvent_Death)
+	target.deactivate(sim)
+
+	if target.endsFight {
+		target.deathTime = sim.CurrentTime
+		sim.Encounter.targetsAlive--
+		if sim.Encounter.targetsAlive == 0 {
+			sim.Encounter.allTargetsDead = true
+		}
+	}
+}
+
+func (target *Target) resetLife(sim *Simulation) {
+	target.lifeStart = sim.CurrentTime
+	target.lifeDamageTaken = 0
+}
+
+// Returns the remaining health percent of the target. Targets without health are assumed to lose
+// it evenly over the fight, the same way execute phases are decided.
+func (target *Target) HealthPercent(sim *Simulation) float64 {
+	if target.HasHealthBar() {
+		return target.CurrentHealthPercent()
+	}
+	return sim.GetRemainingDurationPercent()
+}
+
+// Returns the estimated time until the target is at or below the given health percent, based on
+// the damage it has taken since it spawned. This is capped at the rest of the fight, which is also
+// used until the target has taken any damage. Inactive targets return 0.
+func (target *Target) TimeToHealthPercent(sim *Simulation, percent float64) time.Duration {
+	if !target.IsEnabled() {
+		return 0
+	}
+
+	remainingDuration := sim.GetRemainingDuration()
+	if !target.HasHealthBar() {
+		healthPercent := sim.GetRemainingDurationPercent()
+		if healthPercent <= percent {
+			return 0
+		}
+		return time.Duration(float64(remainingDuration) * (healthPercent - percent) / healthPercent)
+	}
+
+	healthToLose := target.CurrentHealth() - percent*target.MaxHealth()
+	if healthToLose <= 0 {
+		return 0
+	}
+	elapsed := sim.CurrentTime - target.lifeStart
+	if target.lifeDamageTaken <= 0 || elapsed <= 0 {
+		return remainingDuration
+	}
+	// Targets don't outlive the fight, which also keeps tiny damage rates from overflowing.
+	return DurationFromSeconds(min(healthToLose*elapsed.Seconds()/target.lifeDamageTaken, remainingDuration.Seconds()))
 }
 
+// Returns the estimated time until the target dies. See TimeToHealthPercent.
+func (target *Target) TimeToDie(sim *Simulation) time.Duration {
+	return target.TimeToHealthPercent(sim, 0)
+}
+
+// Returns the next active target after this one, or this target if there is no other.
 func (target *Target) NextTarget() *Target {
-	nextIndex := target.Index + 1
-	if nextIndex >= target.Env.GetNumTargets() {
-		nextIndex = 0
+	nextTarget := target
+	for {
+		nextIndex := nextTarget.Index + 1
+		if nextIndex >= int32(len(target.Env.Encounter.Targets)) {
+			nextIndex = 0
+		}
+		nextTarget = target.Env.GetTarget(nextIndex)
+		if nextTarget == target || nextTarget.IsEnabled() {
+			return nextTarget
+		}
 	}
-	return target.Env.GetTarget(nextIndex)
 }
 
 func (target *Target) GetMetricsProto() *proto.UnitMetrics {
@@ -172,6 +384,9 @@ func (target *Target) GetMetricsProto() *proto.UnitMetrics {
 	metrics.Name = target.Label
 	metrics.UnitIndex = target.UnitIndex
 	metrics.Auras = target.auraTracker.GetMetricsProto()
+	if target.endsFight {
+		metrics.Ttk = target.ttkMetrics.ToProto()
+	}
 	return metrics
 }
 
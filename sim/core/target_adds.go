@@ -0,0 +1,184 @@
+package core
+
+import (
+	"time"
+
+	"github.com/wowsims/sod/sim/core/proto"
+)
+
+// Adds are targets that are only active for part of the fight. They spawn at their spawn time,
+// despawn after their despawn time or once their health is gone, and can respawn in waves.
+type addLifecycle struct {
+	isAdd bool
+
+	spawnTime       time.Duration
+	despawnTime     time.Duration
+	respawnInterval time.Duration
+	waves           int32
+
+	numSpawns     int32
+	despawnAction *PendingAction
+}
+
+func newAddLifecycle(options *proto.Target) addLifecycle {
+	return addLifecycle{
+		isAdd:           isAdd(options),
+		spawnTime:       DurationFromSeconds(options.SpawnTime),
+		despawnTime:     DurationFromSeconds(options.DespawnTime),
+		respawnInterval: DurationFromSeconds(options.RespawnInterval),
+		waves:           options.Waves,
+	}
+}
+
+func isAdd(options *proto.Target) bool {
+	return options.SpawnTime > 0 || options.DespawnTime > 0 || options.RespawnInterval > 0
+}
+
+func (target *Target) resetAdd(sim *Simulation) {
+	target.numSpawns = 0
+	target.despawnAction = nil
+
+	if target.spawnTime > 0 {
+		target.enabled = false
+		if target.gcdAction != nil {
+			target.CancelGCDTimer(sim)
+		}
+		target.scheduleSpawn(sim, target.spawnTime)
+	} else {
+		target.startWave(sim)
+	}
+}
+
+// Called every time the add spawns, including at the start of the fight.
+func (target *Target) startWave(sim *Simulation) {
+	target.numSpawns++
+	if target.despawnTime > 0 {
+		target.scheduleDespawn(sim)
+	}
+	if target.respawnInterval > 0 && (target.waves == 0 || target.numSpawns < target.waves) {
+		target.scheduleSpawn(sim, sim.CurrentTime+target.respawnInterval)
+	}
+}
+
+func (target *Target) scheduleSpawn(sim *Simulation, spawnAt time.Duration) {
+	sim.AddPendingAction(&PendingAction{
+		NextActionAt: spawnAt,
+		Priority:     ActionPriorityAuto,
+		OnAction: func(sim *Simulation) {
+			target.spawn(sim)
+		},
+	})
+}
+
+func (target *Target) scheduleDespawn(sim *Simulation) {
+	if target.despawnAction != nil {
+		target.despawnAction.Cancel(sim)
+	}
+	target.despawnAction = &PendingAction{
+		NextActionAt: sim.CurrentTime + target.despawnTime,
+		Priority:     ActionPriorityAuto,
+		OnAction: func(sim *Simulation) {
+			target.despawnAction = nil
+			if sim.Log != nil {
+				target.Log(sim, "Despawned")
+			}
+			target.logLifecycleEvent(sim, proto.CombatLogEvent_Despawn)
+			target.deactivate(sim)
+		},
+	}
+	sim.AddPendingAction(target.despawnAction)
+}
+
+// Spawns the add, or brings it back to full health if it is still active.
+func (target *Target) spawn(sim *Simulation) {
+	if sim.Log != nil {
+		target.Log(sim, "Spawned")
+	}
+	target.logLifecycleEvent(sim, proto.CombatLogEvent_Spawn)
+
+	target.healthBar.reset(sim)
+	target.resetLife(sim)
+	target.startWave(sim)
+	if target.enabled {
+		return
+	}
+
+	target.enabled = true
+	target.AutoAttacks.EnableAutoSwing(sim)
+	if target.gcdAction != nil {
+		target.SetGCDTimer(sim, sim.CurrentTime)
+	}
+	sim.Encounter.updateActiveTargets()
+
+	// Anyone without a target to attack picks up the new add.
+	for _, unit := range sim.Raid.AllUnits {
+		if unit.CurrentTarget != nil && unit.CurrentTarget.Type == EnemyUnit && !unit.CurrentTarget.IsEnabled() {
+			unit.CurrentTarget = &target.Unit
+		}
+	}
+}
+
+// Keeps the target out of the fight at the start of the iteration, for AIs which decide when their
+// targets join the fight with Spawn. Call from the AI's Reset.
+func (target *Target) StartDespawned(sim *Simulation) {
+	target.enabled = false
+	if target.gcdAction != nil {
+		target.CancelGCDTimer(sim)
+	}
+}
+
+// Brings the target into the fight, for AIs which spawn targets themselves.
+func (target *Target) Spawn(sim *Simulation) {
+	target.spawn(sim)
+}
+
+// Removes the target from the fight, until it spawns again.
+func (target *Target) deactivate(sim *Simulation) {
+	if target.despawnAction != nil {
+		target.despawnAction.Cancel(sim)
+		target.despawnAction = nil
+	}
+
+	target.enabled = false
+	target.AutoAttacks.CancelAutoSwing(sim)
+	if target.gcdAction != nil {
+		target.CancelGCDTimer(sim)
+	}
+	sim.Encounter.updateActiveTargets()
+
+	// Anyone still attacking the target moves on to the next active one.
+	if nextTarget := target.NextTarget(); nextTarget != target {
+		for _, unit := range sim.Raid.AllUnits {
+			if unit.CurrentTarget == &target.Unit {
+				unit.CurrentTarget = &nextTarget.Unit
+			}
+		}
+	}
+}
+
+// Rebuilds the list of active targets. A new list is made every time, so that AoE effects which
+// are looping over the old one when a target spawns or dies are unaffected.
+func (encounter *Encounter) updateActiveTargets() {
+	activeTargets := make([]*Target, 0, len(encounter.Targets))
+	activeTargetUnits := make([]*Unit, 0, len(encounter.Targets))
+	for _, target := range encounter.Targets {
+		if target.enabled {
+			activeTargets = append(activeTargets, target)
+			activeTargetUnits = append(activeTargetUnits, &target.Unit)
+		}
+	}
+	encounter.ActiveTargets = activeTargets
+	encounter.ActiveTargetUnits = activeTargetUnits
+	encounter.updateAOECapMultiplier()
+}
+
+func (target *Target) logLifecycleEvent(sim *Simulation, eventType proto.CombatLogEvent_Type) {
+	if sim.combatLogEnabled {
+		unitRef := target.Env.GetUnitReference(&target.Unit)
+		sim.logCombatEvent(&proto.CombatLogEvent{
+			Type:   eventType,
+			Source: unitRef,
+			Target: unitRef,
+		})
+	}
+}
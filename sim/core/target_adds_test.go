@@ -0,0 +1,57 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/wowsims/sod/sim/core/proto"
+	"github.com/wowsims/sod/sim/core/stats"
+)
+
+func TestAddWaves(t *testing.T) {
+	rsr := fakeCasterRequest(t, "cast_spell(spell:10197)\ncast_spell(spell:10149)\n", &proto.SimOptions{
+		Iterations:          10,
+		RandomSeed:          101,
+		DebugFirstIteration: true,
+		CombatLog:           true,
+	})
+	add := &proto.Target{
+		Level:           63,
+		MobType:         proto.MobType_MobTypeDemon,
+		Stats:           stats.Stats{stats.Health: 3000}.ToFloatArray(),
+		DespawnTime:     15,
+		RespawnInterval: 30,
+		Waves:           2,
+	}
+	rsr.Encounter.Targets = append([]*proto.Target{add}, rsr.Encounter.Targets...)
+	result := runFakeCasterSim(t, rsr)
+
+	// The player starts on the add and moves on to the boss once it is gone. The second wave
+	// is active from 30s to 45s, or until it dies.
+	counts := map[proto.CombatLogEvent_Type]int{}
+	addDamage := 0.0
+	bossDamage := 0.0
+	for _, event := range result.CombatLog {
+		counts[event.Type]++
+		if event.Type != proto.CombatLogEvent_Damage || event.Damage == 0 {
+			continue
+		}
+		if event.Target.Index == 0 {
+			if (event.Timestamp > 15 && event.Timestamp < 30) || event.Timestamp > 45 {
+				t.Errorf("Add took %f damage at %fs, while it was despawned", event.Damage, event.Timestamp)
+			}
+			addDamage += event.Damage
+		} else {
+			bossDamage += event.Damage
+		}
+	}
+	if addDamage == 0 || addDamage > 2*add.Stats[stats.Health] {
+		t.Errorf("Add took %f damage, expected some but no more than its health in both waves", addDamage)
+	}
+	if bossDamage == 0 {
+		t.Errorf("Player never switched to the boss")
+	}
+	if counts[proto.CombatLogEvent_Spawn] != 1 || counts[proto.CombatLogEvent_Death]+counts[proto.CombatLogEvent_Despawn] != 2 {
+		t.Errorf("Got %d spawns, %d deaths and %d despawns, expected 1 spawn and 2 deaths or despawns",
+			counts[proto.CombatLogEvent_Spawn], counts[proto.CombatLogEvent_Death], counts[proto.CombatLogEvent_Despawn])
+	}
+}
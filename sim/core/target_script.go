@@ -0,0 +1,391 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/wowsims/sod/sim/core/proto"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Scripted targets run the phases and abilities described in their config instead of a Go AI, so
+// new encounters can be written as data. Phases run in order, each starting once its trigger fires,
+// and only the abilities of the current phase are used.
+
+type scriptedAbility struct {
+	config   *proto.TargetScriptAbility
+	spell    *Spell
+	cooldown time.Duration
+
+	// Earliest time the ability can be used again in the current phase, or NeverExpires once an
+	// ability without a cooldown has been used.
+	readyAt time.Duration
+}
+
+type scriptedPhase struct {
+	config    *proto.TargetScriptPhase
+	abilities []*scriptedAbility
+}
+
+type scriptedTargetAI struct {
+	target *Target
+	phases []*scriptedPhase
+
+	phaseIndex int
+	phaseStart time.Duration
+
+	// Immunity windows can overlap with each other and with damage taken modifiers, so the
+	// multiplier they replace is kept aside until the last one ends.
+	immunities            int
+	savedDamageTakenMulti float64
+}
+
+func newScriptedTargetAI(script *proto.TargetScript) TargetAI {
+	ai := &scriptedTargetAI{}
+	for _, phaseConfig := range script.Phases {
+		phase := &scriptedPhase{config: phaseConfig}
+		for _, abilityConfig := range phaseConfig.Abilities {
+			phase.abilities = append(phase.abilities, &scriptedAbility{
+				config:   abilityConfig,
+				cooldown: DurationFromSeconds(abilityConfig.Cooldown),
+			})
+		}
+		ai.phases = append(ai.phases, phase)
+	}
+	return ai
+}
+
+func (ai *scriptedTargetAI) Initialize(target *Target, _ *proto.Target) {
+	ai.target = target
+
+	tag := int32(0)
+	for _, phase := range ai.phases {
+		for _, ability := range phase.abilities {
+			tag++
+			ability.spell = ai.registerAbilitySpell(ability.config, tag)
+		}
+	}
+}
+
+func (ai *scriptedTargetAI) Reset(sim *Simulation) {
+	ai.immunities = 0
+	ai.startPhase(sim, 0)
+}
+
+func (ai *scriptedTargetAI) startPhase(sim *Simulation, phaseIndex int) {
+	ai.phaseIndex = phaseIndex
+	ai.phaseStart = sim.CurrentTime
+	if phaseIndex >= len(ai.phases) {
+		return
+	}
+
+	phase := ai.phases[phaseIndex]
+	if sim.Log != nil && phase.config.Name != "" {
+		ai.target.Log(sim, "Starting phase %s", phase.config.Name)
+	}
+	for _, ability := range phase.abilities {
+		ability.readyAt = 0
+	}
+}
+
+// Returns whether the trigger has fired, given the time since the start of the phase it belongs to.
+func (ai *scriptedTargetAI) triggerMet(sim *Simulation, trigger *proto.TargetScriptTrigger, elapsed time.Duration) bool {
+	if trigger == nil || (trigger.Time <= 0 && trigger.HealthPercent <= 0) {
+		return true
+	}
+	if trigger.Time > 0 && elapsed >= DurationFromSeconds(trigger.Time) {
+		return true
+	}
+	return trigger.HealthPercent > 0 && ai.target.HealthPercent(sim)*100 <= trigger.HealthPercent
+}
+
+func (ai *scriptedTargetAI) ExecuteCustomRotation(sim *Simulation) {
+	for ai.phaseIndex+1 < len(ai.phases) && ai.triggerMet(sim, ai.phases[ai.phaseIndex+1].config.Trigger, sim.CurrentTime-ai.phaseStart) {
+		ai.startPhase(sim, ai.phaseIndex+1)
+	}
+	if ai.phaseIndex >= len(ai.phases) {
+		return
+	}
+
+	for _, ability := range ai.phases[ai.phaseIndex].abilities {
+		if sim.CurrentTime < ability.readyAt || !ai.triggerMet(sim, ability.config.Trigger, sim.CurrentTime-ai.phaseStart) {
+			continue
+		}
+
+		ability.spell.Cast(sim, &ai.target.Unit)
+		if ability.cooldown > 0 {
+			ability.readyAt = sim.CurrentTime + ability.cooldown
+		} else {
+			ability.readyAt = NeverExpires
+		}
+	}
+}
+
+func (ai *scriptedTargetAI) registerAbilitySpell(config *proto.TargetScriptAbility, tag int32) *Spell {
+	// Tagged so abilities sharing a spell ID, e.g. in different phases, get separate metrics.
+	spellConfig := SpellConfig{
+		ActionID: ActionID{SpellID: config.SpellId, Tag: tag},
+		Flags:    SpellFlagNoOnCastComplete,
+	}
+
+	switch effect := config.Effect.(type) {
+	case *proto.TargetScriptAbility_Melee:
+		spellConfig.SpellSchool = SpellSchoolFromProto(effect.Melee.School)
+		spellConfig.ProcMask = ProcMaskMeleeMHSpecial
+		spellConfig.Flags |= SpellFlagMeleeMetrics
+		spellConfig.DamageMultiplier = 1
+		spellConfig.ApplyEffects = func(sim *Simulation, _ *Unit, spell *Spell) {
+			if tank := ai.target.CurrentTarget; tank != nil {
+				spell.CalcAndDealDamage(sim, tank, rollScriptedDamage(sim, effect.Melee), spell.OutcomeEnemyMeleeWhite)
+			}
+		}
+	case *proto.TargetScriptAbility_Spell:
+		spellConfig.SpellSchool = SpellSchoolFromProto(effect.Spell.School)
+		spellConfig.ProcMask = ProcMaskSpellDamage
+		spellConfig.DamageMultiplier = 1
+		spellConfig.ApplyEffects = func(sim *Simulation, _ *Unit, spell *Spell) {
+			target := ai.target.CurrentTarget
+			if effect.Spell.RandomPlayer {
+				target = ai.randomPlayer(sim, config.Name)
+			}
+			if target == nil {
+				return
+			}
+			spell.CalcAndDealDamage(sim, target, rollScriptedDamage(sim, effect.Spell), spell.OutcomeMagicHit)
+		}
+	case *proto.TargetScriptAbility_RaidDamage:
+		spellConfig.SpellSchool = SpellSchoolFromProto(effect.RaidDamage.School)
+		spellConfig.ProcMask = ProcMaskSpellDamage
+		spellConfig.DamageMultiplier = 1
+		spellConfig.ApplyEffects = func(sim *Simulation, _ *Unit, spell *Spell) {
+			for _, unit := range sim.Raid.AllPlayerUnits {
+				spell.CalcAndDealDamage(sim, unit, rollScriptedDamage(sim, effect.RaidDamage), spell.OutcomeMagicHit)
+			}
+		}
+	case *proto.TargetScriptAbility_TankDebuff:
+		aura := ai.registerTankDebuff(config, effect.TankDebuff, spellConfig.ActionID)
+		spellConfig.ApplyEffects = func(sim *Simulation, _ *Unit, _ *Spell) {
+			if aura == nil {
+				return
+			}
+			aura.Activate(sim)
+			aura.AddStack(sim)
+		}
+	case *proto.TargetScriptAbility_DamageTakenModifier:
+		multiplier := effect.DamageTakenModifier.Multiplier
+		if multiplier <= 0 {
+			panic(fmt.Sprintf("[USER_ERROR] Scripted ability %q of %s must have a positive damage taken multiplier, use an immunity to prevent all damage", config.Name, ai.target.Label))
+		}
+		aura := ai.target.RegisterAura(Aura{
+			ActionID: spellConfig.ActionID,
+			Label:    scriptedAuraLabel(config),
+			Duration: scriptedWindowDuration(effect.DamageTakenModifier.Duration),
+			OnGain: func(aura *Aura, sim *Simulation) {
+				ai.multiplyDamageTaken(multiplier)
+			},
+			OnExpire: func(aura *Aura, sim *Simulation) {
+				ai.multiplyDamageTaken(1 / multiplier)
+			},
+		})
+		spellConfig.ApplyEffects = func(sim *Simulation, _ *Unit, _ *Spell) {
+			aura.Activate(sim)
+		}
+	case *proto.TargetScriptAbility_Immunity:
+		aura := ai.target.RegisterAura(Aura{
+			ActionID: spellConfig.ActionID,
+			Label:    scriptedAuraLabel(config),
+			Duration: scriptedWindowDuration(effect.Immunity.Duration),
+			OnGain: func(aura *Aura, sim *Simulation) {
+				if ai.immunities == 0 {
+					ai.savedDamageTakenMulti = aura.Unit.PseudoStats.DamageTakenMultiplier
+					aura.Unit.PseudoStats.DamageTakenMultiplier = 0
+				}
+				ai.immunities++
+			},
+			OnExpire: func(aura *Aura, sim *Simulation) {
+				ai.immunities--
+				if ai.immunities == 0 {
+					aura.Unit.PseudoStats.DamageTakenMultiplier = ai.savedDamageTakenMulti
+				}
+			},
+		})
+		spellConfig.ApplyEffects = func(sim *Simulation, _ *Unit, _ *Spell) {
+			aura.Activate(sim)
+		}
+	case *proto.TargetScriptAbility_Movement:
+		duration := DurationFromSeconds(effect.Movement.Duration)
+		spellConfig.ApplyEffects = func(sim *Simulation, _ *Unit, _ *Spell) {
+			if effect.Movement.RandomPlayer {
+				if unit := ai.randomPlayer(sim, config.Name); unit != nil {
+					unit.MoveFor(duration, sim)
+				}
+				return
+			}
+			for _, unit := range sim.Raid.AllPlayerUnits {
+				unit.MoveFor(duration, sim)
+			}
+		}
+	case *proto.TargetScriptAbility_TargetSwap:
+		duration := DurationFromSeconds(effect.TargetSwap.Duration)
+		spellConfig.ApplyEffects = func(sim *Simulation, _ *Unit, _ *Spell) {
+			ai.swapTargets(sim, duration, effect.TargetSwap.RandomPlayer, config.Name)
+		}
+	default:
+		panic(fmt.Sprintf("[USER_ERROR] Scripted ability %q of %s has no effect", config.Name, ai.target.Label))
+	}
+
+	return ai.target.RegisterSpell(spellConfig)
+}
+
+// Registers the debuff on the target's tank, as the tank doesn't change during the fight. Returns
+// nil if the target has no tank.
+func (ai *scriptedTargetAI) registerTankDebuff(config *proto.TargetScriptAbility, debuff *proto.TargetScriptDebuff, actionID ActionID) *Aura {
+	tank := ai.target.CurrentTarget
+	if tank == nil {
+		return nil
+	}
+
+	label := debuff.Label
+	if label == "" {
+		label = scriptedAuraLabel(config)
+	}
+	damageTakenPerStack := debuff.DamageTakenPerStack
+	return tank.RegisterAura(Aura{
+		ActionID: actionID,
+		// Several targets can debuff the same tank.
+		Label:     fmt.Sprintf("%s-%d", label, ai.target.Index),
+		Duration:  scriptedWindowDuration(debuff.Duration),
+		MaxStacks: max(1, debuff.MaxStacks),
+		OnStacksChange: func(aura *Aura, sim *Simulation, oldStacks int32, newStacks int32) {
+			aura.Unit.PseudoStats.DamageTakenMultiplier *= (1 + damageTakenPerStack*float64(newStacks)) / (1 + damageTakenPerStack*float64(oldStacks))
+		},
+	})
+}
+
+func (ai *scriptedTargetAI) multiplyDamageTaken(multiplier float64) {
+	if ai.immunities > 0 {
+		ai.savedDamageTakenMulti *= multiplier
+	} else {
+		ai.target.PseudoStats.DamageTakenMultiplier *= multiplier
+	}
+}
+
+// Moves players attacking this target, or one random player among them, to the next target for
+// the duration of the window.
+func (ai *scriptedTargetAI) swapTargets(sim *Simulation, duration time.Duration, randomPlayer bool, label string) {
+	nextTarget := ai.target.NextTarget()
+	if nextTarget == ai.target {
+		return
+	}
+
+	var swapped []*Unit
+	for _, unit := range sim.Raid.AllPlayerUnits {
+		if unit.CurrentTarget == &ai.target.Unit {
+			swapped = append(swapped, unit)
+		}
+	}
+	if randomPlayer && len(swapped) > 0 {
+		idx := int(sim.RandomFloat(label) * float64(len(swapped)))
+		swapped = []*Unit{swapped[idx]}
+	}
+	for _, unit := range swapped {
+		unit.CurrentTarget = &nextTarget.Unit
+	}
+
+	StartDelayedAction(sim, DelayedActionOptions{
+		DoAt: sim.CurrentTime + duration,
+		OnAction: func(sim *Simulation) {
+			if !ai.target.IsEnabled() {
+				return
+			}
+			for _, unit := range swapped {
+				if unit.CurrentTarget == &nextTarget.Unit {
+					unit.CurrentTarget = &ai.target.Unit
+				}
+			}
+		},
+	})
+}
+
+func (ai *scriptedTargetAI) randomPlayer(sim *Simulation, label string) *Unit {
+	players := sim.Raid.AllPlayerUnits
+	if len(players) == 0 {
+		return nil
+	}
+	return players[int(sim.RandomFloat(label)*float64(len(players)))]
+}
+
+func rollScriptedDamage(sim *Simulation, damage *proto.TargetScriptDamage) float64 {
+	return sim.Roll(damage.MinDamage, max(damage.MinDamage, damage.MaxDamage))
+}
+
+func scriptedAuraLabel(config *proto.TargetScriptAbility) string {
+	if config.Name != "" {
+		return config.Name
+	}
+	return fmt.Sprintf("Scripted Ability %d", config.SpellId)
+}
+
+// Windows without a duration last until the end of the fight.
+func scriptedWindowDuration(seconds float64) time.Duration {
+	if seconds <= 0 {
+		return NeverExpires
+	}
+	return DurationFromSeconds(seconds)
+}
+
+// Parses a JSON PresetEncounter, checking its paths. Each target path must end with the target's
+// name, e.g. "SoD/Scripted Boss", and the first target must have the encounter's prefix.
+func ParsePresetEncounterJSON(data []byte) (*proto.PresetEncounter, error) {
+	encounter := &proto.PresetEncounter{}
+	if err := protojson.Unmarshal(data, encounter); err != nil {
+		return nil, err
+	}
+
+	idx := strings.LastIndex(encounter.Path, "/")
+	if idx < 0 || idx == len(encounter.Path)-1 {
+		return nil, fmt.Errorf("encounter path %q must be in the form <prefix>/<name>", encounter.Path)
+	}
+	encounterPrefix := encounter.Path[:idx]
+	if len(encounter.Targets) == 0 {
+		return nil, errors.New("encounter " + encounter.Path + " has no targets")
+	}
+
+	for i, presetTarget := range encounter.Targets {
+		idx := strings.LastIndex(presetTarget.Path, "/")
+		if presetTarget.Target == nil || idx < 0 || presetTarget.Path[idx+1:] != presetTarget.Target.Name {
+			return nil, fmt.Errorf("target path %q must be in the form <prefix>/<target name>", presetTarget.Path)
+		}
+		if i == 0 && presetTarget.Path[:idx] != encounterPrefix {
+			return nil, fmt.Errorf("encounter %s must have the same prefix as its first target", encounter.Path)
+		}
+	}
+	return encounter, nil
+}
+
+// Registers an encounter from a JSON PresetEncounter, along with any of its targets which aren't
+// presets yet. Presets are only listed in the UI once they are in the generated database, so a
+// script can also be simmed directly with the CLI's --encounter flag, without rebuilding.
+func AddPresetEncounterJSON(data []byte) error {
+	encounter, err := ParsePresetEncounterJSON(data)
+	if err != nil {
+		return err
+	}
+
+	paths := make([]string, len(encounter.Targets))
+	for i, presetTarget := range encounter.Targets {
+		if GetPresetTargetWithPath(presetTarget.Path) == nil {
+			idx := strings.LastIndex(presetTarget.Path, "/")
+			AddPresetTarget(&PresetTarget{
+				PathPrefix: presetTarget.Path[:idx],
+				Config:     presetTarget.Target,
+			})
+		}
+		paths[i] = presetTarget.Path
+	}
+
+	AddPresetEncounter(encounter.Path[strings.LastIndex(encounter.Path, "/")+1:], paths)
+	return nil
+}
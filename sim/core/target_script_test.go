@@ -0,0 +1,121 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/wowsims/sod/sim/core/proto"
+)
+
+func TestTargetScriptAbilities(t *testing.T) {
+	simMetrics := func(abilities ...*proto.TargetScriptAbility) (float64, float64) {
+		rsr := fakeCasterRequest(t, "cast_spell(spell:10149)\n", &proto.SimOptions{
+			Iterations: 20,
+			RandomSeed: 101,
+		})
+		if len(abilities) > 0 {
+			rsr.Encounter.Targets[0].Script = &proto.TargetScript{
+				Phases: []*proto.TargetScriptPhase{{Name: "Phase 1", Abilities: abilities}},
+			}
+		}
+		result := runFakeCasterSim(t, rsr)
+		return result.RaidMetrics.Dps.Avg, result.EncounterMetrics.Targets[0].Dps.Avg
+	}
+	window := func(seconds float64) *proto.TargetScriptWindow {
+		return &proto.TargetScriptWindow{Duration: seconds}
+	}
+
+	baseDps, _ := simMetrics()
+	immuneDps, _ := simMetrics(&proto.TargetScriptAbility{
+		Name:   "Divine Shield",
+		Effect: &proto.TargetScriptAbility_Immunity{Immunity: window(20)},
+	})
+	vulnerableDps, _ := simMetrics(&proto.TargetScriptAbility{
+		Name:   "Vulnerable",
+		Effect: &proto.TargetScriptAbility_DamageTakenModifier{DamageTakenModifier: &proto.TargetScriptModifier{Multiplier: 1.5}},
+	})
+	movingDps, _ := simMetrics(&proto.TargetScriptAbility{
+		Name:     "Flame Wave",
+		Trigger:  &proto.TargetScriptTrigger{Time: 10},
+		Cooldown: 20,
+		Effect:   &proto.TargetScriptAbility_Movement{Movement: window(5)},
+	})
+	_, raidDamageDps := simMetrics(&proto.TargetScriptAbility{
+		Name:     "Shadow Volley",
+		SpellId:  25586,
+		Cooldown: 10,
+		Effect:   &proto.TargetScriptAbility_RaidDamage{RaidDamage: &proto.TargetScriptDamage{School: proto.SpellSchool_SpellSchoolShadow, MinDamage: 1000, MaxDamage: 1000}},
+	})
+	if immuneDps >= baseDps {
+		t.Errorf("Expected immunity to lower dps, got %f vs %f", immuneDps, baseDps)
+	}
+	if vulnerableDps <= baseDps {
+		t.Errorf("Expected the damage taken modifier to raise dps, got %f vs %f", vulnerableDps, baseDps)
+	}
+	if movingDps >= baseDps {
+		t.Errorf("Expected movement to lower dps, got %f vs %f", movingDps, baseDps)
+	}
+	if raidDamageDps <= 0 {
+		t.Errorf("Expected the raid damage to hit the player")
+	}
+}
+
+func TestTargetScriptPhases(t *testing.T) {
+	// The second phase starts at 50% health, which without a health bar is half way through the
+	// fight, and makes the target immune for the rest of it.
+	rsr := fakeCasterRequest(t, "cast_spell(spell:10149)\n", &proto.SimOptions{
+		Iterations:          1,
+		RandomSeed:          101,
+		DebugFirstIteration: true,
+		CombatLog:           true,
+	})
+	rsr.Encounter.DurationVariation = 0
+	rsr.Encounter.Targets[0].Script = &proto.TargetScript{
+		Phases: []*proto.TargetScriptPhase{
+			{Name: "Phase 1"},
+			{
+				Name:    "Phase 2",
+				Trigger: &proto.TargetScriptTrigger{HealthPercent: 50},
+				Abilities: []*proto.TargetScriptAbility{{
+					Name:   "Ice Block",
+					Effect: &proto.TargetScriptAbility_Immunity{Immunity: &proto.TargetScriptWindow{}},
+				}},
+			},
+		},
+	}
+	result := runFakeCasterSim(t, rsr)
+	damaged := false
+	for _, event := range result.CombatLog {
+		if event.Type != proto.CombatLogEvent_Damage || event.Target.Index != 0 || event.Damage <= 0 {
+			continue
+		}
+		damaged = true
+		if event.Timestamp > 30.5 {
+			t.Errorf("Target took %f damage at %fs, after becoming immune", event.Damage, event.Timestamp)
+			break
+		}
+	}
+	if !damaged {
+		t.Errorf("Target took no damage before becoming immune")
+	}
+
+	// Taking no damage at all is what immunities are for.
+	rsr.Encounter.Targets[0].Script.Phases[1].Abilities[0].Effect = &proto.TargetScriptAbility_DamageTakenModifier{DamageTakenModifier: &proto.TargetScriptModifier{Multiplier: 0}}
+	if result := RunRaidSim(rsr); !strings.Contains(result.ErrorResult, "positive damage taken multiplier") {
+		t.Errorf("Expected a zero damage taken multiplier to be rejected, got %q", result.ErrorResult)
+	}
+}
+
+func TestParsePresetEncounterJSON(t *testing.T) {
+	encounter, err := ParsePresetEncounterJSON([]byte(`{"path": "SoD/Test", "targets": [{"path": "SoD/Dummy", "target": {"name": "Dummy"}}]}`))
+	if err != nil {
+		t.Fatalf("Failed to parse encounter: %s", err)
+	}
+	if encounter.Path != "SoD/Test" || len(encounter.Targets) != 1 || encounter.Targets[0].Target.Name != "Dummy" {
+		t.Errorf("Unexpected encounter: %v", encounter)
+	}
+
+	if _, err := ParsePresetEncounterJSON([]byte(`{"path": "SoD/Bad", "targets": [{"path": "SoD/Other Name", "target": {"name": "Bad"}}]}`)); err == nil {
+		t.Errorf("Expected a target path not ending with its name to be rejected")
+	}
+}
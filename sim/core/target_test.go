@@ -0,0 +1,109 @@
+package core
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/wowsims/sod/sim/core/proto"
+	"github.com/wowsims/sod/sim/core/stats"
+)
+
+func TestTimeToHealthPercent(t *testing.T) {
+	rsr := fakeCasterRequest(t, "cast_spell(spell:10149)\n", &proto.SimOptions{RandomSeed: 101})
+	rsr.Encounter.Duration = 100
+	rsr.Encounter.DurationVariation = 0
+	add := &proto.Target{Name: "add", Level: 63, DespawnTime: 1000, Stats: stats.Stats{stats.Health: 10000}.ToFloatArray()}
+	rsr.Encounter.Targets = append([]*proto.Target{add}, rsr.Encounter.Targets...)
+	sim := NewSim(rsr)
+	sim.reset()
+	addTarget, boss := sim.Encounter.Targets[0], sim.Encounter.Targets[1]
+
+	// Until the add takes damage, it can only be assumed to live until the end of the fight.
+	sim.CurrentTime = time.Second * 10
+	if ttd := addTarget.TimeToDie(sim); ttd != time.Second*90 {
+		t.Errorf("Add without damage taken has %s to live, expected 1m30s", ttd)
+	}
+
+	// The add has lost 2000 health in 10 seconds, so it loses another 2000 in 10 more seconds.
+	addTarget.takeDamage(sim, 2000)
+	for _, tc := range []struct {
+		target   *Target
+		percent  float64
+		expected time.Duration
+	}{
+		{addTarget, 0.6, time.Second * 10},
+		{addTarget, 0, time.Second * 40},
+		{addTarget, 0.9, 0},
+		// Without health, targets lose it evenly over the fight.
+		{boss, 0.45, time.Second * 45},
+		{boss, 0, time.Second * 90},
+		{boss, 0.95, 0},
+	} {
+		if ttl := tc.target.TimeToHealthPercent(sim, tc.percent); ttl != tc.expected {
+			t.Errorf("%s has %s until %.0f%% health, expected %s", tc.target.Label, ttl, tc.percent*100, tc.expected)
+		}
+	}
+
+	// Slow damage doesn't make targets outlive the fight.
+	sim.CurrentTime = time.Second * 80
+	if ttd := addTarget.TimeToDie(sim); ttd != time.Second*20 {
+		t.Errorf("Add has %s to live at 80s, expected the 20s left in the fight", ttd)
+	}
+
+	addTarget.takeDamage(sim, 8000)
+	if ttd := addTarget.TimeToDie(sim); ttd != 0 || addTarget.IsEnabled() {
+		t.Errorf("Dead add has %s to live, expected 0", ttd)
+	}
+}
+
+func TestHealthFight(t *testing.T) {
+	rsr := fakeCasterRequest(t, "cast_spell(spell:10197)\ncast_spell(spell:10149)\n", &proto.SimOptions{
+		Iterations:          20,
+		RandomSeed:          101,
+		SaveAllValues:       true,
+		DebugFirstIteration: true,
+		CombatLog:           true,
+	})
+	targetHealth := []float64{20000, 10000}
+	rsr.Encounter.UseHealth = true
+	rsr.Encounter.Targets = nil
+	for _, health := range targetHealth {
+		rsr.Encounter.Targets = append(rsr.Encounter.Targets, &proto.Target{
+			Level:   63,
+			MobType: proto.MobType_MobTypeDemon,
+			Stats:   stats.Stats{stats.Health: health}.ToFloatArray(),
+		})
+	}
+	result := runFakeCasterSim(t, rsr)
+
+	ttk := result.EncounterMetrics.Ttk
+	if ttk == nil || len(ttk.AllValues) != int(rsr.SimOptions.Iterations) {
+		t.Fatalf("Expected a time to kill for each iteration, got %v", ttk)
+	}
+	if math.Abs(ttk.Avg-result.AvgIterationDuration) > 1e-6 {
+		t.Errorf("Average time to kill %f differs from average iteration duration %f", ttk.Avg, result.AvgIterationDuration)
+	}
+
+	firstTtk := result.EncounterMetrics.Targets[0].Ttk.AllValues
+	lastTtk := result.EncounterMetrics.Targets[1].Ttk.AllValues
+	dps := result.RaidMetrics.Dps.AllValues
+	for i, duration := range ttk.AllValues {
+		// The player only moves on to the second target once the first one died.
+		if firstTtk[i] >= lastTtk[i] || lastTtk[i] != duration {
+			t.Errorf("Iteration %d: targets died at %f and %f, fight lasted %f", i, firstTtk[i], lastTtk[i], duration)
+		}
+		// Damage past a target's death is not counted.
+		if damage := dps[i] * duration; math.Abs(damage-(targetHealth[0]+targetHealth[1])) > 1e-3 {
+			t.Errorf("Iteration %d: dealt %f damage, expected exactly the targets' health", i, damage)
+		}
+	}
+
+	counts := map[proto.CombatLogEvent_Type]int{}
+	for _, event := range result.CombatLog {
+		counts[event.Type]++
+	}
+	if counts[proto.CombatLogEvent_ExecutePhase] != 3 || counts[proto.CombatLogEvent_Death] != 2 {
+		t.Errorf("Got %d execute phase and %d death events, expected 3 and 2", counts[proto.CombatLogEvent_ExecutePhase], counts[proto.CombatLogEvent_Death])
+	}
+}
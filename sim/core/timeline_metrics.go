@@ -0,0 +1,258 @@
+package core
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/wowsims/sod/sim/core/proto"
+)
+
+// Timeline metrics record how damage, resource levels and aura uptimes change over fight time.
+// They are enabled by SimOptions.TimelineMetrics, and recorded as the sim advances, so each
+// resource level and active aura is integrated over the time it was held.
+
+// A value per timeline bin, for the current iteration and summed over all iterations.
+type timelineSeries struct {
+	iteration []float64
+	total     []exactSum
+}
+
+func (series *timelineSeries) add(bin int, value float64) {
+	if bin >= len(series.iteration) {
+		series.iteration = append(series.iteration, make([]float64, bin+1-len(series.iteration))...)
+	}
+	series.iteration[bin] += value
+}
+
+func (series *timelineSeries) growTotal(numBins int) {
+	if numBins > len(series.total) {
+		series.total = append(series.total, make([]exactSum, numBins-len(series.total))...)
+	}
+}
+
+func (series *timelineSeries) doneIteration() {
+	series.growTotal(len(series.iteration))
+	for bin, value := range series.iteration {
+		if value != 0 {
+			series.total[bin].add(value)
+			series.iteration[bin] = 0
+		}
+	}
+}
+
+func (series *timelineSeries) merge(other *timelineSeries) {
+	series.growTotal(len(other.total))
+	for bin := range other.total {
+		series.total[bin].merge(&other.total[bin])
+	}
+}
+
+// Returns the totals of each bin divided by the matching total in divisors.
+func (series *timelineSeries) averages(divisors []float64) []float64 {
+	values := make([]float64, len(divisors))
+	for bin := 0; bin < min(len(series.total), len(divisors)); bin++ {
+		if divisors[bin] > 0 {
+			values[bin] = series.total[bin].value() / divisors[bin]
+		}
+	}
+	return values
+}
+
+type timelineResource struct {
+	resourceType proto.ResourceType
+	level        func() float64
+	series       timelineSeries // Resource level multiplied by the seconds it was held.
+}
+
+type timelineMetrics struct {
+	binSize time.Duration
+
+	// Timeline of the pet's owner, which is also credited with the pet's damage.
+	owner *timelineMetrics
+
+	// End of the time recorded so far in the current iteration.
+	recordedUntil time.Duration
+
+	coverage  timelineSeries // Seconds of each bin that iterations lasted through.
+	damage    timelineSeries
+	resources []*timelineResource
+	auras     map[ActionID]*timelineSeries // Seconds each aura was active.
+}
+
+func newTimelineMetrics(unit *Unit, binSize time.Duration) *timelineMetrics {
+	timeline := &timelineMetrics{
+		binSize: binSize,
+		auras:   make(map[ActionID]*timelineSeries),
+	}
+
+	addResource := func(resourceType proto.ResourceType, level func() float64) {
+		timeline.resources = append(timeline.resources, &timelineResource{
+			resourceType: resourceType,
+			level:        level,
+		})
+	}
+	if unit.HasHealthBar() {
+		addResource(proto.ResourceType_ResourceTypeHealth, unit.CurrentHealth)
+	}
+	if unit.HasManaBar() {
+		addResource(proto.ResourceType_ResourceTypeMana, unit.CurrentMana)
+	}
+	if unit.HasRageBar() {
+		addResource(proto.ResourceType_ResourceTypeRage, unit.CurrentRage)
+	}
+	if unit.HasEnergyBar() {
+		addResource(proto.ResourceType_ResourceTypeEnergy, unit.CurrentEnergy)
+		addResource(proto.ResourceType_ResourceTypeComboPoints, func() float64 { return float64(unit.ComboPoints()) })
+	}
+	if unit.HasFocusBar() {
+		addResource(proto.ResourceType_ResourceTypeFocus, unit.CurrentFocus)
+	}
+
+	return timeline
+}
+
+// Enables timeline metrics on every unit, if requested by the sim options.
+func (sim *Simulation) setupTimelineMetrics() {
+	if !sim.Options.TimelineMetrics {
+		return
+	}
+	sim.timelineEnabled = true
+
+	binSize := time.Second
+	if sim.Options.TimelineBinSize > 0 {
+		binSize = DurationFromSeconds(sim.Options.TimelineBinSize)
+	}
+
+	for _, unit := range sim.AllUnits {
+		unit.Metrics.timeline = newTimelineMetrics(unit, binSize)
+	}
+	for _, party := range sim.Raid.Parties {
+		for _, player := range party.Players {
+			character := player.GetCharacter()
+			for _, pet := range character.Pets {
+				pet.Metrics.timeline.owner = character.Metrics.timeline
+			}
+		}
+	}
+}
+
+// Records the state of every unit from the end of the previous recording until the given time.
+func (sim *Simulation) recordTimeline(until time.Duration) {
+	for _, unit := range sim.AllUnits {
+		unit.Metrics.timeline.record(unit, until)
+	}
+}
+
+func (timeline *timelineMetrics) record(unit *Unit, until time.Duration) {
+	for timeline.recordedUntil < until {
+		bin := int(timeline.recordedUntil / timeline.binSize)
+		end := min(until, time.Duration(bin+1)*timeline.binSize)
+		seconds := (end - timeline.recordedUntil).Seconds()
+
+		timeline.coverage.add(bin, seconds)
+		for _, resource := range timeline.resources {
+			resource.series.add(bin, resource.level()*seconds)
+		}
+		for _, aura := range unit.activeAuras {
+			if aura.ActionID.IsEmptyAction() {
+				continue
+			}
+			series, ok := timeline.auras[aura.ActionID]
+			if !ok {
+				series = &timelineSeries{}
+				timeline.auras[aura.ActionID] = series
+			}
+			series.add(bin, seconds)
+		}
+
+		timeline.recordedUntil = end
+	}
+}
+
+func (timeline *timelineMetrics) addDamage(sim *Simulation, damage float64) {
+	if sim.CurrentTime < 0 {
+		return
+	}
+	bin := int(sim.CurrentTime / timeline.binSize)
+	timeline.damage.add(bin, damage)
+	if timeline.owner != nil {
+		timeline.owner.damage.add(bin, damage)
+	}
+}
+
+func (timeline *timelineMetrics) doneIteration() {
+	timeline.recordedUntil = 0
+	timeline.coverage.doneIteration()
+	timeline.damage.doneIteration()
+	for _, resource := range timeline.resources {
+		resource.series.doneIteration()
+	}
+	for _, series := range timeline.auras {
+		series.doneIteration()
+	}
+}
+
+func (timeline *timelineMetrics) merge(other *timelineMetrics) {
+	timeline.coverage.merge(&other.coverage)
+	timeline.damage.merge(&other.damage)
+	// Both units were built from the same request, so they have the same resources.
+	for i, resource := range timeline.resources {
+		resource.series.merge(&other.resources[i].series)
+	}
+	for actionID, otherSeries := range other.auras {
+		series, ok := timeline.auras[actionID]
+		if !ok {
+			series = &timelineSeries{}
+			timeline.auras[actionID] = series
+		}
+		series.merge(otherSeries)
+	}
+}
+
+func (timeline *timelineMetrics) ToProto(numIterations int) *proto.TimelineMetrics {
+	binSeconds := timeline.binSize.Seconds()
+
+	coverageSeconds := make([]float64, len(timeline.coverage.total))
+	coverage := make([]float64, len(timeline.coverage.total))
+	for bin := range coverageSeconds {
+		coverageSeconds[bin] = timeline.coverage.total[bin].value()
+		coverage[bin] = coverageSeconds[bin] / (binSeconds * float64(numIterations))
+	}
+
+	timelineProto := &proto.TimelineMetrics{
+		BinSize:  binSeconds,
+		Coverage: coverage,
+		Dps:      timeline.damage.averages(coverageSeconds),
+	}
+
+	for _, resource := range timeline.resources {
+		timelineProto.Resources = append(timelineProto.Resources, &proto.TimelineResource{
+			Type:   resource.resourceType,
+			Values: resource.series.averages(coverageSeconds),
+		})
+	}
+
+	auraIDs := make([]ActionID, 0, len(timeline.auras))
+	for actionID := range timeline.auras {
+		auraIDs = append(auraIDs, actionID)
+	}
+	slices.SortFunc(auraIDs, func(a, b ActionID) int {
+		if a.SpellID != b.SpellID {
+			return cmp.Compare(a.SpellID, b.SpellID)
+		} else if a.ItemID != b.ItemID {
+			return cmp.Compare(a.ItemID, b.ItemID)
+		} else if a.OtherID != b.OtherID {
+			return cmp.Compare(a.OtherID, b.OtherID)
+		}
+		return cmp.Compare(a.Tag, b.Tag)
+	})
+	for _, actionID := range auraIDs {
+		timelineProto.Auras = append(timelineProto.Auras, &proto.TimelineAura{
+			Id:     actionID.ToProto(),
+			Uptime: timeline.auras[actionID].averages(coverageSeconds),
+		})
+	}
+
+	return timelineProto
+}
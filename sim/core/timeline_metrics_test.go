@@ -0,0 +1,64 @@
+package core
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/wowsims/sod/sim/core/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestTimelineMetrics(t *testing.T) {
+	rsr := fakeCasterRequest(t, "cast_spell(spell:10197)\ncast_spell(spell:10149)\n", &proto.SimOptions{
+		Iterations:      20,
+		RandomSeed:      101,
+		TimelineMetrics: true,
+		TimelineBinSize: 5,
+	})
+	result := runFakeCasterSim(t, rsr)
+
+	player := result.RaidMetrics.Parties[0].Players[0]
+	timeline := player.Timeline
+	if timeline == nil || len(timeline.Dps) == 0 {
+		t.Fatalf("Expected timeline metrics, got %v", timeline)
+	}
+	if timeline.Coverage[0] != 1 {
+		t.Errorf("First bin coverage is %f, expected 1", timeline.Coverage[0])
+	}
+	// The fight lasts 55 to 65 seconds, so only some iterations reach the last bin.
+	if coverage := timeline.Coverage[len(timeline.Coverage)-1]; coverage <= 0 || coverage >= 1 {
+		t.Errorf("Last bin coverage is %f, expected part of the iterations", coverage)
+	}
+
+	// The damage in each bin must add up to the damage of every action.
+	timelineDamage := 0.0
+	for bin, dps := range timeline.Dps {
+		timelineDamage += dps * timeline.Coverage[bin] * timeline.BinSize * float64(rsr.SimOptions.Iterations)
+	}
+	actionDamage := 0.0
+	for _, action := range player.Actions {
+		for _, target := range action.Targets {
+			actionDamage += target.Damage
+		}
+	}
+	if math.Abs(timelineDamage-actionDamage) > 1e-6*actionDamage {
+		t.Errorf("Timeline damage adds up to %f, expected %f", timelineDamage, actionDamage)
+	}
+
+	var mana *proto.TimelineResource
+	for _, resource := range timeline.Resources {
+		if resource.Type == proto.ResourceType_ResourceTypeMana {
+			mana = resource
+		}
+	}
+	if mana == nil || mana.Values[0] <= mana.Values[len(mana.Values)-1] {
+		t.Errorf("Expected mana to go down over the fight, got %v", mana)
+	}
+
+	rsr.SimOptions.Workers = 3
+	workersResult := runFakeCasterSim(t, rsr)
+	if diff := cmp.Diff(timeline, workersResult.RaidMetrics.Parties[0].Players[0].Timeline, protocmp.Transform()); diff != "" {
+		t.Errorf("Timeline with 3 workers differs from single-threaded timeline: %s", diff)
+	}
+}
@@ -0,0 +1,547 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	goproto "google.golang.org/protobuf/proto"
+
+	"github.com/wowsims/sod/sim/core/proto"
+)
+
+const (
+	defaultTuneIterationsPerCandidate = 1000
+	defaultTuneMaxRounds              = 10
+
+	// Limits on the number of values of a single tunable constant, and on the number of grid search
+	// candidates, so a typo in a range can't start a search which never finishes.
+	maxTunableValues      = 1000
+	maxGridTuneCandidates = 10000
+)
+
+// Rotation tuning searches for the values of the tunable constants in the APL rotations of a raid
+// which give the most dps. Every candidate is simmed with the same random seed and test-level RNG
+// controls, as in CompareSims, so candidates differ only in the rolls their values affect.
+
+// A tunable constant, or several constants with the same name which are tuned together.
+type aplTunableParam struct {
+	name     string
+	original string
+	values   []string
+}
+
+// A parsed tunable range, in the units it was written in: seconds for durations and fractions for
+// percentages.
+type aplTunableRange struct {
+	min, max, step float64
+	units          aplTunableUnits
+}
+
+type aplTunableUnits int
+
+const (
+	aplTunableNumber aplTunableUnits = iota
+	aplTunablePercent
+	aplTunableDuration
+)
+
+func parseAPLTunableNumber(str string) (float64, aplTunableUnits, error) {
+	if strings.HasSuffix(str, "%") {
+		if v, err := strconv.ParseFloat(str[:len(str)-1], 64); err == nil {
+			return v / 100, aplTunablePercent, nil
+		}
+	} else if v, err := strconv.ParseFloat(str, 64); err == nil {
+		return v, aplTunableNumber, nil
+	} else if d, err := time.ParseDuration(str); err == nil {
+		return d.Seconds(), aplTunableDuration, nil
+	}
+	return 0, 0, fmt.Errorf("'%s' is not a number, percentage or duration", str)
+}
+
+func parseAPLTunableRange(config *proto.APLTunableRange) (*aplTunableRange, error) {
+	var values [3]float64
+	units, hasUnits := aplTunableNumber, false
+	for i, bound := range []string{config.Min, config.Max, config.Step} {
+		v, boundUnits, err := parseAPLTunableNumber(bound)
+		if err != nil {
+			return nil, err
+		}
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("'%s' is not a finite number", bound)
+		}
+		values[i] = v
+		// A plain 0 can be used as the bound of any range.
+		if bound == "0" {
+			continue
+		}
+		if hasUnits && boundUnits != units {
+			return nil, errors.New("min, max and step must use the same units")
+		}
+		units, hasUnits = boundUnits, true
+	}
+
+	tunableRange := &aplTunableRange{
+		min:   values[0],
+		max:   values[1],
+		step:  values[2],
+		units: units,
+	}
+	if tunableRange.step <= 0 {
+		return nil, errors.New("step must be positive")
+	}
+	if tunableRange.max < tunableRange.min {
+		return nil, errors.New("max must not be less than min")
+	}
+	// Checked before converting to an int, which could overflow for huge ranges.
+	if numSteps := tunableRange.numSteps(); numSteps+1 > maxTunableValues {
+		return nil, fmt.Errorf("range has %.0f values, more than the limit of %d", numSteps+1, maxTunableValues)
+	}
+	return tunableRange, nil
+}
+
+func (tunableRange *aplTunableRange) format(v float64) string {
+	switch tunableRange.units {
+	case aplTunablePercent:
+		return strconv.FormatFloat(v*100, 'f', -1, 64) + "%"
+	case aplTunableDuration:
+		return DurationFromSeconds(v).String()
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func (tunableRange *aplTunableRange) numSteps() float64 {
+	return math.Floor((tunableRange.max-tunableRange.min)/tunableRange.step + 1e-9)
+}
+
+func (tunableRange *aplTunableRange) numValues() int {
+	return int(tunableRange.numSteps()) + 1
+}
+
+func (tunableRange *aplTunableRange) values() []string {
+	values := make([]string, tunableRange.numValues())
+	for i := range values {
+		// Round away the error accumulated by the step, e.g. 0.1 + 0.2.
+		v := math.Round((tunableRange.min+float64(i)*tunableRange.step)*1e9) / 1e9
+		values[i] = tunableRange.format(v)
+	}
+	return values
+}
+
+// Returns the tunable constants in the rotations of every player, in a fixed order so the constants
+// of a cloned request line up with those of the original.
+func findAPLTunableConsts(raid *proto.Raid) []*proto.APLValueConst {
+	var consts []*proto.APLValueConst
+	var walk func(msg protoreflect.Message)
+	walk = func(msg protoreflect.Message) {
+		if constValue, ok := msg.Interface().(*proto.APLValueConst); ok {
+			if constValue.Tunable != nil {
+				consts = append(consts, constValue)
+			}
+			return
+		}
+
+		fields := msg.Descriptor().Fields()
+		for i := 0; i < fields.Len(); i++ {
+			field := fields.Get(i)
+			if field.Kind() != protoreflect.MessageKind || field.IsMap() || !msg.Has(field) {
+				continue
+			}
+			if field.IsList() {
+				list := msg.Get(field).List()
+				for j := 0; j < list.Len(); j++ {
+					walk(list.Get(j).Message())
+				}
+			} else {
+				walk(msg.Get(field).Message())
+			}
+		}
+	}
+
+	for _, party := range raid.GetParties() {
+		for _, player := range party.GetPlayers() {
+			if player.GetRotation() != nil {
+				walk(player.Rotation.ProtoReflect())
+			}
+		}
+	}
+	return consts
+}
+
+// Groups the tunable constants by name, returning the parameters to tune and the parameter of each
+// constant.
+func newAPLTunableParams(consts []*proto.APLValueConst) ([]*aplTunableParam, []int, error) {
+	var params []*aplTunableParam
+	paramIdxs := make([]int, len(consts))
+	paramsByName := make(map[string]int)
+	for i, constValue := range consts {
+		name := constValue.Tunable.Name
+		if name == "" {
+			name = fmt.Sprintf("Tunable %d", i+1)
+		}
+
+		tunableRange, err := parseAPLTunableRange(constValue.Tunable)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid range for tunable '%s': %w", name, err)
+		}
+		values := tunableRange.values()
+
+		if idx, ok := paramsByName[name]; ok {
+			param := params[idx]
+			if param.original != constValue.Val || !slices.Equal(param.values, values) {
+				return nil, nil, fmt.Errorf("tunables named '%s' must have the same value and range", name)
+			}
+			paramIdxs[i] = idx
+			continue
+		}
+
+		paramsByName[name] = len(params)
+		paramIdxs[i] = len(params)
+		params = append(params, &aplTunableParam{
+			name:     name,
+			original: constValue.Val,
+			values:   values,
+		})
+	}
+	return params, paramIdxs, nil
+}
+
+type rotationTuner struct {
+	// Request for the baseline, with the sim options used for every candidate.
+	baseRequest *proto.RaidSimRequest
+	params      []*aplTunableParam
+	paramIdxs   []int
+
+	// Results of every candidate simmed so far, by candidate key.
+	candidates      map[string]*tuneCandidateResult
+	candidatesOrder []*tuneCandidateResult
+	baseline        *tuneCandidateResult
+
+	progress      chan *proto.ProgressMetrics
+	simsCompleted int32
+}
+
+type tuneCandidateResult struct {
+	values []string
+	dps    *proto.DistributionMetrics
+}
+
+func tuneCandidateKey(values []string) string {
+	return strings.Join(values, "\x00")
+}
+
+func TuneRotation(ctx context.Context, request *proto.TuneRotationRequest, progress chan *proto.ProgressMetrics) *proto.TuneRotationResult {
+	result, err := tuneRotation(ctx, request, progress)
+	if err != nil {
+		return &proto.TuneRotationResult{
+			ErrorResult: err.Error(),
+		}
+	}
+	return result
+}
+
+func tuneRotation(ctx context.Context, request *proto.TuneRotationRequest, progress chan *proto.ProgressMetrics) (*proto.TuneRotationResult, error) {
+	if request.BaseSettings == nil {
+		return nil, errors.New("no base settings provided")
+	}
+	settings := request.TuneSettings
+	if settings == nil {
+		settings = &proto.TuneRotationSettings{}
+	}
+
+	baseRequest := goproto.Clone(request.BaseSettings).(*proto.RaidSimRequest)
+	if baseRequest.SimOptions == nil {
+		baseRequest.SimOptions = &proto.SimOptions{}
+	}
+	simOptions := baseRequest.SimOptions
+	simOptions.SaveAllValues = true
+	simOptions.TargetDpsStderr = 0
+	simOptions.TargetDpsCiWidth = 0
+	simOptions.Iterations = settings.IterationsPerCandidate
+	if simOptions.Iterations <= 0 {
+		simOptions.Iterations = defaultTuneIterationsPerCandidate
+	}
+	if simOptions.RandomSeed == 0 {
+		simOptions.RandomSeed = time.Now().UnixNano()
+	}
+	simOptions.IsTest = true
+
+	params, paramIdxs, err := newAPLTunableParams(findAPLTunableConsts(baseRequest.Raid))
+	if err != nil {
+		return nil, err
+	}
+	if len(params) == 0 {
+		return nil, errors.New("no tunable constants found in the rotations")
+	}
+
+	tuner := &rotationTuner{
+		baseRequest: baseRequest,
+		params:      params,
+		paramIdxs:   paramIdxs,
+		candidates:  make(map[string]*tuneCandidateResult),
+		progress:    progress,
+	}
+
+	original := MapSlice(params, func(param *aplTunableParam) string { return param.original })
+	switch settings.Method {
+	case proto.TuneRotationSettings_GridSearch:
+		err = tuner.gridSearch(ctx, original)
+	case proto.TuneRotationSettings_CoordinateDescent:
+		maxRounds := int(settings.MaxRounds)
+		if maxRounds <= 0 {
+			maxRounds = defaultTuneMaxRounds
+		}
+		err = tuner.coordinateDescent(ctx, original, maxRounds)
+	default:
+		err = fmt.Errorf("unknown search method: %s", settings.Method)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return tuner.toProto(), nil
+}
+
+func (tuner *rotationTuner) gridSearch(ctx context.Context, original []string) error {
+	numCandidates := 1
+	for _, param := range tuner.params {
+		numCandidates *= len(param.values)
+		if numCandidates > maxGridTuneCandidates {
+			return fmt.Errorf("grid search has more than %d candidates, use coordinate descent or larger steps", maxGridTuneCandidates)
+		}
+	}
+
+	candidates := [][]string{original}
+	for i := 0; i < numCandidates; i++ {
+		// Enumerate with the last parameter changing fastest.
+		values := make([]string, len(tuner.params))
+		idx := i
+		for p := len(tuner.params) - 1; p >= 0; p-- {
+			param := tuner.params[p]
+			values[p] = param.values[idx%len(param.values)]
+			idx /= len(param.values)
+		}
+		candidates = append(candidates, values)
+	}
+
+	_, err := tuner.simCandidates(ctx, candidates)
+	return err
+}
+
+// Tunes one parameter at a time with the others fixed at their current values, and moves a
+// parameter only if another value is strictly better, so it stops once no single change helps.
+func (tuner *rotationTuner) coordinateDescent(ctx context.Context, current []string, maxRounds int) error {
+	results, err := tuner.simCandidates(ctx, [][]string{current})
+	if err != nil {
+		return err
+	}
+	best := results[0]
+
+	for round := 0; round < maxRounds; round++ {
+		changed := false
+		for p, param := range tuner.params {
+			candidates := make([][]string, len(param.values))
+			for i, value := range param.values {
+				candidates[i] = append([]string(nil), best.values...)
+				candidates[i][p] = value
+			}
+
+			results, err := tuner.simCandidates(ctx, candidates)
+			if err != nil {
+				return err
+			}
+			for _, result := range results {
+				if result.dps.Avg > best.dps.Avg {
+					best = result
+					changed = true
+				}
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+	return nil
+}
+
+// Returns the results of the given candidates, simming the ones which haven't been simmed yet.
+func (tuner *rotationTuner) simCandidates(pctx context.Context, candidates [][]string) ([]*tuneCandidateResult, error) {
+	var toSim []*tuneCandidateResult
+	for _, values := range candidates {
+		key := tuneCandidateKey(values)
+		if _, ok := tuner.candidates[key]; !ok {
+			candidate := &tuneCandidateResult{values: values}
+			tuner.candidates[key] = candidate
+			toSim = append(toSim, candidate)
+		}
+	}
+
+	if err := tuner.runSims(pctx, toSim); err != nil {
+		return nil, err
+	}
+	tuner.candidatesOrder = append(tuner.candidatesOrder, toSim...)
+	if tuner.baseline == nil {
+		tuner.baseline = tuner.candidatesOrder[0]
+	}
+
+	return MapSlice(candidates, func(values []string) *tuneCandidateResult {
+		return tuner.candidates[tuneCandidateKey(values)]
+	}), nil
+}
+
+func (tuner *rotationTuner) candidateRequest(values []string) *proto.RaidSimRequest {
+	request := goproto.Clone(tuner.baseRequest).(*proto.RaidSimRequest)
+	for i, constValue := range findAPLTunableConsts(request.Raid) {
+		constValue.Val = values[tuner.paramIdxs[i]]
+	}
+	return request
+}
+
+func (tuner *rotationTuner) runSims(pctx context.Context, candidates []*tuneCandidateResult) error {
+	if len(candidates) == 0 {
+		return nil
+	}
+	if err := pctx.Err(); err != nil {
+		return err
+	}
+
+	concurrency := runtime.NumCPU() + 1
+
+	tickets := make(chan struct{}, concurrency)
+	for i := 0; i < concurrency; i++ {
+		tickets <- struct{}{}
+	}
+
+	type indexedResult struct {
+		result *proto.RaidSimResult
+		index  int
+	}
+	results := make(chan indexedResult, len(candidates))
+
+	iterations := tuner.baseRequest.SimOptions.Iterations
+	simsBefore := tuner.simsCompleted
+	totalSims := simsBefore + int32(len(candidates))
+	totalIterations := totalSims * iterations
+
+	completedIterations := simsBefore * iterations
+	completedSims := simsBefore
+
+	ctx, cancel := context.WithCancel(pctx)
+	defer cancel()
+	// reporter for all sims combined.
+	if tuner.progress != nil {
+		go func() {
+			for ctx.Err() == nil {
+				select {
+				case tuner.progress <- &proto.ProgressMetrics{
+					TotalSims:           totalSims,
+					CompletedSims:       atomic.LoadInt32(&completedSims),
+					CompletedIterations: atomic.LoadInt32(&completedIterations),
+					TotalIterations:     totalIterations,
+				}:
+				case <-ctx.Done():
+					return
+				}
+				time.Sleep(time.Second)
+			}
+		}()
+	}
+
+	// launcher for all candidates (limited by concurrency max)
+	go func() {
+		for i, candidate := range candidates {
+			select {
+			case <-tickets:
+			case <-ctx.Done():
+				return
+			}
+			singleSimProgress := make(chan *proto.ProgressMetrics)
+			// Sims only close their progress channel outside of tests, so the watcher also stops once
+			// the sim is done, and drains the channel until then so the sim never blocks on it.
+			simDone := make(chan struct{})
+			// watches this progress and pushes up to main reporter.
+			go func() {
+				var prevDone int32
+				for {
+					select {
+					case p, ok := <-singleSimProgress:
+						if !ok {
+							return
+						}
+						atomic.AddInt32(&completedIterations, p.CompletedIterations-prevDone)
+						prevDone = p.CompletedIterations
+					case <-simDone:
+						return
+					}
+				}
+			}()
+			go func(request *proto.RaidSimRequest, index int) {
+				// results has room for every candidate, so this never blocks after runSims returns.
+				results <- indexedResult{
+					result: runSim(request, singleSimProgress, false),
+					index:  index,
+				}
+				close(simDone)
+				atomic.AddInt32(&completedSims, 1)
+				tickets <- struct{}{} // when done, allow for new sim to be launched.
+			}(tuner.candidateRequest(candidate.values), i)
+		}
+	}()
+
+	for range candidates {
+		var result indexedResult
+		select {
+		case result = <-results:
+		case <-pctx.Done():
+			return pctx.Err()
+		}
+		if result.result == nil || result.result.ErrorResult != "" {
+			return errors.New("simulation failed: " + result.result.GetErrorResult())
+		}
+		candidates[result.index].dps = result.result.RaidMetrics.Dps
+	}
+	tuner.simsCompleted = totalSims
+	return nil
+}
+
+func (tuner *rotationTuner) toProto() *proto.TuneRotationResult {
+	baselineDps := tuner.baseline.dps
+	candidateToProto := func(candidate *tuneCandidateResult) *proto.TuneRotationCandidate {
+		var delta aggregator
+		for i := range candidate.dps.AllValues {
+			delta.add(candidate.dps.AllValues[i] - baselineDps.AllValues[i])
+		}
+		mean, stdev := delta.meanAndStdDev()
+		margin := confidenceZ95 * stdev / math.Sqrt(float64(delta.n))
+
+		return &proto.TuneRotationCandidate{
+			Values:         candidate.values,
+			Dps:            candidate.dps.Avg,
+			DpsDelta:       mean,
+			DpsDeltaCiLow:  mean - margin,
+			DpsDeltaCiHigh: mean + margin,
+		}
+	}
+
+	ranked := append([]*tuneCandidateResult(nil), tuner.candidatesOrder...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].dps.Avg > ranked[j].dps.Avg
+	})
+
+	return &proto.TuneRotationResult{
+		Names:            MapSlice(tuner.params, func(param *aplTunableParam) string { return param.name }),
+		Baseline:         candidateToProto(tuner.baseline),
+		Candidates:       MapSlice(ranked, candidateToProto),
+		CandidatesSimmed: int32(len(ranked)),
+	}
+}
@@ -0,0 +1,145 @@
+package core
+
+import (
+	"context"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/wowsims/sod/sim/core/proto"
+)
+
+func TestParseAPLTunableRange(t *testing.T) {
+	for _, tc := range []struct {
+		min, max, step string
+		expected       []string
+	}{
+		{min: "0s", max: "1s", step: "250ms", expected: []string{"0s", "250ms", "500ms", "750ms", "1s"}},
+		{min: "0", max: "0.3", step: "0.1", expected: []string{"0", "0.1", "0.2", "0.3"}},
+		{min: "10%", max: "30%", step: "10%", expected: []string{"10%", "20%", "30%"}},
+		{min: "0", max: "20%", step: "10%", expected: []string{"0%", "10%", "20%"}},
+	} {
+		tunableRange, err := parseAPLTunableRange(&proto.APLTunableRange{Min: tc.min, Max: tc.max, Step: tc.step})
+		if err != nil {
+			t.Errorf("Range %s-%s by %s failed with error: %s", tc.min, tc.max, tc.step, err)
+			continue
+		}
+		if values := tunableRange.values(); !slices.Equal(values, tc.expected) {
+			t.Errorf("Range %s-%s by %s has values %v, expected %v", tc.min, tc.max, tc.step, values, tc.expected)
+		}
+	}
+}
+
+func TestParseAPLTunableRangeErrors(t *testing.T) {
+	for _, tc := range []struct {
+		min, max, step string
+	}{
+		{min: "0", max: "10", step: "0"},
+		{min: "0", max: "10", step: "-1"},
+		{min: "10", max: "0", step: "1"},
+		{min: "0s", max: "10%", step: "1s"},
+		{min: "0", max: "10", step: "fast"},
+		{min: "0", max: "10000", step: "1"},
+		{min: "0", max: "10", step: "NaN"},
+		{min: "NaN", max: "10", step: "1"},
+		{min: "0", max: "Inf", step: "1"},
+		{min: "-Inf", max: "0", step: "1"},
+		{min: "0", max: "1e300", step: "1e-300"},
+	} {
+		if _, err := parseAPLTunableRange(&proto.APLTunableRange{Min: tc.min, Max: tc.max, Step: tc.step}); err == nil {
+			t.Errorf("Expected range %s-%s by %s to be rejected", tc.min, tc.max, tc.step)
+		}
+	}
+}
+
+func TestTuneRunSimsCancel(t *testing.T) {
+	goroutinesBefore := runtime.NumGoroutine()
+
+	tuner := &rotationTuner{
+		baseRequest: &proto.RaidSimRequest{
+			Raid: SinglePlayerRaidProto(&proto.Player{
+				Class:     proto.Class_ClassShaman,
+				Spec:      &proto.Player_ElementalShaman{},
+				Equipment: &proto.EquipmentSpec{},
+			}, &proto.PartyBuffs{}, &proto.RaidBuffs{}, &proto.Debuffs{}),
+			Encounter:  MakeSingleTargetEncounter(60, 0),
+			SimOptions: &proto.SimOptions{Iterations: 100, IsTest: true},
+		},
+		progress: make(chan *proto.ProgressMetrics),
+	}
+	candidates := make([]*tuneCandidateResult, 10*runtime.NumCPU()+20)
+	for i := range candidates {
+		candidates[i] = &tuneCandidateResult{}
+	}
+
+	// Cancel as soon as the sims have started, and stop reading progress like a closed UI would.
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-tuner.progress
+		cancel()
+	}()
+	if err := tuner.runSims(ctx, candidates); err != context.Canceled {
+		t.Fatalf("Expected runSims to be canceled, got %v", err)
+	}
+
+	// Sims which already started run to completion, but nothing is left blocked afterwards.
+	for deadline := time.Now().Add(10 * time.Second); runtime.NumGoroutine() > goroutinesBefore; {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines still running after runSims was canceled, expected %d", runtime.NumGoroutine(), goroutinesBefore)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestTuneRotation(t *testing.T) {
+	// Both waits delay the start of the rotation, so starting right away is best.
+	rsr := fakeCasterRequest(t, `wait_until(current_time() >= const("10s", {name="start", min="0s", max="20s", step="10s"}))
+wait_until(current_time() >= const("5s", {name="delay", min="0s", max="10s", step="5s"}))
+cast_spell(spell:10149)
+`, &proto.SimOptions{
+		RandomSeed: 101,
+	})
+
+	for _, tc := range []struct {
+		method       proto.TuneRotationSettings_SearchMethod
+		expectedSims int32
+	}{
+		{method: proto.TuneRotationSettings_GridSearch, expectedSims: 9},
+		{method: proto.TuneRotationSettings_CoordinateDescent, expectedSims: 7},
+	} {
+		result := RunTuneRotation(&proto.TuneRotationRequest{
+			BaseSettings: rsr,
+			TuneSettings: &proto.TuneRotationSettings{
+				Method:                 tc.method,
+				IterationsPerCandidate: 20,
+			},
+		})
+		if result.ErrorResult != "" {
+			t.Fatalf("%s: tune failed with error: %s", tc.method, result.ErrorResult)
+		}
+
+		if !slices.Equal(result.Names, []string{"start", "delay"}) {
+			t.Errorf("%s: expected names [start delay], got %v", tc.method, result.Names)
+		}
+		if result.CandidatesSimmed != tc.expectedSims || len(result.Candidates) != int(tc.expectedSims) {
+			t.Errorf("%s: expected %d candidates, got %d", tc.method, tc.expectedSims, result.CandidatesSimmed)
+		}
+		if !slices.Equal(result.Baseline.Values, []string{"10s", "5s"}) || result.Baseline.DpsDelta != 0 {
+			t.Errorf("%s: unexpected baseline: %v", tc.method, result.Baseline)
+		}
+
+		best := result.Candidates[0]
+		if !slices.Equal(best.Values, []string{"0s", "0s"}) {
+			t.Errorf("%s: expected best values [0s 0s], got %v", tc.method, best.Values)
+		}
+		if best.DpsDelta <= 0 || best.DpsDeltaCiLow <= 0 {
+			t.Errorf("%s: expected best candidate to beat the baseline, got %v", tc.method, best)
+		}
+		for i := 1; i < len(result.Candidates); i++ {
+			if result.Candidates[i].Dps > result.Candidates[i-1].Dps {
+				t.Errorf("%s: candidates are not sorted by dps", tc.method)
+			}
+		}
+	}
+}
@@ -63,6 +63,8 @@ type Unit struct {
 	Moving                  bool
 	moveAura                *Aura
 	moveSpell               *Spell
+	// Number of movements in progress, as they can overlap. The unit stops when the last one ends.
+	movements int
 
 	// Environment in which this Unit exists. This will be nil until after the
 	// construction phase.
@@ -160,7 +162,7 @@ type Unit struct {
 // Units can be disabled for several reasons:
 //  1. Downtime for temporary pets (e.g. Water Elemental)
 //  2. Enemy units in various phases (not yet implemented)
-//  3. Dead units (not yet implemented)
+//  3. Targets that died in health fights
 func (unit *Unit) IsEnabled() bool {
 	return unit.enabled
 }
@@ -395,6 +397,7 @@ func (unit *Unit) initMovement() {
 		MaxStacks: 30,
 
 		OnGain: func(aura *Aura, sim *Simulation) {
+			unit.CancelHardcast(sim)
 			unit.AutoAttacks.CancelAutoSwing(sim)
 			unit.Moving = true
 		},
@@ -418,6 +421,69 @@ func (unit *Unit) initMovement() {
 	})
 }
 
+// Makes the unit move for a while without changing its distance from the target, e.g. to step out
+// of something on the ground.
+func (unit *Unit) MoveFor(duration time.Duration, sim *Simulation) {
+	unit.startMoving(sim)
+	StartDelayedAction(sim, DelayedActionOptions{
+		DoAt: sim.CurrentTime + duration,
+		OnAction: func(sim *Simulation) {
+			unit.stopMoving(sim)
+		},
+	})
+}
+
+// Starts a movement, which lasts until the matching stopMoving call.
+func (unit *Unit) startMoving(sim *Simulation) {
+	unit.movements++
+	if unit.movements == 1 {
+		unit.moveSpell.Cast(sim, unit.CurrentTarget)
+	}
+}
+
+// Ends a movement started with startMoving, stopping the unit if no other movement is in progress.
+func (unit *Unit) stopMoving(sim *Simulation) {
+	if unit.movements == 0 {
+		return
+	}
+	unit.movements--
+	if unit.movements == 0 {
+		unit.moveAura.Deactivate(sim)
+	}
+}
+
+// Makes the unit run the given distance away from its target, wait there and run back, moving for
+// the whole duration if it is too short to stop in between.
+func (unit *Unit) MoveAwayFor(duration time.Duration, distance float64, sim *Simulation) {
+	travelTime := DurationFromSeconds(distance / RunSpeed)
+	if distance <= 0 || 2*travelTime >= duration {
+		unit.MoveFor(duration, sim)
+		return
+	}
+
+	// Distances are changed relative to where the unit is, as other movements can overlap this one.
+	// They are updated before the movement stops, so melee only restarts in range.
+	stopAt := func(doAt time.Duration, distance float64) {
+		StartDelayedAction(sim, DelayedActionOptions{
+			DoAt: doAt,
+			OnAction: func(sim *Simulation) {
+				unit.DistanceFromTarget += distance
+				unit.stopMoving(sim)
+			},
+		})
+	}
+
+	unit.startMoving(sim)
+	stopAt(sim.CurrentTime+travelTime, distance)
+	StartDelayedAction(sim, DelayedActionOptions{
+		DoAt: sim.CurrentTime + duration - travelTime,
+		OnAction: func(sim *Simulation) {
+			unit.startMoving(sim)
+		},
+	})
+	stopAt(sim.CurrentTime+duration, -distance)
+}
+
 func (unit *Unit) MoveTo(moveRange float64, sim *Simulation) {
 	if moveRange == unit.DistanceFromTarget {
 		return
@@ -431,8 +497,9 @@ func (unit *Unit) MoveTo(moveRange float64, sim *Simulation) {
 	moveTicks := timeToMove / tickPeriod
 	moveInterval := moveDistance / float64(moveTicks)
 
-	unit.moveSpell.Cast(sim, unit.CurrentTarget)
+	unit.startMoving(sim)
 
+	numTicks := 0
 	sim.AddPendingAction(NewPeriodicAction(sim, PeriodicActionOptions{
 		Period:          time.Millisecond * 500,
 		NumTicks:        int(moveTicks),
@@ -442,8 +509,9 @@ func (unit *Unit) MoveTo(moveRange float64, sim *Simulation) {
 			unit.DistanceFromTarget += moveInterval
 			unit.moveAura.SetStacks(sim, int32(unit.DistanceFromTarget))
 
-			if unit.DistanceFromTarget == moveRange {
-				unit.moveAura.Deactivate(sim)
+			numTicks++
+			if numTicks == int(moveTicks) {
+				unit.stopMoving(sim)
 			}
 		},
 	}))
@@ -518,6 +586,7 @@ func (unit *Unit) reset(sim *Simulation, _ Agent) {
 	}
 
 	unit.DistanceFromTarget = unit.StartDistanceFromTarget
+	unit.movements = 0
 
 	unit.manaBar.reset()
 	unit.focusBar.reset(sim)
@@ -561,6 +630,11 @@ func (unit *Unit) doneIteration(sim *Simulation) {
 	}
 }
 
+func (unit *Unit) mergeMetrics(other *Unit) {
+	unit.Metrics.merge(&other.Metrics)
+	unit.auraTracker.mergeMetrics(&other.auraTracker)
+}
+
 func (unit *Unit) GetSpellsMatchingSchool(school SpellSchool) []*Spell {
 	var spells []*Spell
 	for _, spell := range unit.Spellbook {
@@ -166,29 +166,117 @@ func Flatten[T any](src [][]T) []T {
 	return dst
 }
 
+// exactSum accumulates float64 values without intermediate rounding, using
+// Shewchuk's algorithm (the same one behind Python's math.fsum). Because the
+// result is the correctly-rounded exact sum, it doesn't depend on the order in
+// which values were added, so sums from separately-run iterations can be merged
+// without changing a single bit of the final value.
+type exactSum struct {
+	partials []float64 // Non-overlapping, in increasing order of magnitude.
+	special  float64   // Sum of any non-finite values, which can't be tracked exactly.
+}
+
+func (s *exactSum) add(v float64) {
+	if math.IsInf(v, 0) || math.IsNaN(v) {
+		s.special += v
+		return
+	}
+
+	i := 0
+	for _, y := range s.partials {
+		if math.Abs(v) < math.Abs(y) {
+			v, y = y, v
+		}
+		hi := v + y
+		lo := y - (hi - v)
+		if lo != 0 {
+			s.partials[i] = lo
+			i++
+		}
+		v = hi
+	}
+	s.partials = append(s.partials[:i], v)
+}
+
+func (s *exactSum) merge(other *exactSum) {
+	for _, v := range other.partials {
+		s.add(v)
+	}
+	s.special += other.special
+}
+
+func (s *exactSum) scale(f float64) {
+	v := s.value() * f
+	s.partials = append(s.partials[:0], v)
+	s.special = 0
+}
+
+func (s *exactSum) value() float64 {
+	if s.special != 0 {
+		return s.special
+	}
+
+	n := len(s.partials)
+	if n == 0 {
+		return 0
+	}
+
+	n--
+	hi := s.partials[n]
+	lo := 0.0
+	for n > 0 {
+		x := hi
+		n--
+		y := s.partials[n]
+		hi = x + y
+		lo = y - (hi - x)
+		if lo != 0 {
+			break
+		}
+	}
+
+	// Make half-even rounding work across multiple partials.
+	if n > 0 && ((lo < 0 && s.partials[n-1] < 0) || (lo > 0 && s.partials[n-1] > 0)) {
+		y := lo * 2
+		x := hi + y
+		if y == x-hi {
+			hi = x
+		}
+	}
+	return hi
+}
+
+// z-score of a two-sided 95% confidence interval.
+const confidenceZ95 = 1.96
+
 type aggregator struct {
 	n     int
-	sum   float64
-	sumSq float64
+	sum   exactSum
+	sumSq exactSum
 }
 
 func (x *aggregator) add(v float64) {
 	x.n++
-	x.sum += v
-	x.sumSq += v * v
+	x.sum.add(v)
+	x.sumSq.add(v * v)
 }
 
 func (x *aggregator) scale(f float64) {
-	x.sum *= f
-	x.sumSq *= f * f
+	x.sum.scale(f)
+	x.sumSq.scale(f * f)
 }
 
 func (x *aggregator) merge(y *aggregator) *aggregator {
-	return &aggregator{n: x.n + y.n, sum: x.sum + y.sum, sumSq: x.sumSq + y.sumSq}
+	z := &aggregator{n: x.n + y.n}
+	z.sum.merge(&x.sum)
+	z.sum.merge(&y.sum)
+	z.sumSq.merge(&x.sumSq)
+	z.sumSq.merge(&y.sumSq)
+	return z
 }
 
 func (x *aggregator) meanAndStdDev() (float64, float64) {
-	mean := x.sum / float64(x.n)
-	stdDev := math.Sqrt(x.sumSq/float64(x.n) - mean*mean)
+	mean := x.sum.value() / float64(x.n)
+	stdDev := math.Sqrt(x.sumSq.value()/float64(x.n) - mean*mean)
 	return mean, stdDev
 }
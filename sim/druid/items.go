@@ -64,6 +64,7 @@ func (druid *Druid) newBloodbarkCleaveItem(itemID int32) {
 		DamageMultiplier: 1,
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+			results := sim.Environment.LimitToActiveTargets(results)
 			for idx := range results {
 				results[idx] = spell.CalcDamage(sim, target, 5, spell.OutcomeMagicCrit)
 				target = sim.Environment.NextTargetUnit(target)
@@ -0,0 +1,70 @@
+package encounters
+
+import (
+	"github.com/wowsims/sod/sim/core"
+	"github.com/wowsims/sod/sim/core/proto"
+	"github.com/wowsims/sod/sim/core/stats"
+)
+
+// A level 60 boss joined by waves of three adds. Each wave spawns 20s into the fight and every
+// 45s after, and despawns after 30s unless it is killed first.
+func addAddWaves(bossPrefix string) {
+	core.AddPresetTarget(&core.PresetTarget{
+		PathPrefix: bossPrefix,
+		Config: &proto.Target{
+			Name:      "Add Waves Boss",
+			Level:     63,
+			MobType:   proto.MobType_MobTypeUnknown,
+			TankIndex: 0,
+
+			Stats: stats.Stats{
+				stats.Health:      127_393, // TODO:
+				stats.Armor:       3731,    // TODO:
+				stats.AttackPower: 805,     // TODO:
+			}.ToFloatArray(),
+
+			SpellSchool:      proto.SpellSchool_SpellSchoolPhysical,
+			SwingSpeed:       2,      // TODO:
+			MinBaseDamage:    3000,   // TODO:
+			DamageSpread:     0.3333, // TODO:
+			ParryHaste:       true,
+			DualWield:        false,
+			DualWieldPenalty: false,
+			TargetInputs:     make([]*proto.TargetInput, 0),
+		},
+	})
+	core.AddPresetTarget(&core.PresetTarget{
+		PathPrefix: bossPrefix,
+		Config: &proto.Target{
+			Name:      "Add Waves Add",
+			Level:     60,
+			MobType:   proto.MobType_MobTypeUnknown,
+			TankIndex: 1,
+
+			Stats: stats.Stats{
+				stats.Health:      20_000, // TODO:
+				stats.Armor:       3075,   // TODO:
+				stats.AttackPower: 640,    // TODO:
+			}.ToFloatArray(),
+
+			SpellSchool:      proto.SpellSchool_SpellSchoolPhysical,
+			SwingSpeed:       2,      // TODO:
+			MinBaseDamage:    1000,   // TODO:
+			DamageSpread:     0.3333, // TODO:
+			ParryHaste:       true,
+			DualWield:        false,
+			DualWieldPenalty: false,
+			TargetInputs:     make([]*proto.TargetInput, 0),
+
+			SpawnTime:       20,
+			DespawnTime:     30,
+			RespawnInterval: 45,
+		},
+	})
+	core.AddPresetEncounter("Add Waves", []string{
+		bossPrefix + "/Add Waves Boss",
+		bossPrefix + "/Add Waves Add",
+		bossPrefix + "/Add Waves Add",
+		bossPrefix + "/Add Waves Add",
+	})
+}
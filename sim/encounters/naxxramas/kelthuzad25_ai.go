@@ -1,11 +1,17 @@
 package naxxramas
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/wowsims/sod/sim/core"
 	"github.com/wowsims/sod/sim/core/proto"
 	"github.com/wowsims/sod/sim/core/stats"
 )
 
+// Kel'Thuzad stays out of the fight while the raid fights off his minions in phase 1.
+const kelThuzadPhase1Duration = time.Second * 228
+
 func addKelThuzad25(bossPrefix string) {
 	core.AddPresetTarget(&core.PresetTarget{
 		PathPrefix: bossPrefix,
@@ -34,13 +40,111 @@ func addKelThuzad25(bossPrefix string) {
 		},
 		AI: NewKelThuzad25AI(),
 	})
+
+	// Phase 1 minions, which come in waves until Kel'Thuzad joins the fight. Each wave replaces
+	// the last, since any minions left alive are picked up by the rest of the raid.
+	for i, spawnTime := range []float64{5, 7, 9} {
+		core.AddPresetTarget(&core.PresetTarget{
+			PathPrefix: bossPrefix,
+			Config:     kelThuzadMinionConfig(16427, fmt.Sprintf("Soldier of the Frozen Wastes %d", i+1), 80, 17_010, 1_500, spawnTime, 12),
+		})
+	}
+	core.AddPresetTarget(&core.PresetTarget{
+		PathPrefix: bossPrefix,
+		Config:     kelThuzadMinionConfig(16428, "Unstoppable Abomination", 81, 252_000, 6_000, 30, 30),
+	})
+	core.AddPresetTarget(&core.PresetTarget{
+		PathPrefix: bossPrefix,
+		Config:     kelThuzadMinionConfig(16429, "Soul Weaver", 81, 201_600, 3_000, 45, 30),
+	})
+
+	for i := 0; i < 2; i++ {
+		core.AddPresetTarget(&core.PresetTarget{
+			PathPrefix: bossPrefix,
+			Config: &proto.Target{
+				Id:        16441,
+				Name:      fmt.Sprintf("Guardian of Icecrown %d", i+1),
+				Level:     82,
+				MobType:   proto.MobType_MobTypeUndead,
+				TankIndex: 2 + int32(i),
+
+				// Guardians are tanked rather than killed, so they don't have health.
+				Stats: stats.Stats{
+					stats.Armor:       10643,
+					stats.AttackPower: 805,
+				}.ToFloatArray(),
+
+				SpellSchool:   proto.SpellSchool_SpellSchoolPhysical,
+				SwingSpeed:    2,
+				MinBaseDamage: 8_000,
+				DamageSpread:  0.3333,
+				TargetInputs:  make([]*proto.TargetInput, 0),
+			},
+			AI: NewGuardianOfIcecrown25AI(),
+		})
+	}
+
 	core.AddPresetEncounter("Kel'Thuzad", []string{
 		bossPrefix + "/Kel'Thuzad",
+		bossPrefix + "/Soldier of the Frozen Wastes 1",
+		bossPrefix + "/Soldier of the Frozen Wastes 2",
+		bossPrefix + "/Soldier of the Frozen Wastes 3",
+		bossPrefix + "/Unstoppable Abomination",
+		bossPrefix + "/Soul Weaver",
+		bossPrefix + "/Guardian of Icecrown 1",
+		bossPrefix + "/Guardian of Icecrown 2",
 	})
 }
 
+// Minions spawn every interval until the end of phase 1, and despawn when the next one spawns.
+func kelThuzadMinionConfig(id int32, name string, level int32, health float64, minBaseDamage float64, spawnTime float64, interval float64) *proto.Target {
+	return &proto.Target{
+		Id:        id,
+		Name:      name,
+		Level:     level,
+		MobType:   proto.MobType_MobTypeUndead,
+		TankIndex: 1,
+
+		Stats: stats.Stats{
+			stats.Health:      health,
+			stats.Armor:       10643,
+			stats.AttackPower: 805,
+		}.ToFloatArray(),
+
+		SpellSchool:   proto.SpellSchool_SpellSchoolPhysical,
+		SwingSpeed:    2,
+		MinBaseDamage: minBaseDamage,
+		DamageSpread:  0.3333,
+		TargetInputs:  make([]*proto.TargetInput, 0),
+
+		SpawnTime:       spawnTime,
+		DespawnTime:     interval,
+		RespawnInterval: interval,
+		Waves:           int32((kelThuzadPhase1Duration.Seconds()-spawnTime)/interval) + 1,
+	}
+}
+
+type kelThuzadPhase int
+
+const (
+	kelThuzadPhase1 kelThuzadPhase = iota + 1
+	kelThuzadPhase2
+	kelThuzadPhase3
+)
+
 type KelThuzad25AI struct {
 	Target *core.Target
+
+	phase      kelThuzadPhase
+	phase2Time time.Duration
+	guardians  []*core.Target
+
+	// Stuns from Frost Blast, for each player.
+	frostBlastAuras map[*core.Unit]*core.Aura
+
+	Frostbolt     *core.Spell
+	FrostBlast    *core.Spell
+	ShadowFissure *core.Spell
 }
 
 func NewKelThuzad25AI() core.AIFactory {
@@ -51,10 +155,198 @@ func NewKelThuzad25AI() core.AIFactory {
 
 func (ai *KelThuzad25AI) Initialize(target *core.Target, config *proto.Target) {
 	ai.Target = target
+
+	for _, other := range target.Env.Encounter.Targets {
+		if _, ok := other.AI.(*GuardianOfIcecrown25AI); ok {
+			ai.guardians = append(ai.guardians, other)
+		}
+	}
+
+	ai.frostBlastAuras = make(map[*core.Unit]*core.Aura)
+	for _, unit := range target.Env.Raid.AllPlayerUnits {
+		ai.frostBlastAuras[unit] = ai.registerFrostBlastAura(unit)
+	}
+
+	ai.registerFrostboltSpell(target)
+	ai.registerFrostBlastSpell(target)
+	ai.registerShadowFissureSpell(target)
+}
+
+func (ai *KelThuzad25AI) Reset(sim *core.Simulation) {
+	ai.phase = kelThuzadPhase1
+	ai.Target.StartDespawned(sim)
+	core.StartDelayedAction(sim, core.DelayedActionOptions{
+		DoAt: kelThuzadPhase1Duration,
+		OnAction: func(sim *core.Simulation) {
+			ai.startPhase2(sim)
+		},
+	})
+}
+
+func (ai *KelThuzad25AI) startPhase2(sim *core.Simulation) {
+	ai.phase = kelThuzadPhase2
+	ai.phase2Time = sim.CurrentTime
+	ai.Target.Spawn(sim)
+
+	ai.Frostbolt.CD.Set(sim.CurrentTime + time.Second*5)
+	ai.FrostBlast.CD.Set(sim.CurrentTime + time.Second*45)
+	ai.ShadowFissure.CD.Set(sim.CurrentTime + time.Second*15)
+}
+
+// Guardians of Icecrown join the fight one at a time.
+func (ai *KelThuzad25AI) startPhase3(sim *core.Simulation) {
+	ai.phase = kelThuzadPhase3
+	if sim.Log != nil {
+		ai.Target.Log(sim, "Phase 3")
+	}
+
+	for i, guardian := range ai.guardians {
+		core.StartDelayedAction(sim, core.DelayedActionOptions{
+			DoAt:     sim.CurrentTime + time.Duration(i)*time.Second*10,
+			OnAction: guardian.Spawn,
+		})
+	}
+}
+
+// Without health, Kel'Thuzad is assumed to lose it evenly from when he joins the fight.
+func (ai *KelThuzad25AI) healthPercent(sim *core.Simulation) float64 {
+	if ai.Target.HasHealthBar() {
+		return ai.Target.HealthPercent(sim)
+	}
+	remaining := sim.GetRemainingDuration()
+	return float64(remaining) / float64(max(remaining+sim.CurrentTime-ai.phase2Time, 1))
+}
+
+func (ai *KelThuzad25AI) randomPlayer(sim *core.Simulation, label string) *core.Unit {
+	players := sim.Raid.AllPlayerUnits
+	return players[int(sim.RandomFloat(label)*float64(len(players)))]
+}
+
+// Stuns a player, who can't act until it wears off.
+func (ai *KelThuzad25AI) registerFrostBlastAura(unit *core.Unit) *core.Aura {
+	return unit.GetOrRegisterAura(core.Aura{
+		ActionID: core.ActionID{SpellID: 27808},
+		Label:    "Frost Blast",
+		Duration: time.Second * 4,
+		OnGain: func(aura *core.Aura, sim *core.Simulation) {
+			aura.Unit.PseudoStats.Stunned = true
+			aura.Unit.AutoAttacks.CancelAutoSwing(sim)
+			aura.Unit.SetGCDTimer(sim, max(aura.Unit.GCD.ReadyAt(), aura.ExpiresAt()))
+		},
+		OnExpire: func(aura *core.Aura, sim *core.Simulation) {
+			aura.Unit.PseudoStats.Stunned = false
+			aura.Unit.AutoAttacks.EnableAutoSwing(sim)
+		},
+	})
+}
+
+func (ai *KelThuzad25AI) registerFrostboltSpell(target *core.Target) {
+	ai.Frostbolt = target.RegisterSpell(core.SpellConfig{
+		ActionID:    core.ActionID{SpellID: 28478},
+		SpellSchool: core.SpellSchoolFrost,
+		ProcMask:    core.ProcMaskSpellDamage,
+		Flags:       core.SpellFlagNoOnCastComplete,
+
+		Cast: core.CastConfig{
+			CD: core.Cooldown{
+				Timer:    target.NewTimer(),
+				Duration: time.Second * 8,
+			},
+		},
+
+		DamageMultiplier: 1,
+
+		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+			spell.CalcAndDealDamage(sim, target, sim.Roll(10_063, 11_937), spell.OutcomeMagicHit)
+		},
+	})
+}
+
+func (ai *KelThuzad25AI) registerFrostBlastSpell(target *core.Target) {
+	ai.FrostBlast = target.RegisterSpell(core.SpellConfig{
+		ActionID:    core.ActionID{SpellID: 27808},
+		SpellSchool: core.SpellSchoolFrost,
+		ProcMask:    core.ProcMaskSpellDamage,
+		Flags:       core.SpellFlagNoOnCastComplete,
+
+		Cast: core.CastConfig{
+			CD: core.Cooldown{
+				Timer:    target.NewTimer(),
+				Duration: time.Second * 45,
+			},
+		},
+
+		DamageMultiplier: 1,
+
+		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+			// Deals 26% of the player's maximum health over the stun.
+			spell.CalcAndDealDamage(sim, target, 0.26*target.MaxHealth(), spell.OutcomeAlwaysHit)
+			ai.frostBlastAuras[target].Activate(sim)
+		},
+	})
 }
 
-func (ai *KelThuzad25AI) Reset(*core.Simulation) {
+// Players have to step out of the fissure before it erupts.
+func (ai *KelThuzad25AI) registerShadowFissureSpell(target *core.Target) {
+	ai.ShadowFissure = target.RegisterSpell(core.SpellConfig{
+		ActionID: core.ActionID{SpellID: 27810},
+		Flags:    core.SpellFlagNoOnCastComplete,
+
+		Cast: core.CastConfig{
+			CD: core.Cooldown{
+				Timer:    target.NewTimer(),
+				Duration: time.Second * 20,
+			},
+		},
+
+		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+			target.MoveFor(time.Millisecond*1500, sim)
+		},
+	})
 }
 
 func (ai *KelThuzad25AI) ExecuteCustomRotation(sim *core.Simulation) {
+	if ai.phase == kelThuzadPhase1 || len(sim.Raid.AllPlayerUnits) == 0 {
+		return
+	}
+
+	if ai.phase == kelThuzadPhase2 && ai.healthPercent(sim) <= 0.45 {
+		ai.startPhase3(sim)
+	}
+
+	if ai.Frostbolt.IsReady(sim) {
+		target := ai.Target.CurrentTarget
+		if target == nil {
+			target = ai.randomPlayer(sim, "Frostbolt")
+		}
+		ai.Frostbolt.Cast(sim, target)
+	}
+	if ai.FrostBlast.IsReady(sim) {
+		ai.FrostBlast.Cast(sim, ai.randomPlayer(sim, "Frost Blast"))
+	}
+	if ai.ShadowFissure.IsReady(sim) {
+		ai.ShadowFissure.Cast(sim, ai.randomPlayer(sim, "Shadow Fissure"))
+	}
+}
+
+// Guardians of Icecrown only join the fight once Kel'Thuzad calls for them in phase 3.
+type GuardianOfIcecrown25AI struct {
+	Target *core.Target
+}
+
+func NewGuardianOfIcecrown25AI() core.AIFactory {
+	return func() core.TargetAI {
+		return &GuardianOfIcecrown25AI{}
+	}
+}
+
+func (ai *GuardianOfIcecrown25AI) Initialize(target *core.Target, config *proto.Target) {
+	ai.Target = target
+}
+
+func (ai *GuardianOfIcecrown25AI) Reset(sim *core.Simulation) {
+	ai.Target.StartDespawned(sim)
+}
+
+func (ai *GuardianOfIcecrown25AI) ExecuteCustomRotation(sim *core.Simulation) {
 }
@@ -1,6 +1,8 @@
 package naxxramas
 
 import (
+	"time"
+
 	"github.com/wowsims/sod/sim/core"
 	"github.com/wowsims/sod/sim/core/proto"
 	"github.com/wowsims/sod/sim/core/stats"
@@ -30,7 +32,26 @@ func addLoatheb25(bossPrefix string) {
 			ParryHaste:       false,
 			DualWield:        false,
 			DualWieldPenalty: false,
-			TargetInputs:     make([]*proto.TargetInput, 0),
+			TargetInputs: []*proto.TargetInput{
+				{
+					Label:       "Spore Group Size",
+					Tooltip:     "Number of raid members buffed by each spore. The raid is split into groups of this size, which take turns killing the spores.",
+					InputType:   proto.InputType_Number,
+					NumberValue: 5,
+				},
+				{
+					Label:       "Spore Interval",
+					Tooltip:     "Seconds between spores, or 0 for no spores.",
+					InputType:   proto.InputType_Number,
+					NumberValue: 12,
+				},
+				{
+					Label:       "Raid Size",
+					Tooltip:     "Number of raid members the spores rotate through, which can be more than the players in the sim.",
+					InputType:   proto.InputType_Number,
+					NumberValue: 25,
+				},
+			},
 		},
 		AI: NewLoatheb25AI(),
 	})
@@ -41,6 +62,17 @@ func addLoatheb25(bossPrefix string) {
 
 type Loatheb25AI struct {
 	Target *core.Target
+
+	sporeGroupSize int
+	sporeInterval  time.Duration
+	sporeGroups    int
+
+	// Fungal Creep auras of the players in the sim, in raid order.
+	fungalCreepAuras []*core.Aura
+	sporeCount       int
+
+	InevitableDoom *core.Spell
+	Spore          *core.Spell
 }
 
 func NewLoatheb25AI() core.AIFactory {
@@ -51,10 +83,144 @@ func NewLoatheb25AI() core.AIFactory {
 
 func (ai *Loatheb25AI) Initialize(target *core.Target, config *proto.Target) {
 	ai.Target = target
+
+	players := target.Env.Raid.AllPlayerUnits
+	ai.sporeGroupSize = max(1, int(targetInputNumber(config, 0, 5)))
+	ai.sporeInterval = core.DurationFromSeconds(targetInputNumber(config, 1, 12))
+	raidSize := max(len(players), int(targetInputNumber(config, 2, 25)))
+	ai.sporeGroups = (raidSize + ai.sporeGroupSize - 1) / ai.sporeGroupSize
+
+	for _, unit := range players {
+		character := target.Env.Raid.GetPlayerFromUnit(unit).GetCharacter()
+		ai.fungalCreepAuras = append(ai.fungalCreepAuras, character.NewTemporaryStatsAura("Fungal Creep", core.ActionID{SpellID: 29232}, stats.Stats{
+			stats.MeleeCrit: 50 * core.CritRatingPerCritChance,
+			stats.SpellCrit: 50 * core.SpellCritRatingPerCritChance,
+		}, time.Second*90))
+
+		ai.registerCorruptedMind(character)
+	}
+
+	ai.registerInevitableDoomSpell(target)
+	if ai.sporeInterval > 0 {
+		ai.registerSporeSpell(target)
+	}
+}
+
+func (ai *Loatheb25AI) Reset(sim *core.Simulation) {
+	ai.sporeCount = 0
+
+	ai.InevitableDoom.CD.Set(time.Minute * 2)
+	if ai.Spore != nil {
+		ai.Spore.CD.Set(ai.sporeInterval)
+	}
+}
+
+// Each healing spell stops the caster from casting any other healing spell for a minute.
+func (ai *Loatheb25AI) registerCorruptedMind(character *core.Character) {
+	cd := core.Cooldown{
+		Timer:    character.NewTimer(),
+		Duration: time.Minute,
+	}
+
+	core.MakePermanent(character.GetOrRegisterAura(core.Aura{
+		ActionID: core.ActionID{SpellID: 29185},
+		Label:    "Corrupted Mind",
+		OnCastComplete: func(aura *core.Aura, sim *core.Simulation, spell *core.Spell) {
+			if spell.ProcMask.Matches(core.ProcMaskSpellHealing) {
+				cd.Use(sim)
+			}
+		},
+	}))
+
+	// Healing spells are only all registered once the character is initialized.
+	ai.Target.Env.RegisterPreFinalizeEffect(func() {
+		for _, spell := range character.Spellbook {
+			if !spell.ProcMask.Matches(core.ProcMaskSpellHealing) {
+				continue
+			}
+			extraCastCondition := spell.ExtraCastCondition
+			spell.ExtraCastCondition = func(sim *core.Simulation, target *core.Unit) bool {
+				return cd.IsReady(sim) && (extraCastCondition == nil || extraCastCondition(sim, target))
+			}
+		}
+	})
+}
+
+// Hits the whole raid 10s after each cast. Cast every 30s from 2 minutes in, and every 15s after
+// 5 minutes.
+func (ai *Loatheb25AI) registerInevitableDoomSpell(target *core.Target) {
+	ai.InevitableDoom = target.RegisterSpell(core.SpellConfig{
+		ActionID:    core.ActionID{SpellID: 29204},
+		SpellSchool: core.SpellSchoolShadow,
+		ProcMask:    core.ProcMaskSpellDamage,
+		Flags:       core.SpellFlagNoOnCastComplete,
+
+		Cast: core.CastConfig{
+			CD: core.Cooldown{
+				Timer:    target.NewTimer(),
+				Duration: time.Second * 30,
+			},
+		},
+
+		DamageMultiplier: 1,
+
+		Dot: core.DotConfig{
+			Aura: core.Aura{
+				Label: "Inevitable Doom",
+			},
+			NumberOfTicks: 1,
+			TickLength:    time.Second * 10,
+
+			OnSnapshot: func(sim *core.Simulation, target *core.Unit, dot *core.Dot, isRollover bool) {
+				dot.Snapshot(target, 4000, isRollover)
+			},
+			OnTick: func(sim *core.Simulation, target *core.Unit, dot *core.Dot) {
+				dot.CalcAndDealPeriodicSnapshotDamage(sim, target, dot.OutcomeTick)
+			},
+		},
+
+		ApplyEffects: func(sim *core.Simulation, _ *core.Unit, spell *core.Spell) {
+			for _, unit := range sim.Raid.AllPlayerUnits {
+				spell.Dot(unit).Apply(sim)
+			}
+			if sim.CurrentTime >= time.Minute*5 {
+				spell.CD.Set(sim.CurrentTime + time.Second*15)
+			}
+		},
+	})
 }
 
-func (ai *Loatheb25AI) Reset(*core.Simulation) {
+// Each spore is killed by the next group in turn, who gain Fungal Creep.
+func (ai *Loatheb25AI) registerSporeSpell(target *core.Target) {
+	ai.Spore = target.RegisterSpell(core.SpellConfig{
+		ActionID: core.ActionID{SpellID: 29234},
+		Flags:    core.SpellFlagNoOnCastComplete,
+
+		Cast: core.CastConfig{
+			CD: core.Cooldown{
+				Timer:    target.NewTimer(),
+				Duration: ai.sporeInterval,
+			},
+		},
+
+		ApplyEffects: func(sim *core.Simulation, _ *core.Unit, spell *core.Spell) {
+			group := ai.sporeCount % ai.sporeGroups
+			ai.sporeCount++
+
+			for i, aura := range ai.fungalCreepAuras {
+				if i/ai.sporeGroupSize == group {
+					aura.Activate(sim)
+				}
+			}
+		},
+	})
 }
 
 func (ai *Loatheb25AI) ExecuteCustomRotation(sim *core.Simulation) {
+	if ai.InevitableDoom.IsReady(sim) {
+		ai.InevitableDoom.Cast(sim, &ai.Target.Unit)
+	}
+	if ai.Spore != nil && ai.Spore.IsReady(sim) {
+		ai.Spore.Cast(sim, &ai.Target.Unit)
+	}
 }
@@ -1,5 +1,9 @@
 package naxxramas
 
+import (
+	"github.com/wowsims/sod/sim/core/proto"
+)
+
 func Register() {
 	addPatchwerk25("Naxxrammas 25")
 	addKelThuzad25("Naxxrammas 25")
@@ -9,3 +13,12 @@ func Register() {
 	// TODO: Figure out why this isn't pickable
 	//addPatchwerk10("Naxxrammas")
 }
+
+// Returns the value of a number input, or the default if the encounter was saved before the input
+// was added.
+func targetInputNumber(config *proto.Target, index int, defaultValue float64) float64 {
+	if config == nil || index >= len(config.TargetInputs) {
+		return defaultValue
+	}
+	return config.TargetInputs[index].NumberValue
+}
@@ -1,6 +1,9 @@
 package naxxramas
 
 import (
+	"slices"
+	"time"
+
 	"github.com/wowsims/sod/sim/core"
 	"github.com/wowsims/sod/sim/core/proto"
 	"github.com/wowsims/sod/sim/core/stats"
@@ -10,7 +13,7 @@ func addThaddius25(bossPrefix string) {
 	core.AddPresetTarget(&core.PresetTarget{
 		PathPrefix: bossPrefix,
 		Config: &proto.Target{
-			Id:        15990,
+			Id:        15928,
 			Name:      "Thaddius",
 			Level:     83,
 			MobType:   proto.MobType_MobTypeUndead,
@@ -30,7 +33,26 @@ func addThaddius25(bossPrefix string) {
 			ParryHaste:       false,
 			DualWield:        false,
 			DualWieldPenalty: false,
-			TargetInputs:     make([]*proto.TargetInput, 0),
+			TargetInputs: []*proto.TargetInput{
+				{
+					Label:       "Correct Group Chance",
+					Tooltip:     "Chance (0 to 1) for each raid member to move to the group of their new charge after a Polarity Shift. Otherwise they stand with the other charge until the next shift, taking charge damage instead of gaining the damage buff.",
+					InputType:   proto.InputType_Number,
+					NumberValue: 0.9,
+				},
+				{
+					Label:       "Charge Stacks",
+					Tooltip:     "Number of nearby raid members with the same charge when standing with the correct group. Each one increases damage dealt by 10%.",
+					InputType:   proto.InputType_Number,
+					NumberValue: 5,
+				},
+				{
+					Label:       "Out of Range Chance",
+					Tooltip:     "Chance (0 to 1) for each raid member to be out of Thaddius' range after a Polarity Shift, until the next shift. Thaddius casts Ball Lightning at raid members out of range.",
+					InputType:   proto.InputType_Number,
+					NumberValue: 0,
+				},
+			},
 		},
 		AI: NewThaddius25AI(),
 	})
@@ -39,8 +61,30 @@ func addThaddius25(bossPrefix string) {
 	})
 }
 
+// Each raid member's charge since the last Polarity Shift, and where they are standing.
+type thaddiusCharge struct {
+	unit *core.Unit
+
+	positiveCharge *core.Aura
+	negativeCharge *core.Aura
+
+	wrongGroup bool
+	outOfRange bool
+}
+
 type Thaddius25AI struct {
 	Target *core.Target
+
+	correctGroupChance float64
+	chargeStacks       int32
+	outOfRangeChance   float64
+
+	charges []*thaddiusCharge
+
+	PolarityShift  *core.Spell
+	ChargeDamage   *core.Spell
+	ChainLightning *core.Spell
+	BallLightning  *core.Spell
 }
 
 func NewThaddius25AI() core.AIFactory {
@@ -51,10 +95,191 @@ func NewThaddius25AI() core.AIFactory {
 
 func (ai *Thaddius25AI) Initialize(target *core.Target, config *proto.Target) {
 	ai.Target = target
+
+	ai.correctGroupChance = targetInputNumber(config, 0, 0.9)
+	ai.chargeStacks = max(0, int32(targetInputNumber(config, 1, 5)))
+	ai.outOfRangeChance = targetInputNumber(config, 2, 0)
+
+	for _, unit := range target.Env.Raid.AllPlayerUnits {
+		ai.charges = append(ai.charges, &thaddiusCharge{
+			unit:           unit,
+			positiveCharge: ai.registerChargeAura(unit, core.ActionID{SpellID: 29659}, "Positive Charge"),
+			negativeCharge: ai.registerChargeAura(unit, core.ActionID{SpellID: 29660}, "Negative Charge"),
+		})
+	}
+
+	ai.registerPolarityShiftSpell(target)
+	ai.registerChargeDamageSpell(target)
+	ai.registerChainLightningSpell(target)
+	ai.registerBallLightningSpell(target)
+}
+
+func (ai *Thaddius25AI) Reset(sim *core.Simulation) {
+	for _, charge := range ai.charges {
+		charge.wrongGroup = false
+		charge.outOfRange = false
+	}
+
+	ai.PolarityShift.CD.Set(time.Second * 15)
+	ai.ChainLightning.CD.Set(time.Second * 10)
 }
 
-func (ai *Thaddius25AI) Reset(*core.Simulation) {
+// Stacks for each nearby raid member with the same charge. Raid members standing with the wrong
+// group keep the aura without any stacks.
+func (ai *Thaddius25AI) registerChargeAura(unit *core.Unit, actionID core.ActionID, label string) *core.Aura {
+	return unit.GetOrRegisterAura(core.Aura{
+		ActionID:  actionID,
+		Label:     label,
+		Duration:  core.NeverExpires,
+		MaxStacks: max(1, ai.chargeStacks),
+		OnStacksChange: func(aura *core.Aura, sim *core.Simulation, oldStacks int32, newStacks int32) {
+			aura.Unit.PseudoStats.DamageDealtMultiplier *= (1 + 0.1*float64(newStacks)) / (1 + 0.1*float64(oldStacks))
+		},
+	})
+}
+
+func (ai *Thaddius25AI) registerPolarityShiftSpell(target *core.Target) {
+	ai.PolarityShift = target.RegisterSpell(core.SpellConfig{
+		ActionID: core.ActionID{SpellID: 28089},
+		Flags:    core.SpellFlagNoOnCastComplete,
+
+		Cast: core.CastConfig{
+			CD: core.Cooldown{
+				Timer:    target.NewTimer(),
+				Duration: time.Second * 30,
+			},
+		},
+
+		ApplyEffects: func(sim *core.Simulation, _ *core.Unit, spell *core.Spell) {
+			for _, charge := range ai.charges {
+				charge.positiveCharge.Deactivate(sim)
+				charge.negativeCharge.Deactivate(sim)
+
+				aura := charge.positiveCharge
+				if sim.RandomFloat("Polarity Shift") < 0.5 {
+					aura = charge.negativeCharge
+				}
+				charge.wrongGroup = !sim.Proc(ai.correctGroupChance, "Polarity Shift Correct Group")
+				charge.outOfRange = sim.Proc(ai.outOfRangeChance, "Polarity Shift Out of Range")
+
+				aura.Activate(sim)
+				if !charge.wrongGroup {
+					aura.SetStacks(sim, ai.chargeStacks)
+				}
+			}
+
+			// The first pulse of the other group's charges hits right after the shift.
+			ai.ChargeDamage.CD.Reset()
+		},
+	})
+}
+
+// Raid members standing with the wrong group are hit by the charges of everyone around them.
+func (ai *Thaddius25AI) registerChargeDamageSpell(target *core.Target) {
+	ai.ChargeDamage = target.RegisterSpell(core.SpellConfig{
+		ActionID:    core.ActionID{SpellID: 28062},
+		SpellSchool: core.SpellSchoolNature,
+		ProcMask:    core.ProcMaskSpellDamage,
+		Flags:       core.SpellFlagNoOnCastComplete,
+
+		Cast: core.CastConfig{
+			CD: core.Cooldown{
+				Timer:    target.NewTimer(),
+				Duration: time.Second * 5,
+			},
+		},
+
+		DamageMultiplier: 1,
+
+		ApplyEffects: func(sim *core.Simulation, _ *core.Unit, spell *core.Spell) {
+			for _, charge := range ai.charges {
+				if charge.wrongGroup {
+					spell.CalcAndDealDamage(sim, charge.unit, sim.Roll(4500, 5500), spell.OutcomeAlwaysHit)
+				}
+			}
+		},
+	})
+}
+
+// Hits a random raid member and jumps to two others.
+func (ai *Thaddius25AI) registerChainLightningSpell(target *core.Target) {
+	ai.ChainLightning = target.RegisterSpell(core.SpellConfig{
+		ActionID:    core.ActionID{SpellID: 28167},
+		SpellSchool: core.SpellSchoolNature,
+		ProcMask:    core.ProcMaskSpellDamage,
+		Flags:       core.SpellFlagNoOnCastComplete,
+
+		Cast: core.CastConfig{
+			CD: core.Cooldown{
+				Timer:    target.NewTimer(),
+				Duration: time.Second * 15,
+			},
+		},
+
+		DamageMultiplier: 1,
+
+		ApplyEffects: func(sim *core.Simulation, _ *core.Unit, spell *core.Spell) {
+			units := make([]*core.Unit, len(ai.charges))
+			for i, charge := range ai.charges {
+				units[i] = charge.unit
+			}
+			for hit := 0; hit < 3 && len(units) > 0; hit++ {
+				idx := int(sim.RandomFloat("Chain Lightning") * float64(len(units)))
+				spell.CalcAndDealDamage(sim, units[idx], sim.Roll(5088, 5912), spell.OutcomeMagicHit)
+				units[idx] = units[len(units)-1]
+				units = units[:len(units)-1]
+			}
+		},
+	})
+}
+
+func (ai *Thaddius25AI) registerBallLightningSpell(target *core.Target) {
+	ai.BallLightning = target.RegisterSpell(core.SpellConfig{
+		ActionID:    core.ActionID{SpellID: 28299},
+		SpellSchool: core.SpellSchoolNature,
+		ProcMask:    core.ProcMaskSpellDamage,
+		Flags:       core.SpellFlagNoOnCastComplete,
+
+		Cast: core.CastConfig{
+			CD: core.Cooldown{
+				Timer:    target.NewTimer(),
+				Duration: time.Second * 2,
+			},
+		},
+
+		DamageMultiplier: 1,
+
+		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+			spell.CalcAndDealDamage(sim, target, sim.Roll(7400, 8600), spell.OutcomeMagicHit)
+		},
+	})
 }
 
 func (ai *Thaddius25AI) ExecuteCustomRotation(sim *core.Simulation) {
+	if len(ai.charges) == 0 {
+		return
+	}
+
+	if ai.PolarityShift.IsReady(sim) {
+		ai.PolarityShift.Cast(sim, &ai.Target.Unit)
+	}
+	if ai.ChargeDamage.IsReady(sim) && slices.ContainsFunc(ai.charges, func(charge *thaddiusCharge) bool { return charge.wrongGroup }) {
+		ai.ChargeDamage.Cast(sim, &ai.Target.Unit)
+	}
+	if ai.ChainLightning.IsReady(sim) {
+		ai.ChainLightning.Cast(sim, &ai.Target.Unit)
+	}
+
+	if ai.BallLightning.IsReady(sim) {
+		var outOfRange []*core.Unit
+		for _, charge := range ai.charges {
+			if charge.outOfRange {
+				outOfRange = append(outOfRange, charge.unit)
+			}
+		}
+		if len(outOfRange) > 0 {
+			idx := int(sim.RandomFloat("Ball Lightning") * float64(len(outOfRange)))
+			ai.BallLightning.Cast(sim, outOfRange[idx])
+		}
+	}
 }
@@ -2,16 +2,18 @@ package encounters
 
 import (
 	"github.com/wowsims/sod/sim/core"
+	"github.com/wowsims/sod/sim/encounters/naxxramas"
 )
 
 func init() {
-	// TODO: Classic encounters?
-	// naxxramas.Register()
+	naxxramas.Register()
 	addLevel25("SoD")
 	addLevel40("SoD")
 	addGnomereganMechanical("SoD")
 	addLevel50("SoD")
 	addLevel60("SoD")
+	addAddWaves("SoD")
+	addScriptedEncounters()
 }
 
 func AddSingleTargetBossEncounter(presetTarget *core.PresetTarget) {
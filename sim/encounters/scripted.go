@@ -0,0 +1,32 @@
+package encounters
+
+import (
+	"embed"
+	"log"
+	"path"
+
+	"github.com/wowsims/sod/sim/core"
+)
+
+// Encounters written as JSON PresetEncounters with scripted targets. See core.AddPresetEncounterJSON.
+// These are embedded so they are listed in the UI, while the CLI can sim other script files
+// directly with its --encounter flag.
+//
+//go:embed scripts/*.json
+var scriptFiles embed.FS
+
+func addScriptedEncounters() {
+	entries, err := scriptFiles.ReadDir("scripts")
+	if err != nil {
+		log.Fatalf("Failed to read encounter scripts: %s", err)
+	}
+	for _, entry := range entries {
+		data, err := scriptFiles.ReadFile(path.Join("scripts", entry.Name()))
+		if err != nil {
+			log.Fatalf("Failed to read encounter script %s: %s", entry.Name(), err)
+		}
+		if err := core.AddPresetEncounterJSON(data); err != nil {
+			log.Fatalf("Invalid encounter script %s: %s", entry.Name(), err)
+		}
+	}
+}
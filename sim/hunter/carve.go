@@ -57,6 +57,7 @@ func (hunter *Hunter) registerCarveSpell() {
 		ThreatMultiplier: 1,
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+			results := sim.Environment.LimitToActiveTargets(results)
 			curTarget := target
 			for idx := range results {
 				baseDamage := spell.Unit.MHNormalizedWeaponDamage(sim, spell.MeleeAttackPower())
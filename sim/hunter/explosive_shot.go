@@ -14,7 +14,6 @@ func (hunter *Hunter) registerExplosiveShotSpell(timer *core.Timer) {
 	}
 
 	actionID := core.ActionID{SpellID: 409552}
-	numHits := hunter.Env.GetNumTargets()
 
 	baseLowDamage := hunter.baseRuneAbilityDamage() * 0.36 * 1.15 // Buff from 1/3/2024 - verify with new build and update numbers
 	baseHighDamage := hunter.baseRuneAbilityDamage() * 0.54 * 1.15
@@ -79,6 +78,7 @@ func (hunter *Hunter) registerExplosiveShotSpell(timer *core.Timer) {
 
 				if result.Landed() {
 					curTarget := target
+					numHits := sim.GetNumTargets()
 					for hitIndex := int32(0); hitIndex < numHits; hitIndex++ {
 						if curTarget != target {
 							baseDamage = sim.Roll(baseLowDamage, baseHighDamage) + 0.039*spell.RangedAttackPower(curTarget)
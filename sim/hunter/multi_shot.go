@@ -66,6 +66,7 @@ func (hunter *Hunter) getMultiShotConfig(rank int, timer *core.Timer) core.Spell
 		BonusCoefficient: 1,
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+			numHits := min(numHits, max(sim.GetNumTargets(), 1))
 			curTarget := target
 
 			for hitIndex := int32(0); hitIndex < numHits; hitIndex++ {
@@ -193,7 +193,7 @@ func (hunter *Hunter) applyLockAndLoad() {
 		return
 	}
 
-	lockAndLoadMetrics := hunter.Metrics.NewResourceMetrics(core.ActionID{SpellID: 415413}, proto.ResourceType_ResourceTypeMana)
+	lockAndLoadMetrics := hunter.NewManaMetrics(core.ActionID{SpellID: 415413})
 
 	hunter.LockAndLoadAura = hunter.GetOrRegisterAura(core.Aura{
 		Label:    "Lock And Load",
@@ -39,7 +39,7 @@ func (mage *Mage) registerLivingBombSpell() {
 		BonusCoefficient: explosionCoeff,
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
-			for _, aoeTarget := range sim.Encounter.TargetUnits {
+			for _, aoeTarget := range sim.Encounter.ActiveTargetUnits {
 				spell.CalcAndDealDamage(sim, aoeTarget, baseExplosionDamage, spell.OutcomeMagicCrit)
 			}
 		},
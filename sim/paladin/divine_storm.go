@@ -46,6 +46,7 @@ func (paladin *Paladin) registerDivineStormSpell() {
 		ThreatMultiplier: 1,
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+			results := sim.Environment.LimitToActiveTargets(results)
 			var totalDamageDealt float64
 			for idx := range results {
 				baseDamage := spell.Unit.MHWeaponDamage(sim, spell.MeleeAttackPower())
@@ -38,6 +38,7 @@ func (paladin *Paladin) registerHammerOfTheRighteous() {
 		ThreatMultiplier: 1,
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+			results := sim.Environment.LimitToActiveTargets(results)
 			weapon := paladin.AutoAttacks.MH()
 			baseDamage := weapon.CalculateAverageWeaponDamage(spell.MeleeAttackPower()) / weapon.SwingSpeed
 
@@ -73,7 +73,7 @@ func (paladin *Paladin) registerHolyWrathSpell() {
 				spell.BonusCritRating += bonusCrit
 
 				results = results[:0]
-				for _, target := range paladin.Env.Encounter.TargetUnits {
+				for _, target := range sim.Encounter.ActiveTargetUnits {
 					if hasPurifyingPower || (target.MobType == proto.MobType_MobTypeDemon || target.MobType == proto.MobType_MobTypeUndead) {
 						damage := sim.Roll(rank.damageLow, rank.damageHigh)
 						result := spell.CalcDamage(sim, target, damage, spell.OutcomeMagicHitAndCrit)
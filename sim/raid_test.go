@@ -1,8 +1,13 @@
 package sim
 
 import (
+	"slices"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+	googleProto "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+
 	"github.com/wowsims/sod/sim/core"
 	"github.com/wowsims/sod/sim/core/proto"
 	"github.com/wowsims/sod/sim/core/stats"
@@ -103,3 +108,287 @@ func TestFixedRaid(t *testing.T) {
  	`)
 }
 */
+
+// Single player request used by tests of sim features that don't depend on the class.
+func fireMageRequest(simOptions *proto.SimOptions) *proto.RaidSimRequest {
+	buffs := core.FullBuffsPhase3
+	return &proto.RaidSimRequest{
+		Raid: core.SinglePlayerRaidProto(
+			core.WithSpec(&proto.Player{
+				Race:          proto.Race_RaceTroll,
+				Level:         50,
+				Class:         proto.Class_ClassMage,
+				Equipment:     core.GetGearSet("../ui/mage/gear_sets", "p3_fire").GearSet,
+				TalentsString: "-0550020123033151-2035",
+				Consumes:      &proto.Consumes{},
+				Buffs:         buffs.Player,
+				Rotation:      core.GetAplRotation("../ui/mage/apls", "p3_fire").Rotation,
+			}, &proto.Player_Mage{
+				Mage: &proto.Mage{
+					Options: &proto.Mage_Options{
+						Armor: proto.Mage_Options_MageArmor,
+					},
+				},
+			}),
+			buffs.Party,
+			buffs.Raid,
+			buffs.Debuffs),
+		Encounter:  core.MakeSingleTargetEncounter(50, 5),
+		SimOptions: simOptions,
+	}
+}
+
+func TestWorkersDeterministic(t *testing.T) {
+	rsr := fireMageRequest(&proto.SimOptions{
+		Iterations: 50,
+		RandomSeed: 101,
+	})
+
+	expected := core.RunRaidSim(rsr)
+	if expected.ErrorResult != "" {
+		t.Fatalf("Sim failed with error: %s", expected.ErrorResult)
+	}
+
+	for _, workers := range []int32{2, 3, 7, 64} {
+		rsr.SimOptions.Workers = workers
+		result := core.RunRaidSim(rsr)
+		// Action metrics come out of a map, so their order isn't stable even between single-threaded runs.
+		diff := cmp.Diff(expected, result, protocmp.Transform(), protocmp.SortRepeated(func(a, b *proto.ActionMetrics) bool {
+			return a.Id.String() < b.Id.String()
+		}))
+		if diff != "" {
+			t.Fatalf("Result with %d workers differs from single-threaded result: %s", workers, diff)
+		}
+	}
+}
+
+func TestCombatLog(t *testing.T) {
+	result := core.RunRaidSim(fireMageRequest(&proto.SimOptions{
+		Iterations:          10,
+		RandomSeed:          101,
+		DebugFirstIteration: true,
+		CombatLog:           true,
+	}))
+	if result.ErrorResult != "" {
+		t.Fatalf("Sim failed with error: %s", result.ErrorResult)
+	}
+
+	counts := map[proto.CombatLogEvent_Type]int{}
+	for _, event := range result.CombatLog {
+		counts[event.Type]++
+		if event.Type == proto.CombatLogEvent_Damage && (event.Source.GetType() != proto.UnitReference_Player || event.Target.GetType() != proto.UnitReference_Target) {
+			t.Fatalf("Damage event from %v to %v, expected player to target", event.Source, event.Target)
+		}
+	}
+
+	for _, eventType := range []proto.CombatLogEvent_Type{
+		proto.CombatLogEvent_CastStarted,
+		proto.CombatLogEvent_CastCompleted,
+		proto.CombatLogEvent_Damage,
+		proto.CombatLogEvent_AuraGained,
+		proto.CombatLogEvent_AuraFaded,
+		proto.CombatLogEvent_Resource,
+		proto.CombatLogEvent_ExecutePhase,
+		proto.CombatLogEvent_AplAction,
+	} {
+		if counts[eventType] == 0 {
+			t.Errorf("No %s events in combat log", eventType)
+		}
+	}
+
+	// Only the first iteration is logged.
+	if counts[proto.CombatLogEvent_ExecutePhase] != 3 {
+		t.Errorf("Got %d execute phase events, expected 3", counts[proto.CombatLogEvent_ExecutePhase])
+	}
+}
+
+func TestAPLItemSlotCooldowns(t *testing.T) {
+	// The fire mage gear has Atalai Blood Ritual Charm in the first trinket slot, whose use effect
+	// has a spell ID rather than the item's ID.
+	simDps := func(text string) (float64, []string) {
+		rotation, err := core.APLRotationFromText("type: TypeAPL\n" + text + "\ncast_spell(spell:10149(rank=9))\n")
+		if err != nil {
+			t.Fatalf("Failed to parse rotation: %s", err)
+		}
+		rsr := fireMageRequest(&proto.SimOptions{
+			Iterations: 20,
+			RandomSeed: 101,
+		})
+		rsr.Raid.Parties[0].Players[0].Rotation = rotation
+		result := core.RunRaidSim(rsr)
+		if result.ErrorResult != "" {
+			t.Fatalf("Sim failed with error: %s", result.ErrorResult)
+		}
+		stats := core.ComputeStats(&proto.ComputeStatsRequest{Raid: rsr.Raid, Encounter: rsr.Encounter})
+		return result.RaidMetrics.Dps.Avg, stats.RaidStats.Parties[0].Players[0].RotationStats.PriorityList[0].Warnings
+	}
+
+	baselineDps, _ := simDps("")
+	useDps, warnings := simDps("use_item_slot(ItemSlotTrinket1)")
+	if len(warnings) != 0 {
+		t.Fatalf("Unexpected warnings: %v", warnings)
+	}
+	if useDps <= baselineDps {
+		t.Errorf("Expected using the trinket to increase dps, got %f vs %f", useDps, baselineDps)
+	}
+	if castDps, _ := simDps("cast_spell(spell:446297)"); castDps != useDps {
+		t.Errorf("Expected the same dps as casting the trinket's spell, got %f vs %f", useDps, castDps)
+	}
+	if readyDps, _ := simDps("use_item_slot(ItemSlotTrinket1) if item_slot_is_ready(ItemSlotTrinket1) and item_slot_time_to_ready(ItemSlotTrinket1) <= 0s"); readyDps != useDps {
+		t.Errorf("Expected the trinket to be used whenever it is ready, got %f vs %f", readyDps, useDps)
+	}
+	if notReadyDps, _ := simDps("use_item_slot(ItemSlotTrinket1) if item_slot_time_to_ready(ItemSlotTrinket1) > 0s"); notReadyDps != baselineDps {
+		t.Errorf("Expected the trinket to never be used, got %f vs %f", notReadyDps, baselineDps)
+	}
+
+	_, warnings = simDps("use_item_slot(ItemSlotNeck)")
+	if !slices.Contains(warnings, "No item with a use effect equipped in Neck") {
+		t.Errorf("Expected a warning for a slot without a use effect, got %v", warnings)
+	}
+}
+
+func TestThaddiusPolarityShift(t *testing.T) {
+	preset := core.GetPresetTargetWithPath("Naxxrammas 25/Thaddius")
+	if preset == nil {
+		t.Fatalf("Thaddius preset not found")
+	}
+	simDps := func(correctGroupChance float64, chargeStacks float64) float64 {
+		target := googleProto.Clone(preset.Config).(*proto.Target)
+		target.TargetInputs[0].NumberValue = correctGroupChance
+		target.TargetInputs[1].NumberValue = chargeStacks
+		rsr := fireMageRequest(&proto.SimOptions{
+			Iterations: 20,
+			RandomSeed: 101,
+		})
+		rsr.Encounter = &proto.Encounter{
+			Duration: 120,
+			Targets:  []*proto.Target{target},
+		}
+		result := core.RunRaidSim(rsr)
+		if result.ErrorResult != "" {
+			t.Fatalf("Sim failed with error: %s", result.ErrorResult)
+		}
+		return result.RaidMetrics.Dps.Avg
+	}
+
+	noStacksDps := simDps(1, 0)
+	correctDps := simDps(1, 5)
+	wrongDps := simDps(0, 5)
+	if correctDps <= noStacksDps {
+		t.Errorf("Expected charge stacks to increase dps, got %f vs %f", correctDps, noStacksDps)
+	}
+	if wrongDps >= correctDps {
+		t.Errorf("Expected standing with the wrong group to lose the damage buff, got %f vs %f", wrongDps, correctDps)
+	}
+}
+
+func TestLoathebSpores(t *testing.T) {
+	preset := core.GetPresetTargetWithPath("Naxxrammas 25/Loatheb")
+	if preset == nil {
+		t.Fatalf("Loatheb preset not found")
+	}
+	simDps := func(sporeGroupSize float64, sporeInterval float64) float64 {
+		target := googleProto.Clone(preset.Config).(*proto.Target)
+		target.TargetInputs[0].NumberValue = sporeGroupSize
+		target.TargetInputs[1].NumberValue = sporeInterval
+		rsr := fireMageRequest(&proto.SimOptions{
+			Iterations: 20,
+			RandomSeed: 101,
+		})
+		rsr.Encounter = &proto.Encounter{
+			Duration: 180,
+			Targets:  []*proto.Target{target},
+		}
+		result := core.RunRaidSim(rsr)
+		if result.ErrorResult != "" {
+			t.Fatalf("Sim failed with error: %s", result.ErrorResult)
+		}
+		return result.RaidMetrics.Dps.Avg
+	}
+
+	noSporesDps := simDps(5, 0)
+	// Every fifth spore buffs the player, so Fungal Creep is only up part of the time.
+	sporesDps := simDps(5, 30)
+	moreSporesDps := simDps(25, 30)
+	if sporesDps <= noSporesDps {
+		t.Errorf("Expected spores to increase dps, got %f vs %f", sporesDps, noSporesDps)
+	}
+	if moreSporesDps <= sporesDps {
+		t.Errorf("Expected every spore buffing the player to increase dps further, got %f vs %f", moreSporesDps, sporesDps)
+	}
+}
+
+func TestKelThuzadPhases(t *testing.T) {
+	var encounter *proto.PresetEncounter
+	for _, preset := range core.PresetEncounters {
+		if preset.Path == "Naxxrammas 25/Kel'Thuzad" {
+			encounter = preset
+		}
+	}
+	if encounter == nil {
+		t.Fatalf("Kel'Thuzad preset not found")
+	}
+
+	// Returns the damage the player dealt to each target, and the dps of each target.
+	simFight := func(duration float64) ([]float64, []float64) {
+		rsr := fireMageRequest(&proto.SimOptions{
+			Iterations: 5,
+			RandomSeed: 101,
+		})
+		rsr.Encounter = &proto.Encounter{
+			Duration: duration,
+		}
+		for _, target := range encounter.Targets {
+			rsr.Encounter.Targets = append(rsr.Encounter.Targets, target.Target)
+		}
+		result := core.RunRaidSim(rsr)
+		if result.ErrorResult != "" {
+			t.Fatalf("Sim failed with error: %s", result.ErrorResult)
+		}
+
+		damageTaken := make([]float64, len(encounter.Targets))
+		for _, action := range result.RaidMetrics.Parties[0].Players[0].Actions {
+			for _, target := range action.Targets {
+				if int(target.UnitIndex) < len(damageTaken) {
+					damageTaken[target.UnitIndex] += target.Damage
+				}
+			}
+		}
+		targetDps := make([]float64, len(encounter.Targets))
+		for i, target := range result.EncounterMetrics.Targets {
+			targetDps[i] = target.Dps.Avg
+		}
+		return damageTaken, targetDps
+	}
+
+	damageTaken, targetDps := simFight(200)
+	if damageTaken[0] != 0 || targetDps[0] != 0 {
+		t.Errorf("Expected Kel'Thuzad to stay out of phase 1, got %f damage taken and %f dps", damageTaken[0], targetDps[0])
+	}
+	if damageTaken[1] <= 0 {
+		t.Errorf("Expected the minions to be attacked in phase 1")
+	}
+
+	damageTaken, targetDps = simFight(400)
+	if damageTaken[0] <= 0 || targetDps[0] <= 0 {
+		t.Errorf("Expected Kel'Thuzad to join the fight in phase 2, got %f damage taken and %f dps", damageTaken[0], targetDps[0])
+	}
+}
+
+func TestScriptedEncounter(t *testing.T) {
+	preset := core.GetPresetTargetWithPath("SoD/Scripted Mechanics")
+	if preset == nil {
+		t.Fatalf("Scripted Mechanics preset not found")
+	}
+	rsr := fireMageRequest(&proto.SimOptions{
+		Iterations: 20,
+		RandomSeed: 101,
+	})
+	rsr.Encounter = &proto.Encounter{
+		Duration: 180,
+		Targets:  []*proto.Target{preset.Config},
+	}
+	if result := core.RunRaidSim(rsr); result.ErrorResult != "" {
+		t.Fatalf("Sim failed with error: %s", result.ErrorResult)
+	}
+}
@@ -91,5 +91,14 @@ func (rogue *Rogue) registerEviscerate() {
 
 			spell.DealDamage(sim, result)
 		},
+		ExpectedInitialDamage: func(sim *core.Simulation, target *core.Unit, spell *core.Spell, _ bool) *core.SpellResult {
+			comboPoints := rogue.ComboPoints()
+			flatBaseDamage := flatDamage + comboDamageBonus*float64(comboPoints)
+
+			baseDamage := flatBaseDamage + damageVariance/2 +
+				0.03*float64(comboPoints)*spell.MeleeAttackPower()
+
+			return spell.CalcDamage(sim, target, baseDamage, spell.OutcomeExpectedMeleeSpecialHitAndCrit)
+		},
 	})
 }
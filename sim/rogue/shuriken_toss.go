@@ -36,6 +36,7 @@ func (rogue *Rogue) registerShurikenTossSpell() {
 		ThreatMultiplier: 1,
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+			results := sim.Environment.LimitToActiveTargets(results)
 			rogue.BreakStealth(sim)
 			baseDamage := spell.MeleeAttackPower() * 0.15
 
@@ -82,6 +82,7 @@ func (shaman *Shaman) newChainLightningSpellConfig(rank int, cdTimer *core.Timer
 	results := make([]*core.SpellResult, min(ChainLightningTargetCount, shaman.Env.GetNumTargets()))
 
 	spell.ApplyEffects = func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+		results := sim.Environment.LimitToActiveTargets(results)
 		origMult := spell.DamageMultiplier
 		for hitIndex := range results {
 			baseDamage := sim.Roll(baseDamageLow, baseDamageHigh)
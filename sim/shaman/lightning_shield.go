@@ -133,7 +133,7 @@ func (shaman *Shaman) newLightningShieldSpellConfig(rank int) core.SpellConfig {
 
 			if hasOverchargedRune {
 				// Deals damage to all targets within 8 yards and does not lose stacks
-				for _, aoeTarget := range sim.Encounter.TargetUnits {
+				for _, aoeTarget := range sim.Encounter.ActiveTargetUnits {
 					if aoeTarget.DistanceFromTarget <= 8 {
 						procSpell.Cast(sim, aoeTarget)
 					}
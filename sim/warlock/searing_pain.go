@@ -51,6 +51,9 @@ func (warlock *Warlock) getSearingPainBaseConfig(rank int) core.SpellConfig {
 		ThreatMultiplier:         2,
 		BonusCoefficient:         spellCoeff,
 
+		ExpectedInitialDamage: func(sim *core.Simulation, target *core.Unit, spell *core.Spell, _ bool) *core.SpellResult {
+			return spell.CalcDamage(sim, target, (baseDamage[0]+baseDamage[1])/2, spell.OutcomeExpectedMagicHitAndCrit)
+		},
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
 			damage := sim.Roll(baseDamage[0], baseDamage[1])
 			spell.CalcAndDealDamage(sim, target, damage, spell.OutcomeMagicHitAndCrit)
@@ -52,7 +52,11 @@ func (warlock *Warlock) getShadowBoltBaseConfig(rank int) core.SpellConfig {
 		ThreatMultiplier:         1,
 		BonusCoefficient:         spellCoeff,
 
+		ExpectedInitialDamage: func(sim *core.Simulation, target *core.Unit, spell *core.Spell, _ bool) *core.SpellResult {
+			return spell.CalcDamage(sim, target, (baseDamage[0]+baseDamage[1])/2, spell.OutcomeExpectedMagicHitAndCrit)
+		},
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+			results := sim.Environment.LimitToActiveTargets(results)
 			for idx := range results {
 				damage := sim.Roll(baseDamage[0], baseDamage[1])
 				results[idx] = spell.CalcDamage(sim, target, damage, spell.OutcomeMagicHitAndCrit)
@@ -81,7 +81,7 @@ func (warlock *Warlock) registerShadowflameSpell() {
 		BonusCoefficient:         baseSpellCoeff,
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
-			for _, aoeTarget := range sim.Encounter.TargetUnits {
+			for _, aoeTarget := range sim.Encounter.ActiveTargetUnits {
 				result := spell.CalcAndDealDamage(sim, aoeTarget, baseDamage, spell.OutcomeMagicHitAndCrit)
 				if result.Landed() {
 					warlock.ShadowflameDot.Cast(sim, aoeTarget)
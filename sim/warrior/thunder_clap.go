@@ -57,6 +57,7 @@ func (warrior *Warrior) registerThunderClapSpell() {
 		ThreatMultiplier: core.TernaryFloat64(hasFuriousThunder, 2.5*1.5, 2.5),
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+			results := sim.Environment.LimitToActiveTargets(results)
 			for idx := range results {
 				results[idx] = spell.CalcDamage(sim, target, info.baseDamage, spell.OutcomeMagicHitAndCrit)
 				target = sim.Environment.NextTargetUnit(target)
@@ -97,6 +97,12 @@ var handlers = map[string]apiHandler{
 	"/statWeights": {msg: func() googleProto.Message { return &proto.StatWeightsRequest{} }, handle: func(msg googleProto.Message) googleProto.Message {
 		return core.StatWeights(msg.(*proto.StatWeightsRequest))
 	}},
+	"/compareSims": {msg: func() googleProto.Message { return &proto.CompareSimsRequest{} }, handle: func(msg googleProto.Message) googleProto.Message {
+		return core.RunCompareSims(msg.(*proto.CompareSimsRequest))
+	}},
+	"/tuneRotation": {msg: func() googleProto.Message { return &proto.TuneRotationRequest{} }, handle: func(msg googleProto.Message) googleProto.Message {
+		return core.RunTuneRotation(msg.(*proto.TuneRotationRequest))
+	}},
 	"/computeStats": {msg: func() googleProto.Message { return &proto.ComputeStatsRequest{} }, handle: func(msg googleProto.Message) googleProto.Message {
 		return core.ComputeStats(msg.(*proto.ComputeStatsRequest))
 	}},
@@ -109,6 +115,12 @@ var asyncAPIHandlers = map[string]asyncAPIHandler{
 	"/statWeightsAsync": {msg: func() googleProto.Message { return &proto.StatWeightsRequest{} }, handle: func(msg googleProto.Message, reporter chan *proto.ProgressMetrics) {
 		core.StatWeightsAsync(msg.(*proto.StatWeightsRequest), reporter)
 	}},
+	"/compareSimsAsync": {msg: func() googleProto.Message { return &proto.CompareSimsRequest{} }, handle: func(msg googleProto.Message, reporter chan *proto.ProgressMetrics) {
+		core.RunCompareSimsAsync(msg.(*proto.CompareSimsRequest), reporter)
+	}},
+	"/tuneRotationAsync": {msg: func() googleProto.Message { return &proto.TuneRotationRequest{} }, handle: func(msg googleProto.Message, reporter chan *proto.ProgressMetrics) {
+		core.RunTuneRotationAsync(context.Background(), msg.(*proto.TuneRotationRequest), reporter)
+	}},
 	"/bulkSimAsync": {msg: func() googleProto.Message { return &proto.BulkSimRequest{} }, handle: func(msg googleProto.Message, reporter chan *proto.ProgressMetrics) {
 		// TODO: we can use context's to cancel stuff.
 		// We should have all the async APIs take in context and let it be cancelled via its async ID.
@@ -194,7 +206,7 @@ func (s *server) handleAsyncAPI(w http.ResponseWriter, r *http.Request) {
 					return
 				}
 				simProgress.latestProgress.Store(progMetric)
-				if progMetric.FinalRaidResult != nil || progMetric.FinalWeightResult != nil || progMetric.FinalBulkResult != nil {
+				if progMetric.FinalRaidResult != nil || progMetric.FinalWeightResult != nil || progMetric.FinalBulkResult != nil || progMetric.FinalCompareResult != nil || progMetric.FinalTuneResult != nil {
 					return
 				}
 			}
@@ -254,7 +266,7 @@ func (s *server) setupAsyncServer() {
 		}
 
 		// If this was the last result, delete the cache for this simulation.
-		if latest.FinalRaidResult != nil || latest.FinalWeightResult != nil || latest.FinalBulkResult != nil {
+		if latest.FinalRaidResult != nil || latest.FinalWeightResult != nil || latest.FinalBulkResult != nil || latest.FinalCompareResult != nil || latest.FinalTuneResult != nil {
 			s.progMut.Lock()
 			delete(s.asyncProgresses, msg.ProgressId)
 			s.progMut.Unlock()
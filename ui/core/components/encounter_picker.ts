@@ -620,6 +620,57 @@ function addEncounterFieldPickers(rootElem: HTMLElement, encounter: Encounter, s
 		},
 	});
 
+	const movementGroup = Input.newGroupContainer();
+	rootElem.appendChild(movementGroup);
+
+	new NumberPicker(movementGroup, encounter, {
+		label: 'Movement Interval',
+		labelTooltip: 'Average time, in seconds, between random windows where every player has to move. 0 for no random movement.',
+		changedEvent: (encounter: Encounter) => encounter.movementChangeEmitter,
+		getValue: (encounter: Encounter) => encounter.getMovement().randomInterval,
+		setValue: (eventID: EventID, encounter: Encounter, newValue: number) => {
+			const movement = encounter.getMovement();
+			movement.randomInterval = newValue;
+			encounter.setMovement(eventID, movement);
+		},
+	});
+	new NumberPicker(movementGroup, encounter, {
+		label: 'Movement Interval +/-',
+		labelTooltip: 'Adds a random amount of time, in seconds, between [value, -1 * value] to each movement interval.',
+		changedEvent: (encounter: Encounter) => encounter.movementChangeEmitter,
+		getValue: (encounter: Encounter) => encounter.getMovement().randomIntervalVariation,
+		setValue: (eventID: EventID, encounter: Encounter, newValue: number) => {
+			const movement = encounter.getMovement();
+			movement.randomIntervalVariation = newValue;
+			encounter.setMovement(eventID, movement);
+		},
+		enableWhen: (encounter: Encounter) => encounter.getMovement().randomInterval > 0,
+	});
+	new NumberPicker(movementGroup, encounter, {
+		label: 'Movement Duration',
+		labelTooltip: 'How long each random movement window lasts, in seconds. Hardcasts are interrupted when it starts.',
+		changedEvent: (encounter: Encounter) => encounter.movementChangeEmitter,
+		getValue: (encounter: Encounter) => encounter.getMovement().randomDuration,
+		setValue: (eventID: EventID, encounter: Encounter, newValue: number) => {
+			const movement = encounter.getMovement();
+			movement.randomDuration = newValue;
+			encounter.setMovement(eventID, movement);
+		},
+		enableWhen: (encounter: Encounter) => encounter.getMovement().randomInterval > 0,
+	});
+	new NumberPicker(movementGroup, encounter, {
+		label: 'Movement Distance',
+		labelTooltip: 'How far players run away from the target, in yards, before running back. Melee attacks stop while out of range. 0 to move in place.',
+		changedEvent: (encounter: Encounter) => encounter.movementChangeEmitter,
+		getValue: (encounter: Encounter) => encounter.getMovement().randomDistance,
+		setValue: (eventID: EventID, encounter: Encounter, newValue: number) => {
+			const movement = encounter.getMovement();
+			movement.randomDistance = newValue;
+			encounter.setMovement(eventID, movement);
+		},
+		enableWhen: (encounter: Encounter) => encounter.getMovement().randomInterval > 0,
+	});
+
 	if (showExecuteProportion) {
 		const executeGroup = Input.newGroupContainer();
 		executeGroup.classList.add('execute-group');
@@ -5,6 +5,7 @@ import {
 	APLActionActivateAura,
 	APLActionActivateAuraWithStacks,
 	APLActionAutocastOtherCooldowns,
+	APLActionCallActionList,
 	APLActionCancelAura,
 	APLActionCastPaladinPrimarySeal,
 	APLActionCastSpell,
@@ -18,10 +19,13 @@ import {
 	APLActionMultidot,
 	APLActionMultishield,
 	APLActionResetSequence,
+	APLActionRunActionList,
 	APLActionSchedule,
 	APLActionSequence,
+	APLActionSetVariable,
 	APLActionStrictSequence,
 	APLActionTriggerICD,
+	APLActionUseItemSlot,
 	APLActionWait,
 	APLActionWaitUntil,
 	APLValue,
@@ -432,6 +436,20 @@ const actionKindFactories: { [f in NonNullable<APLActionKind>]: ActionKindConfig
 			}),
 		],
 	}),
+	['useItemSlot']: inputBuilder({
+		label: 'Use Item Slot',
+		submenu: ['Casting'],
+		shortDescription: 'Uses the on-use effect of the item equipped in the slot, if it is ready.',
+		fullDescription: `
+			<ul>
+				<li>Unlike <b>Cast</b>, works with whichever item is equipped, so the rotation doesn't need to change with gear.</li>
+				<li>The item is no longer used by <b>Autocast Other Cooldowns</b>.</li>
+			</ul>
+		`,
+		includeIf: (player: Player<any>, isPrepull: boolean) => !isPrepull,
+		newValue: APLActionUseItemSlot.create,
+		fields: [AplHelpers.itemSlotFieldConfig('itemSlot')],
+	}),
 	['autocastOtherCooldowns']: inputBuilder({
 		label: 'Autocast Other Cooldowns',
 		submenu: ['Casting'],
@@ -527,6 +545,28 @@ const actionKindFactories: { [f in NonNullable<APLActionKind>]: ActionKindConfig
 		newValue: APLActionStrictSequence.create,
 		fields: [actionListFieldConfig('actions')],
 	}),
+	['callActionList']: inputBuilder({
+		label: 'Call Action List',
+		submenu: ['Action Lists'],
+		shortDescription: 'Performs the first ready action of a named action list, or moves on to the next action if none are ready.',
+		fullDescription: `
+			<p>Use the <b>name</b> field to refer to an action list declared by the rotation.</p>
+		`,
+		includeIf: (player: Player<any>, isPrepull: boolean) => !isPrepull,
+		newValue: () => APLActionCallActionList.create(),
+		fields: [AplHelpers.stringFieldConfig('name')],
+	}),
+	['runActionList']: inputBuilder({
+		label: 'Run Action List',
+		submenu: ['Action Lists'],
+		shortDescription: 'Performs the first ready action of a named action list. Actions after this one are never considered.',
+		fullDescription: `
+			<p>Use the <b>name</b> field to refer to an action list declared by the rotation. If no action in the list is ready, the rotation waits instead of moving on.</p>
+		`,
+		includeIf: (player: Player<any>, isPrepull: boolean) => !isPrepull,
+		newValue: () => APLActionRunActionList.create(),
+		fields: [AplHelpers.stringFieldConfig('name')],
+	}),
 	['changeTarget']: inputBuilder({
 		label: 'Change Target',
 		submenu: ['Misc'],
@@ -594,6 +634,18 @@ const actionKindFactories: { [f in NonNullable<APLActionKind>]: ActionKindConfig
 			}),
 		],
 	}),
+	['setVariable']: inputBuilder({
+		label: 'Set Variable',
+		submenu: ['Misc'],
+		shortDescription: 'Stores the current result of a value in a variable, until the end of the iteration.',
+		fullDescription: `
+			<p>The variable must be declared by the rotation. Once set, reading the variable returns the stored result instead of evaluating its expression.</p>
+			<p>Setting a variable doesn't use up the decision, so the actions after it are still considered and already see the new value.</p>
+		`,
+		includeIf: (player: Player<any>, isPrepull: boolean) => !isPrepull,
+		newValue: () => APLActionSetVariable.create(),
+		fields: [AplHelpers.stringFieldConfig('name'), AplValues.valueFieldConfig('value')],
+	}),
 	['customRotation']: inputBuilder({
 		label: 'Custom Rotation',
 		//submenu: ['Misc'],
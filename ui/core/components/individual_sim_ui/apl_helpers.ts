@@ -2,11 +2,11 @@ import { Player, UnitMetadata } from '../../player.js';
 import { ActionID, ItemSlot, OtherAction, UnitReference, UnitReference_Type as UnitType } from '../../proto/common.js';
 import { UIRune as Rune } from '../../proto/ui.js';
 import { ActionId, defaultTargetIcon, getPetIconFromName } from '../../proto_utils/action_id.js';
-import { itemTypeNames } from '../../proto_utils/names.js';
+import { itemTypeNames, slotNames } from '../../proto_utils/names.js';
 import { EventID, TypedEvent } from '../../typed_event.js';
 import { bucket } from '../../utils.js';
 import { BooleanPicker } from '../boolean_picker.js';
-import { DropdownPicker, DropdownPickerConfig, DropdownValueConfig } from '../dropdown_picker.js';
+import { DropdownPicker, DropdownPickerConfig, DropdownValueConfig, TextDropdownPicker } from '../dropdown_picker.js';
 import { Input, InputConfig } from '../input.js';
 import { AdaptiveStringPicker } from '../inputs/string_picker.js';
 import { NumberPicker, NumberPickerConfig } from '../number_picker.js';
@@ -676,6 +676,20 @@ export function stringFieldConfig(field: string, options?: Partial<APLPickerBuil
 	};
 }
 
+export function itemSlotFieldConfig(field: string): APLPickerBuilderFieldConfig<any, any> {
+	return {
+		field: field,
+		newValue: () => ItemSlot.ItemSlotTrinket1,
+		factory: (parent, player, config) =>
+			new TextDropdownPicker(parent, player, {
+				...config,
+				defaultLabel: 'None',
+				equals: (a, b) => a == b,
+				values: Array.from(slotNames.entries()).map(([slot, name]) => ({ value: slot, label: name })),
+			}),
+	};
+}
+
 /*
 export function runeTypeFieldConfig(field: string, includeDeath: boolean): APLPickerBuilderFieldConfig<any, any> {
 
@@ -32,11 +32,15 @@ import {
 	APLValueCurrentTimePercent,
 	APLValueDotIsActive,
 	APLValueDotRemainingTime,
+	APLValueExternalCooldownTimeToNext,
+	APLValueForcedMovementTimeToNext,
 	APLValueFrontOfTarget,
 	APLValueGCDIsReady,
 	APLValueGCDTimeToReady,
 	APLValueIsExecutePhase,
 	APLValueIsExecutePhase_ExecutePhaseThreshold as ExecutePhaseThreshold,
+	APLValueItemSlotIsReady,
+	APLValueItemSlotTimeToReady,
 	APLValueMath,
 	APLValueMath_MathOperator as MathOperator,
 	APLValueMax,
@@ -55,17 +59,23 @@ import {
 	APLValueSpellChanneledTicks,
 	APLValueSpellCPM,
 	APLValueSpellCurrentCost,
+	APLValueSpellExpectedDamage,
+	APLValueSpellExpectedDamagePerCastTime,
 	APLValueSpellIsChanneling,
 	APLValueSpellIsKnown,
 	APLValueSpellIsReady,
 	APLValueSpellTimeToReady,
 	APLValueSpellTravelTime,
+	APLValueTargetHealthPercent,
+	APLValueTargetTimeToDie,
 	APLValueTimeToEnergyTick,
+	APLValueTimeToHealthPercent,
 	APLValueTotemRemainingTime,
+	APLValueVariable,
 	APLValueWarlockShouldRecastDrainSoul,
 	APLValueWarlockShouldRefreshCorruption,
 } from '../../proto/apl.js';
-import { Class, Spec } from '../../proto/common.js';
+import { Class, ExternalCooldown, Spec } from '../../proto/common.js';
 import { ShamanTotems_TotemType as TotemType } from '../../proto/shaman.js';
 import { EventID } from '../../typed_event.js';
 import { TextDropdownPicker, TextDropdownValueConfig } from '../dropdown_picker.js';
@@ -380,6 +390,24 @@ function executePhaseThresholdFieldConfig(field: string): AplHelpers.APLPickerBu
 	};
 }
 
+function externalCooldownFieldConfig(field: string): AplHelpers.APLPickerBuilderFieldConfig<any, any> {
+	return {
+		field: field,
+		newValue: () => ExternalCooldown.ExternalCooldownPowerInfusion,
+		factory: (parent, player, config) =>
+			new TextDropdownPicker(parent, player, {
+				...config,
+				defaultLabel: 'None',
+				equals: (a, b) => a == b,
+				values: [
+					{ value: ExternalCooldown.ExternalCooldownPowerInfusion, label: 'Power Infusion' },
+					{ value: ExternalCooldown.ExternalCooldownInnervate, label: 'Innervate' },
+					{ value: ExternalCooldown.ExternalCooldownBloodlust, label: 'Bloodlust' },
+				],
+			}),
+	};
+}
+
 function totemTypeFieldConfig(field: string): AplHelpers.APLPickerBuilderFieldConfig<any, any> {
 	return {
 		field: field,
@@ -577,6 +605,47 @@ const valueKindFactories: { [f in NonNullable<APLValueKind>]: ValueKindConfig<AP
 		newValue: APLValueNumberTargets.create,
 		fields: [],
 	}),
+	targetHealthPercent: inputBuilder({
+		label: 'Target Health (%)',
+		submenu: ['Encounter'],
+		shortDescription: 'Remaining Health of the unit, as a percentage.',
+		fullDescription: `
+		<p>Targets without Health are assumed to lose it evenly over the encounter, the same way Execute Phases are decided.</p>
+		`,
+		newValue: APLValueTargetHealthPercent.create,
+		fields: [AplHelpers.unitFieldConfig('targetUnit', 'aura_sources_targets_first')],
+	}),
+	targetTimeToDie: inputBuilder({
+		label: 'Target Time to Die',
+		submenu: ['Encounter'],
+		shortDescription: 'Estimated time until the target dies.',
+		fullDescription: `
+		<p>Based on the damage the target has taken since it spawned, and never longer than the rest of the encounter. Until the target has taken any damage, this is the remaining time of the encounter.</p>
+		<p>Useful for skipping DoTs on targets which will die before they finish ticking.</p>
+		`,
+		newValue: APLValueTargetTimeToDie.create,
+		fields: [AplHelpers.unitFieldConfig('targetUnit', 'targets')],
+	}),
+	timeToHealthPercent: inputBuilder({
+		label: 'Time to Health (%)',
+		submenu: ['Encounter'],
+		shortDescription: 'Estimated time until the target is at or below the given Health percentage, or 0 if it already is.',
+		fullDescription: `
+		<p>Estimated in the same way as <b>Target Time to Die</b>.</p>
+		`,
+		newValue: APLValueTimeToHealthPercent.create,
+		fields: [valueFieldConfig('healthPercent'), AplHelpers.unitFieldConfig('targetUnit', 'targets')],
+	}),
+	forcedMovementTimeToNext: inputBuilder({
+		label: 'Forced Movement Time to Next',
+		submenu: ['Encounter'],
+		shortDescription: 'Time until the encounter next makes players move, or 0 while they are moving.',
+		fullDescription: `
+		<p>Useful for casting instants instead of spells that would be interrupted by the movement.</p>
+		`,
+		newValue: APLValueForcedMovementTimeToNext.create,
+		fields: [],
+	}),
 	frontOfTarget: inputBuilder({
 		label: 'Front of Target',
 		submenu: ['Encounter'],
@@ -755,6 +824,23 @@ const valueKindFactories: { [f in NonNullable<APLValueKind>]: ValueKindConfig<AP
 		newValue: APLValueSpellChanneledTicks.create,
 		fields: [AplHelpers.actionIdFieldConfig('spellId', 'channel_spells', '')],
 	}),
+	spellExpectedDamage: inputBuilder({
+		label: 'Expected Damage',
+		submenu: ['Spell'],
+		shortDescription: 'Average damage of a full cast of the spell on the target, including any DoT or channel ticks.',
+		fullDescription: `
+		<p>Accounts for hit, crit and resistances, and the current auras and debuffs of the caster and target. Only some spells support this.</p>
+		`,
+		newValue: APLValueSpellExpectedDamage.create,
+		fields: [AplHelpers.actionIdFieldConfig('spellId', 'castable_spells', ''), AplHelpers.unitFieldConfig('targetUnit', 'targets')],
+	}),
+	spellExpectedDamagePerCastTime: inputBuilder({
+		label: 'Expected Damage per Cast Time',
+		submenu: ['Spell'],
+		shortDescription: '<b>Expected Damage</b> divided by the time the cast takes, including any channel, or the GCD if that is longer.',
+		newValue: APLValueSpellExpectedDamagePerCastTime.create,
+		fields: [AplHelpers.actionIdFieldConfig('spellId', 'castable_spells', ''), AplHelpers.unitFieldConfig('targetUnit', 'targets')],
+	}),
 	channelClipDelay: inputBuilder({
 		label: 'Channel Clip Delay',
 		submenu: ['Spell'],
@@ -763,6 +849,22 @@ const valueKindFactories: { [f in NonNullable<APLValueKind>]: ValueKindConfig<AP
 		fields: [],
 	}),
 
+	// External cooldowns
+	externalCooldownTimeToNext: inputBuilder({
+		label: 'External Cooldown Time To Next',
+		submenu: ['External Cooldown'],
+		shortDescription: 'Amount of time until the raid cooldown plan will next cast the buff on this player at a planned time.',
+		fullDescription: `
+			<ul>
+				<li>For buffs planned at fixed times, the time until the next planned cast.</li>
+				<li>A very long time for buffs planned on a condition, as it isn't known when the condition will be true.</li>
+				<li>A very long time if the buff is not planned for this player, so rotations also work without a plan.</li>
+			</ul>
+		`,
+		newValue: APLValueExternalCooldownTimeToNext.create,
+		fields: [externalCooldownFieldConfig('cooldown')],
+	}),
+
 	// Auras
 	auraIsKnown: inputBuilder({
 		label: 'Aura Known',
@@ -853,6 +955,22 @@ const valueKindFactories: { [f in NonNullable<APLValueKind>]: ValueKindConfig<AP
 		fields: [AplHelpers.runeFieldConfig('runeId')],
 	}),
 
+	// Items
+	itemSlotIsReady: inputBuilder({
+		label: 'Item Slot Is Ready',
+		submenu: ['Item'],
+		shortDescription: '<b>True</b> if the on-use effect of the item equipped in the slot is off cooldown, otherwise <b>False</b>.',
+		newValue: APLValueItemSlotIsReady.create,
+		fields: [AplHelpers.itemSlotFieldConfig('itemSlot')],
+	}),
+	itemSlotTimeToReady: inputBuilder({
+		label: 'Item Slot Time To Ready',
+		submenu: ['Item'],
+		shortDescription: 'Amount of time remaining before the on-use effect of the item equipped in the slot is off cooldown, or a very long time if the item has no on-use effect.',
+		newValue: APLValueItemSlotTimeToReady.create,
+		fields: [AplHelpers.itemSlotFieldConfig('itemSlot')],
+	}),
+
 	// DoT
 	dotIsActive: inputBuilder({
 		label: 'Dot Is Active',
@@ -890,6 +1008,15 @@ const valueKindFactories: { [f in NonNullable<APLValueKind>]: ValueKindConfig<AP
 		fields: [AplHelpers.stringFieldConfig('sequenceName')],
 	}),
 
+	// Variables
+	variable: inputBuilder({
+		label: 'Variable',
+		submenu: ['Variable'],
+		shortDescription: 'Returns the current value of a variable declared by the rotation.',
+		newValue: APLValueVariable.create,
+		fields: [AplHelpers.stringFieldConfig('name')],
+	}),
+
 	// Class/spec specific values
 	totemRemainingTime: inputBuilder({
 		label: 'Totem Remaining Time',
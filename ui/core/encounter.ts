@@ -1,6 +1,7 @@
 import { UnitMetadataList } from './player.js';
 import {
 	Encounter as EncounterProto,
+	EncounterMovement,
 	Target as TargetProto,
 	PresetEncounter,
 	PresetTarget,
@@ -20,6 +21,7 @@ export class Encounter {
 	private executeProportion25: number = 0.25;
 	private executeProportion35: number = 0.35;
 	private useHealth: boolean = false;
+	private movement: EncounterMovement = EncounterMovement.create();
 
 	targets!: Array<TargetProto>;
 	targetsMetadata: UnitMetadataList;
@@ -28,6 +30,7 @@ export class Encounter {
 	readonly targetsChangeEmitter = new TypedEvent<void>();
 	readonly durationChangeEmitter = new TypedEvent<void>();
 	readonly executeProportionChangeEmitter = new TypedEvent<void>();
+	readonly movementChangeEmitter = new TypedEvent<void>();
 
 	// Emits when any of the above emitters emit.
 	readonly changeEmitter = new TypedEvent<void>();
@@ -46,6 +49,7 @@ export class Encounter {
 				this.targetsChangeEmitter,
 				this.durationChangeEmitter,
 				this.executeProportionChangeEmitter,
+				this.movementChangeEmitter,
 			].forEach(emitter => emitter.on(eventID => this.changeEmitter.emit(eventID)));
 		})
 	}
@@ -119,6 +123,17 @@ export class Encounter {
 		this.executeProportionChangeEmitter.emit(eventID);
 	}
 
+	getMovement(): EncounterMovement {
+		return EncounterMovement.clone(this.movement);
+	}
+	setMovement(eventID: EventID, newMovement: EncounterMovement) {
+		if (EncounterMovement.equals(newMovement, this.movement))
+			return;
+
+		this.movement = EncounterMovement.clone(newMovement);
+		this.movementChangeEmitter.emit(eventID);
+	}
+
 	matchesPreset(preset: PresetEncounter): boolean {
 		return preset.targets.length == this.targets.length && this.targets.every((t, i) => TargetProto.equals(t, preset.targets[i].target));
 	}
@@ -142,6 +157,7 @@ export class Encounter {
 			executeProportion35: this.executeProportion35,
 			useHealth: this.useHealth,
 			targets: this.targets,
+			movement: this.movement,
 		});
 	}
 
@@ -153,6 +169,7 @@ export class Encounter {
 			this.setExecuteProportion25(eventID, proto.executeProportion25);
 			this.setExecuteProportion35(eventID, proto.executeProportion35);
 			this.setUseHealth(eventID, proto.useHealth);
+			this.setMovement(eventID, proto.movement || EncounterMovement.create());
 			this.targets = proto.targets;
 			this.targetsChangeEmitter.emit(eventID);
 		});